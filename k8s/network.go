@@ -1,9 +1,13 @@
 package k8s
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -11,14 +15,19 @@ import (
 	"github.com/ava-labs/avalanche-network-runner/api"
 	"github.com/ava-labs/avalanche-network-runner/network"
 	"github.com/ava-labs/avalanche-network-runner/network/node"
+	"github.com/ava-labs/avalanche-network-runner/utils"
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/constants"
 	"github.com/ava-labs/avalanchego/utils/logging"
 	"golang.org/x/sync/errgroup"
 
 	k8sapi "github.com/ava-labs/avalanchego-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
 	k8scli "sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -30,6 +39,8 @@ const (
 	nodeReachableRetryFreq = 3 * time.Second
 	// Prefix the avalanchego-operator uses to pass params to avalanchego nodes
 	envVarPrefix = "AVAGO_"
+	// Longest line of pod logs GetNodeLogs reads
+	maxPodLogLineSize = 1024 * 1024
 )
 
 var _ network.Network = (*networkImpl)(nil)
@@ -41,6 +52,8 @@ type networkParams struct {
 	k8sClient     k8scli.Client
 	dnsChecker    dnsReachableChecker
 	apiClientFunc api.NewAPIClientF
+	podLogs       podLogStreamer
+	pods          podGetter
 }
 
 // networkImpl is the kubernetes data type representing a kubernetes network adapter.
@@ -55,6 +68,8 @@ type networkImpl struct {
 	// Node name --> The node.
 	// If there is a running k8s pod for a node, it's in [nodes]
 	nodes map[string]*Node
+	// Node name --> The config the node was last (re)started with.
+	nodeConfigs map[string]node.Config
 	// URI of the beacon node
 	// TODO allow multiple beacons
 	beaconURL string
@@ -64,6 +79,12 @@ type networkImpl struct {
 	dnsChecker dnsReachableChecker
 	// Create the K8s API client
 	apiClientFunc api.NewAPIClientF
+	// Gets the logs of the nodes' pods
+	podLogs podLogStreamer
+	// Gets the nodes' pods
+	pods podGetter
+	// Sends this network's events to subscribers
+	events *network.EventBroker
 }
 
 func newK8sClient() (k8scli.Client, error) {
@@ -76,6 +97,10 @@ func newK8sClient() (k8scli.Client, error) {
 	return k8scli.New(kubeconfig, k8scli.Options{Scheme: scheme})
 }
 
+func newClientset() (kubernetes.Interface, error) {
+	return kubernetes.NewForConfig(ctrl.GetConfigOrDie())
+}
+
 // If this function returns a nil error, you *must* eventually call
 // Stop() on the returned network. Failure to do so will cause old
 // state to linger in k8s.
@@ -93,8 +118,20 @@ func newNetwork(params networkParams) (network.Network, error) {
 		closedOnStopCh: make(chan struct{}),
 		log:            params.log,
 		nodes:          make(map[string]*Node, len(params.conf.NodeConfigs)),
+		nodeConfigs:    make(map[string]node.Config, len(params.conf.NodeConfigs)),
 		dnsChecker:     params.dnsChecker,
 		apiClientFunc:  params.apiClientFunc,
+		podLogs:        params.podLogs,
+		pods:           params.pods,
+		events:         network.NewEventBroker(),
+	}
+	// [createDeploymentFromConfig] validated these and gave them the network's flags
+	for _, nodeConfig := range params.conf.NodeConfigs {
+		k8sConf, err := objectSpecFromConfig(nodeConfig)
+		if err != nil {
+			return nil, err
+		}
+		net.nodeConfigs[k8sConf.Identifier] = nodeConfig
 	}
 	net.log.Debug("launching beacon nodes...")
 	// Start the beacon nodes and wait until they're reachable
@@ -139,6 +176,10 @@ func NewNetwork(log logging.Logger, conf network.Config) (network.Network, error
 	if err != nil {
 		return nil, fmt.Errorf("couldn't create k8s client: %w", err)
 	}
+	clientset, err := newClientset()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create k8s clientset: %w", err)
+	}
 	return newNetwork(networkParams{
 		conf:      conf,
 		log:       log,
@@ -146,6 +187,8 @@ func NewNetwork(log logging.Logger, conf network.Config) (network.Network, error
 		// TODO is there a better way to wait until the node is reachable?
 		dnsChecker:    &defaultDNSReachableChecker{},
 		apiClientFunc: api.NewAPIClient,
+		podLogs:       &defaultPodLogStreamer{clientset: clientset},
+		pods:          &defaultPodGetter{clientset: clientset},
 	})
 }
 
@@ -194,12 +237,15 @@ func (a *networkImpl) Healthy(ctx context.Context) chan error {
 					case <-a.closedOnStopCh:
 						return network.ErrStopped
 					case <-ctx.Done():
-						return fmt.Errorf("node %q failed to become healthy within timeout", node.GetName())
+						err := fmt.Errorf("node %q failed to become healthy within timeout", node.GetName())
+						a.events.Publish(network.Event{Type: network.NodeUnhealthy, NodeName: node.GetName(), Err: err})
+						return err
 					case <-time.After(healthCheckFreq):
 					}
 					health, err := node.apiClient.HealthAPI().Health(ctx)
 					if err == nil && health.Healthy {
 						a.log.Info("node %q became healthy", node.GetName())
+						a.events.Publish(network.Event{Type: network.NodeHealthy, NodeName: node.GetName()})
 						return nil
 					}
 				}
@@ -214,6 +260,56 @@ func (a *networkImpl) Healthy(ctx context.Context) chan error {
 	return errCh
 }
 
+// See network.Network
+func (a *networkImpl) HealthReport(ctx context.Context) (map[string]network.NodeHealth, error) {
+	a.nodesLock.RLock()
+	if a.isStopped() {
+		a.nodesLock.RUnlock()
+		return nil, network.ErrStopped
+	}
+	// Node name --> Its API client, or nil if it isn't reachable yet
+	clients := make(map[string]api.Client, len(a.nodes))
+	for name, node := range a.nodes {
+		clients[name] = node.apiClient
+	}
+	a.nodesLock.RUnlock()
+
+	var (
+		reportLock sync.Mutex
+		report     = make(map[string]network.NodeHealth, len(clients))
+		wg         sync.WaitGroup
+	)
+	for name, client := range clients {
+		name, client := name, client
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var nodeHealth network.NodeHealth
+			if client == nil {
+				nodeHealth = network.NewNodeHealth(nil, fmt.Errorf("node %q isn't reachable yet", name))
+			} else {
+				nodeHealth = network.NewNodeHealth(client.HealthAPI().Health(ctx))
+			}
+			reportLock.Lock()
+			report[name] = nodeHealth
+			reportLock.Unlock()
+		}()
+	}
+	wg.Wait()
+	return report, nil
+}
+
+// See network.Network
+func (a *networkImpl) WatchHealth(ctx context.Context, interval time.Duration) (<-chan network.HealthTransition, error) {
+	a.nodesLock.RLock()
+	defer a.nodesLock.RUnlock()
+
+	if a.isStopped() {
+		return nil, network.ErrStopped
+	}
+	return network.WatchHealth(ctx, a, interval, a.events)
+}
+
 // See network.Network
 func (a *networkImpl) Stop(ctx context.Context) error {
 	a.nodesLock.Lock()
@@ -231,8 +327,10 @@ func (a *networkImpl) Stop(ctx context.Context) error {
 			failCount++
 		}
 		delete(a.nodes, nodeName)
+		a.events.Publish(network.Event{Type: network.NodeRemoved, NodeName: nodeName})
 	}
 	close(a.closedOnStopCh)
+	a.events.Publish(network.Event{Type: network.NetworkStopped})
 	if failCount > 0 {
 		return fmt.Errorf("%d nodes failed shutting down", failCount)
 	}
@@ -263,6 +361,10 @@ func (a *networkImpl) AddNode(cfg node.Config) (node.Node, error) {
 		return nil, err
 	}
 
+	a.nodesLock.Lock()
+	a.nodeConfigs[nodeSpec.Name] = cfg
+	a.nodesLock.Unlock()
+
 	a.log.Debug("Launching new node %s to network...", cfg.Name)
 	if err := a.launchNodes([]*k8sapi.Avalanchego{nodeSpec}); err != nil {
 		return nil, err
@@ -291,11 +393,442 @@ func (a *networkImpl) RemoveNode(name string) error {
 		}
 		a.log.Info("Removed node %q", name)
 		delete(a.nodes, name)
+		delete(a.nodeConfigs, name)
+		a.events.Publish(network.Event{Type: network.NodeRemoved, NodeName: name})
 		return nil
 	}
 	return fmt.Errorf("node %q not found", name)
 }
 
+// See network.Network
+// The node's Avalanchego object is updated in place, so the
+// avalanchego-operator replaces the node's pod.
+func (a *networkImpl) RestartNode(ctx context.Context, name string, newConfig *node.Config) error {
+	a.nodesLock.RLock()
+	if a.isStopped() {
+		a.nodesLock.RUnlock()
+		return network.ErrStopped
+	}
+	n, ok := a.nodes[name]
+	if !ok {
+		a.nodesLock.RUnlock()
+		return fmt.Errorf("node %q not found", name)
+	}
+	nodeConfig, err := a.nodeConfigs[name].WithUpdates(newConfig)
+	if err != nil {
+		a.nodesLock.RUnlock()
+		return err
+	}
+	if newConfig != nil {
+		if newConfig.Flags != nil {
+			// Flags in the node's config take precedence over the network's
+			for flagName, flagVal := range a.config.Flags {
+				if _, ok := nodeConfig.Flags[flagName]; !ok {
+					nodeConfig.Flags[flagName] = flagVal
+				}
+			}
+		}
+		// Validated when the network was created
+		networkID, _ := utils.NetworkIDFromGenesis([]byte(a.config.Genesis))
+		if err := nodeConfig.Validate(networkID); err != nil {
+			a.nodesLock.RUnlock()
+			return fmt.Errorf("new config for node %q failed validation: %w", name, err)
+		}
+	}
+	nodeSpec, err := buildK8sObjSpec(a.log, []byte(a.config.Genesis), nodeConfig)
+	if err != nil {
+		a.nodesLock.RUnlock()
+		return err
+	}
+	if nodeSpec.Name != name {
+		a.nodesLock.RUnlock()
+		return fmt.Errorf("can't change identifier of node %q to %q", name, nodeSpec.Name)
+	}
+	if !nodeConfig.IsBeacon {
+		nodeSpec.Spec.BootstrapperURL = a.beaconURL
+	}
+	// Update requires the version of the object we're replacing
+	nodeSpec.ResourceVersion = n.k8sObjSpec.ResourceVersion
+	oldPodUID := n.podUID
+	a.nodesLock.RUnlock()
+
+	// Don't hold the lock during the API call
+	a.log.Info("restarting node %q", name)
+	if err := a.k8scli.Update(ctx, nodeSpec); err != nil {
+		return fmt.Errorf("k8scli.Update failed: %w", err)
+	}
+	a.nodesLock.Lock()
+	n, ok = a.nodes[name]
+	if !ok {
+		a.nodesLock.Unlock()
+		return fmt.Errorf("node %q was removed while restarting", name)
+	}
+	n.k8sObjSpec = nodeSpec
+	a.nodeConfigs[name] = nodeConfig
+	a.nodesLock.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, nodeReachableTimeout)
+	defer cancel()
+	return a.waitForNode(ctx, nodeSpec, oldPodUID)
+}
+
+// See network.Network
+// [newImageTag] is the tag of the AvalancheGo image to run the node with.
+func (a *networkImpl) UpgradeNode(ctx context.Context, name string, newImageTag string) (*network.NodeUpgrade, error) {
+	a.nodesLock.RLock()
+	if a.isStopped() {
+		a.nodesLock.RUnlock()
+		return nil, network.ErrStopped
+	}
+	n, ok := a.nodes[name]
+	if !ok {
+		a.nodesLock.RUnlock()
+		return nil, fmt.Errorf("node %q not found", name)
+	}
+	oldClient := n.apiClient
+	nodeConfig := a.nodeConfigs[name]
+	a.nodesLock.RUnlock()
+
+	// The node may not respond, e.g. if its pod is crashing,
+	// and that shouldn't keep it from being upgraded
+	oldVersion, err := oldClient.InfoAPI().GetNodeVersion(ctx)
+	if err != nil {
+		a.log.Warn("couldn't get version of node %q before upgrading it: %s", name, err)
+		oldVersion = nil
+	}
+	k8sConf, err := objectSpecFromConfig(nodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	upgrade := &network.NodeUpgrade{
+		NodeName:            name,
+		OldVersion:          oldVersion,
+		PreviousBinaryOrTag: k8sConf.Tag,
+	}
+	k8sConf.Tag = newImageTag
+	implSpecificConfig, err := json.Marshal(k8sConf)
+	if err != nil {
+		return nil, err
+	}
+	a.log.Info("upgrading node %q to image %s:%s", name, k8sConf.Image, newImageTag)
+	if err := a.RestartNode(ctx, name, &node.Config{ImplSpecificConfig: implSpecificConfig}); err != nil {
+		// The pod may have been replaced but not become reachable
+		a.nodesLock.RLock()
+		restarted := bytes.Equal(a.nodeConfigs[name].ImplSpecificConfig, implSpecificConfig)
+		a.nodesLock.RUnlock()
+		if restarted {
+			return upgrade, err
+		}
+		return nil, err
+	}
+
+	// The node's new pod has a new API client
+	a.nodesLock.RLock()
+	n, ok = a.nodes[name]
+	if !ok {
+		a.nodesLock.RUnlock()
+		return nil, fmt.Errorf("node %q was removed while upgrading", name)
+	}
+	newClient := n.apiClient
+	namespace, podName := n.k8sObjSpec.Namespace, n.k8sObjSpec.Spec.DeploymentName
+	a.nodesLock.RUnlock()
+	if err := network.WaitForHealthy(ctx, newClient); err != nil {
+		return upgrade, fmt.Errorf("node %q didn't become healthy after upgrade: %w", name, err)
+	}
+	// Make sure the version is read from a pod that runs the new image
+	pod, err := a.pods.GetPod(ctx, namespace, podName)
+	if err != nil {
+		return upgrade, fmt.Errorf("couldn't get pod of upgraded node %q: %w", name, err)
+	}
+	newImage := fmt.Sprintf("%s:%s", k8sConf.Image, newImageTag)
+	if !runsImage(pod, newImage) {
+		return upgrade, fmt.Errorf("pod of upgraded node %q doesn't run image %s", name, newImage)
+	}
+	upgrade.NewVersion, err = newClient.InfoAPI().GetNodeVersion(ctx)
+	if err != nil {
+		return upgrade, fmt.Errorf("couldn't get version of upgraded node %q: %w", name, err)
+	}
+	return upgrade, nil
+}
+
+// See network.Network
+func (a *networkImpl) RollingUpgrade(ctx context.Context, newImageTag string, opts network.RollingUpgradeOptions) ([]*network.NodeUpgrade, error) {
+	return network.RollingUpgrade(ctx, a, newImageTag, opts)
+}
+
+// See network.Network
+func (a *networkImpl) CreateSubnet(
+	ctx context.Context,
+	controlKeys []string,
+	threshold uint32,
+	validatorNodes []string,
+) (ids.ID, error) {
+	a.nodesLock.RLock()
+	if a.isStopped() {
+		a.nodesLock.RUnlock()
+		return ids.Empty, network.ErrStopped
+	}
+	validatorIDs := make([]ids.ShortID, len(validatorNodes))
+	for i, nodeName := range validatorNodes {
+		n, ok := a.nodes[nodeName]
+		if !ok {
+			a.nodesLock.RUnlock()
+			return ids.Empty, fmt.Errorf("node %q not found", nodeName)
+		}
+		validatorIDs[i] = n.nodeID
+	}
+	client := a.issuingClient()
+	a.nodesLock.RUnlock()
+	if client == nil {
+		return ids.Empty, errors.New("no reachable node to issue transactions through")
+	}
+
+	wallet, err := network.NewPChainWallet(ctx, client, a.config.FundedPrivateKey)
+	if err != nil {
+		return ids.Empty, err
+	}
+	defer a.closeWallet(wallet)
+	subnetID, err := wallet.CreateSubnet(ctx, controlKeys, threshold)
+	if err != nil {
+		return ids.Empty, err
+	}
+	a.log.Info("created subnet %s", subnetID)
+	for i, nodeID := range validatorIDs {
+		if _, err := wallet.AddSubnetValidator(ctx, subnetID, nodeID, network.SubnetValidatorWeight); err != nil {
+			return ids.Empty, fmt.Errorf("couldn't add node %q as validator of subnet %s: %w", validatorNodes[i], subnetID, err)
+		}
+		a.log.Info("added node %q as validator of subnet %s", validatorNodes[i], subnetID)
+	}
+
+	// Validators must be restarted to sync the subnet
+	newConfigs := make(map[string]*node.Config, len(validatorNodes))
+	a.nodesLock.RLock()
+	for _, nodeName := range validatorNodes {
+		nodeConfig, ok := a.nodeConfigs[nodeName]
+		if !ok {
+			a.nodesLock.RUnlock()
+			return ids.Empty, fmt.Errorf("node %q not found", nodeName)
+		}
+		flags, err := network.WhitelistSubnet(nodeConfig, subnetID)
+		if err != nil {
+			a.nodesLock.RUnlock()
+			return ids.Empty, fmt.Errorf("couldn't whitelist subnet for node %q: %w", nodeName, err)
+		}
+		newConfigs[nodeName] = &node.Config{Flags: flags}
+	}
+	a.nodesLock.RUnlock()
+	restartGr, restartCtx := errgroup.WithContext(ctx)
+	for nodeName, newConfig := range newConfigs {
+		nodeName, newConfig := nodeName, newConfig
+		restartGr.Go(func() error {
+			return a.RestartNode(restartCtx, nodeName, newConfig)
+		})
+	}
+	if err := restartGr.Wait(); err != nil {
+		return ids.Empty, err
+	}
+
+	// Wait until the validators' new pods are healthy
+	clients := make(map[string]api.Client, len(newConfigs))
+	a.nodesLock.RLock()
+	for nodeName := range newConfigs {
+		n, ok := a.nodes[nodeName]
+		if !ok {
+			a.nodesLock.RUnlock()
+			return ids.Empty, fmt.Errorf("node %q was removed while restarting", nodeName)
+		}
+		clients[nodeName] = n.apiClient
+	}
+	a.nodesLock.RUnlock()
+	healthGr, healthCtx := errgroup.WithContext(ctx)
+	for nodeName, client := range clients {
+		nodeName, client := nodeName, client
+		healthGr.Go(func() error {
+			if err := network.WaitForHealthy(healthCtx, client); err != nil {
+				return fmt.Errorf("node %q didn't become healthy after restart: %w", nodeName, err)
+			}
+			return nil
+		})
+	}
+	if err := healthGr.Wait(); err != nil {
+		return ids.Empty, err
+	}
+	return subnetID, nil
+}
+
+// See network.Network
+// VM plugins can't be installed on Kubernetes nodes, so [pluginBinaryPath]
+// must be empty and the nodes' image must already have the VM.
+func (a *networkImpl) CreateBlockchain(
+	ctx context.Context,
+	subnetID ids.ID,
+	vmID ids.ID,
+	name string,
+	genesis []byte,
+	pluginBinaryPath string,
+) (*network.Blockchain, error) {
+	if pluginBinaryPath != "" {
+		return nil, errors.New("can't install VM plugins on Kubernetes nodes; the nodes' image must have the VM")
+	}
+	a.nodesLock.RLock()
+	if a.isStopped() {
+		a.nodesLock.RUnlock()
+		return nil, network.ErrStopped
+	}
+	client := a.issuingClient()
+	a.nodesLock.RUnlock()
+	if client == nil {
+		return nil, errors.New("no reachable node to issue transactions through")
+	}
+	wallet, err := network.NewPChainWallet(ctx, client, a.config.FundedPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer a.closeWallet(wallet)
+	validatorIDs, err := wallet.SubnetValidators(ctx, subnetID)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := wallet.CreateBlockchain(ctx, subnetID, vmID, name, genesis)
+	if err != nil {
+		return nil, err
+	}
+	a.log.Info("created blockchain %s on subnet %s", chainID, subnetID)
+
+	blockchain := &network.Blockchain{
+		ID:        chainID,
+		SubnetID:  subnetID,
+		VMID:      vmID,
+		Clients:   make(map[string]api.Client),
+		Endpoints: make(map[string]string),
+	}
+	validators := ids.ShortSet{}
+	validators.Add(validatorIDs...)
+	a.nodesLock.RLock()
+	for name, n := range a.nodes {
+		if n.apiClient != nil && validators.Contains(n.nodeID) {
+			blockchain.Clients[name] = n.apiClient
+			blockchain.Endpoints[name] = network.ChainEndpoint(n.uri, defaultAPIPort, chainID)
+		}
+	}
+	a.nodesLock.RUnlock()
+	if len(blockchain.Clients) == 0 {
+		return nil, fmt.Errorf("subnet %s has no validators in this network", subnetID)
+	}
+
+	errGr, ctx := errgroup.WithContext(ctx)
+	for name, client := range blockchain.Clients {
+		name, client := name, client
+		errGr.Go(func() error {
+			if err := network.WaitForChain(ctx, client, chainID); err != nil {
+				return fmt.Errorf("node %q: %w", name, err)
+			}
+			return nil
+		})
+	}
+	if err := errGr.Wait(); err != nil {
+		return nil, err
+	}
+	return blockchain, nil
+}
+
+// See network.Network
+func (a *networkImpl) AddValidator(
+	ctx context.Context,
+	nodeName string,
+	stakeAmount uint64,
+	start time.Time,
+	end time.Time,
+	rewardAddr string,
+) (ids.ID, error) {
+	wallet, nodeID, err := a.stakingWallet(ctx, nodeName)
+	if err != nil {
+		return ids.Empty, err
+	}
+	defer a.closeWallet(wallet)
+	txID, err := wallet.AddValidator(ctx, nodeID, stakeAmount, start, end, rewardAddr)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("couldn't add node %q as validator: %w", nodeName, err)
+	}
+	a.log.Info("added node %q as validator in tx %s", nodeName, txID)
+	return txID, nil
+}
+
+// See network.Network
+func (a *networkImpl) AddDelegator(
+	ctx context.Context,
+	nodeName string,
+	stakeAmount uint64,
+	start time.Time,
+	end time.Time,
+	rewardAddr string,
+) (ids.ID, error) {
+	wallet, nodeID, err := a.stakingWallet(ctx, nodeName)
+	if err != nil {
+		return ids.Empty, err
+	}
+	defer a.closeWallet(wallet)
+	txID, err := wallet.AddDelegator(ctx, nodeID, stakeAmount, start, end, rewardAddr)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("couldn't delegate to node %q: %w", nodeName, err)
+	}
+	a.log.Info("delegated to node %q in tx %s", nodeName, txID)
+	return txID, nil
+}
+
+// Returns a wallet that pays with the funded key, and
+// the ID of the node named [nodeName]
+// Assumes [a.nodesLock] isn't held.
+func (a *networkImpl) stakingWallet(ctx context.Context, nodeName string) (*network.PChainWallet, ids.ShortID, error) {
+	a.nodesLock.RLock()
+	if a.isStopped() {
+		a.nodesLock.RUnlock()
+		return nil, ids.ShortEmpty, network.ErrStopped
+	}
+	n, ok := a.nodes[nodeName]
+	if !ok {
+		a.nodesLock.RUnlock()
+		return nil, ids.ShortEmpty, fmt.Errorf("node %q not found", nodeName)
+	}
+	nodeID := n.nodeID
+	client := a.issuingClient()
+	a.nodesLock.RUnlock()
+	if client == nil {
+		return nil, ids.ShortEmpty, errors.New("no reachable node to issue transactions through")
+	}
+	wallet, err := network.NewPChainWallet(ctx, client, a.config.FundedPrivateKey)
+	if err != nil {
+		return nil, ids.ShortEmpty, err
+	}
+	return wallet, nodeID, nil
+}
+
+// Deletes [wallet]'s keystore user. Failing to do so only leaves
+// the user in the keystore, so it's logged rather than returned.
+func (a *networkImpl) closeWallet(wallet *network.PChainWallet) {
+	if err := wallet.Close(); err != nil {
+		a.log.Warn("couldn't close P-Chain wallet: %s", err)
+	}
+}
+
+// Returns the API client of the first reachable node, by name,
+// to issue P-Chain transactions through. Returns nil if no node
+// is reachable yet.
+// Assumes [a.nodesLock] is held.
+func (a *networkImpl) issuingClient() api.Client {
+	names := make([]string, 0, len(a.nodes))
+	for name := range a.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if client := a.nodes[name].apiClient; client != nil {
+			return client
+		}
+	}
+	return nil
+}
+
 // GetAllNodes returns all nodes
 func (a *networkImpl) GetAllNodes() (map[string]node.Node, error) {
 	a.nodesLock.RLock()
@@ -326,6 +859,76 @@ func (a *networkImpl) GetNode(name string) (node.Node, error) {
 	return nil, fmt.Errorf("node %q not found", name)
 }
 
+// See network.Network
+// The lines have no stream, since pod logs don't tell stdout and stderr
+// apart, and their time is when Kubernetes read them from the node.
+func (a *networkImpl) GetNodeLogs(ctx context.Context, nodeName string, query network.LogQuery) ([]network.LogLine, error) {
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid log query: %w", err)
+	}
+	a.nodesLock.RLock()
+	if a.isStopped() {
+		a.nodesLock.RUnlock()
+		return nil, network.ErrStopped
+	}
+	n, ok := a.nodes[nodeName]
+	if !ok {
+		a.nodesLock.RUnlock()
+		return nil, fmt.Errorf("node %q not found", nodeName)
+	}
+	namespace, podName := n.k8sObjSpec.Namespace, n.k8sObjSpec.Spec.DeploymentName
+	a.nodesLock.RUnlock()
+
+	opts := &corev1.PodLogOptions{Timestamps: true}
+	if !query.Since.IsZero() {
+		since := metav1.NewTime(query.Since)
+		opts.SinceTime = &since
+	}
+	// With a level, the last lines of the pod may not be the ones wanted
+	if query.Tail > 0 && query.Level == "" {
+		tail := int64(query.Tail)
+		opts.TailLines = &tail
+	}
+	logs, err := a.podLogs.StreamPodLogs(ctx, namespace, podName, opts)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get logs of pod %q: %w", podName, err)
+	}
+	defer logs.Close()
+	lines := []network.LogLine{}
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxPodLogLineSize)
+	for scanner.Scan() {
+		lineTime, text := splitPodLogLine(scanner.Text())
+		lines = append(lines, network.NewLogLine(lineTime, "", text))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read logs of pod %q: %w", podName, err)
+	}
+	// The API only filters by time to the second
+	return network.FilterLogLines(lines, query), nil
+}
+
+// Splits a line of pod logs into the time at its start and the rest of
+// the line. The time is zero if the line doesn't start with one.
+func splitPodLogLine(line string) (time.Time, string) {
+	i := strings.IndexByte(line, ' ')
+	if i < 0 {
+		return time.Time{}, line
+	}
+	lineTime, err := time.Parse(time.RFC3339Nano, line[:i])
+	if err != nil {
+		return time.Time{}, line
+	}
+	return lineTime, line[i+1:]
+}
+
+// See network.Network
+// NodeExited events aren't sent, since the avalanchego-operator
+// replaces the pod of a node that exits.
+func (a *networkImpl) Subscribe() (<-chan network.Event, func()) {
+	return a.events.Subscribe()
+}
+
 // Assumes [a.nodesLock] is held
 func (net *networkImpl) isStopped() bool {
 	select {
@@ -376,15 +979,42 @@ func (a *networkImpl) launchNode(ctx context.Context, nodeSpec *k8sapi.Avalanche
 	if err != nil {
 		return fmt.Errorf("k8scli.Create failed: %w", err)
 	}
+	if err := a.waitForNode(ctx, nodeSpec, ""); err != nil {
+		return err
+	}
+	a.events.Publish(network.Event{Type: network.NodeAdded, NodeName: nodeSpec.Spec.DeploymentName})
+	return nil
+}
 
-	a.log.Debug("Waiting for pod to be created for node %q...", nodeSpec.Spec.DeploymentName)
-	for len(nodeSpec.Status.NetworkMembersURI) != 1 {
+// Blocks until the node described by [nodeSpec] has a running pod other
+// than the one with UID [oldPodUID], which is empty if the node had no pod,
+// and the pod is reachable. Then updates the node's URI, API client, node ID
+// and pod UID.
+// When a node is restarted, its object's status keeps the URI, and its old
+// pod may stay reachable for a while, so only a new pod UID tells that the
+// node was restarted.
+// Assumes [a.nodesLock] isn't held.
+func (a *networkImpl) waitForNode(ctx context.Context, nodeSpec *k8sapi.Avalanchego, oldPodUID types.UID) error {
+	name := nodeSpec.Spec.DeploymentName
+	a.log.Debug("Waiting for pod to be created for node %q...", name)
+	var podUID types.UID
+	for {
 		if err := a.k8scli.Get(ctx, types.NamespacedName{
 			Name:      nodeSpec.Name,
 			Namespace: nodeSpec.Namespace,
 		}, nodeSpec); err != nil {
 			return fmt.Errorf("k8scli.Get failed: %w", err)
 		}
+		if len(nodeSpec.Status.NetworkMembersURI) == 1 {
+			pod, err := a.newPod(ctx, nodeSpec, oldPodUID)
+			if err != nil {
+				return err
+			}
+			if pod != nil {
+				podUID = pod.UID
+				break
+			}
+		}
 		select {
 		case <-time.After(nodeReachableCheckFreq):
 		case <-ctx.Done():
@@ -434,28 +1064,104 @@ reachableLoop:
 	}
 	// Update node info
 	a.nodesLock.Lock()
-	a.nodes[nodeSpec.Spec.DeploymentName].uri = url
-	a.nodes[nodeSpec.Spec.DeploymentName].apiClient = apiClient
-	a.nodes[nodeSpec.Spec.DeploymentName].nodeID = nodeID
+	n, ok := a.nodes[name]
+	if !ok {
+		a.nodesLock.Unlock()
+		return fmt.Errorf("node %q was removed", name)
+	}
+	n.uri = url
+	n.apiClient = apiClient
+	n.nodeID = nodeID
+	n.podUID = podUID
 	a.nodesLock.Unlock()
-	a.log.Debug("Name: %s, NodeID: %s, URI: %s", nodeSpec.Spec.DeploymentName, nodeID, url)
+	a.log.Debug("Name: %s, NodeID: %s, URI: %s", name, nodeID, url)
 	return nil
 }
 
-// String returns a string representing the network nodes
-func (a *networkImpl) String() string {
+// Returns the running pod of the node described by [nodeSpec], or nil
+// if the node has no running pod other than the one with UID [oldPodUID]
+// that runs [nodeSpec]'s image
+func (a *networkImpl) newPod(ctx context.Context, nodeSpec *k8sapi.Avalanchego, oldPodUID types.UID) (*corev1.Pod, error) {
+	pod, err := a.pods.GetPod(ctx, nodeSpec.Namespace, nodeSpec.Spec.DeploymentName)
+	switch {
+	case apierrors.IsNotFound(err):
+		// The old pod is gone and the new one isn't created yet
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("couldn't get pod of node %q: %w", nodeSpec.Spec.DeploymentName, err)
+	case pod.UID == oldPodUID, pod.DeletionTimestamp != nil, pod.Status.Phase != corev1.PodRunning:
+		return nil, nil
+	case !runsImage(pod, fmt.Sprintf("%s:%s", nodeSpec.Spec.Image, nodeSpec.Spec.Tag)):
+		// The pod was replaced before the operator updated its spec
+		return nil, nil
+	}
+	return pod, nil
+}
+
+// Returns true if one of [pod]'s containers runs [image]
+func runsImage(pod *corev1.Pod, image string) bool {
+	for _, container := range pod.Spec.Containers {
+		if container.Image == image {
+			return true
+		}
+	}
+	return false
+}
+
+// See network.Network
+func (a *networkImpl) Describe() (network.Info, error) {
 	a.nodesLock.RLock()
 	defer a.nodesLock.RUnlock()
 
-	s := strings.Builder{}
-	_, _ = s.WriteString("\n****************************************************************************************************\n")
-	_, _ = s.WriteString("     List of nodes in the network: \n")
-	_, _ = s.WriteString("  +------------------------------------------------------------------------------------------------+\n")
-	_, _ = s.WriteString("  +  NodeID                           |     Label         |      Cluster URI                       +\n")
-	_, _ = s.WriteString("  +------------------------------------------------------------------------------------------------+\n")
-	for _, n := range a.nodes {
-		s.WriteString(fmt.Sprintf("     %s    %s    %s\n", n.nodeID, n.name, n.uri))
+	if a.isStopped() {
+		return network.Info{}, network.ErrStopped
 	}
-	s.WriteString("****************************************************************************************************\n")
-	return s.String()
+	genesis := []byte(a.config.Genesis)
+	// Validated when the network was created
+	networkID, _ := utils.NetworkIDFromGenesis(genesis)
+	info := network.Info{
+		NetworkID:   networkID,
+		GenesisHash: network.GenesisHash(genesis),
+		Beacons:     []network.BeaconInfo{},
+		Nodes:       make(map[string]network.NodeInfo, len(a.nodes)),
+	}
+	for name, n := range a.nodes {
+		nodeConfig := a.nodeConfigs[name]
+		// Flags in the node's config take precedence over the network's
+		flags := make(map[string]interface{}, len(a.config.Flags)+len(nodeConfig.Flags))
+		for flagName, flagVal := range a.config.Flags {
+			flags[flagName] = flagVal
+		}
+		for flagName, flagVal := range nodeConfig.Flags {
+			flags[flagName] = flagVal
+		}
+		nodeInfo := network.NodeInfo{
+			Name:     name,
+			APIPort:  n.GetAPIPort(),
+			P2PPort:  n.GetP2PPort(),
+			IsBeacon: nodeConfig.IsBeacon,
+			PodName:  n.k8sObjSpec.Spec.DeploymentName,
+			Image:    fmt.Sprintf("%s:%s", n.k8sObjSpec.Spec.Image, n.k8sObjSpec.Spec.Tag),
+			Flags:    flags,
+			Status:   network.NodeStatusStarting,
+		}
+		// Set once the node is reachable
+		if n.apiClient != nil {
+			nodeInfo.NodeID = n.nodeID.PrefixedString(constants.NodeIDPrefix)
+			nodeInfo.URI = fmt.Sprintf("http://%s:%d", n.uri, defaultAPIPort)
+			nodeInfo.Status = network.NodeStatusRunning
+		}
+		info.Nodes[name] = nodeInfo
+		if nodeConfig.IsBeacon {
+			info.Beacons = append(info.Beacons, network.BeaconInfo{
+				NodeName: name,
+				NodeID:   nodeInfo.NodeID,
+				IP:       fmt.Sprintf("%s:%d", a.beaconURL, defaultP2PPort),
+			})
+		}
+	}
+	sort.Slice(info.Beacons, func(i, j int) bool {
+		return info.Beacons[i].NodeName < info.Beacons[j].NodeName
+	})
+	return info, nil
 }
@@ -3,7 +3,12 @@ package k8s
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -14,15 +19,22 @@ import (
 	"github.com/ava-labs/avalanche-network-runner/network/node"
 	"github.com/ava-labs/avalanche-network-runner/utils"
 	"github.com/ava-labs/avalanchego/api/health"
+	"github.com/ava-labs/avalanchego/api/info"
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/staking"
 	"github.com/ava-labs/avalanchego/utils/constants"
 	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/platformvm/status"
 
 	k8sapi "github.com/ava-labs/avalanchego-operator/api/v1alpha1"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	k8scli "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -115,8 +127,68 @@ var (
 	)
 )
 
-// newMockK8sClient creates a new mock client
-func newMockK8sClient() k8scli.Client {
+// testPods stands in for the pods the avalanchego-operator runs.
+// Creating or updating a node's object replaces the node's pod,
+// unless [holdUpdates] is set.
+type testPods struct {
+	lock sync.Mutex
+	// Pod name --> The pod
+	pods map[string]*corev1.Pod
+	// If true, updating an object doesn't replace its pod
+	holdUpdates bool
+	// The objects updated while [holdUpdates] was set
+	held []*k8sapi.Avalanchego
+}
+
+func newTestPods() *testPods {
+	return &testPods{pods: map[string]*corev1.Pod{}}
+}
+
+// Replaces the pod of the node described by [nodeSpec] with a new one
+func (p *testPods) replace(nodeSpec *k8sapi.Avalanchego) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.pods[nodeSpec.Spec.DeploymentName] = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nodeSpec.Spec.DeploymentName,
+			Namespace: nodeSpec.Namespace,
+			UID:       types.UID(ids.GenerateTestID().String()),
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Image: fmt.Sprintf("%s:%s", nodeSpec.Spec.Image, nodeSpec.Spec.Tag)}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func (p *testPods) get(_ context.Context, _ string, podName string) *corev1.Pod {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.pods[podName]
+}
+
+func (p *testPods) getErr(_ context.Context, _ string, podName string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.pods[podName]; !ok {
+		return apierrors.NewNotFound(corev1.Resource("pods"), podName)
+	}
+	return nil
+}
+
+// newMockPodGetter creates a mock that gets pods from [pods]
+func newMockPodGetter(pods *testPods) *mocks.PodGetter {
+	podGetter := &mocks.PodGetter{}
+	podGetter.On("GetPod", mock.Anything, mock.Anything, mock.Anything).Return(pods.get, pods.getErr)
+	return podGetter
+}
+
+// newMockK8sClient creates a new mock client that
+// replaces the nodes' pods in [pods]
+func newMockK8sClient(pods *testPods) k8scli.Client {
 	client := &mocks.Client{}
 	client.On("Get", mock.Anything, mock.Anything, mock.Anything).Run(
 		func(args mock.Arguments) {
@@ -125,7 +197,23 @@ func newMockK8sClient() k8scli.Client {
 		}).Return(nil)
 	client.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	client.On("DeleteAllOf", mock.Anything, mock.Anything, mock.Anything).Return(nil)
-	client.On("Create", mock.Anything, mock.Anything).Return(nil)
+	client.On("Create", mock.Anything, mock.Anything).Run(
+		func(args mock.Arguments) {
+			pods.replace(args.Get(1).(*k8sapi.Avalanchego))
+		}).Return(nil)
+	client.On("Update", mock.Anything, mock.Anything).Run(
+		func(args mock.Arguments) {
+			nodeSpec := args.Get(1).(*k8sapi.Avalanchego)
+			pods.lock.Lock()
+			hold := pods.holdUpdates
+			if hold {
+				pods.held = append(pods.held, nodeSpec)
+			}
+			pods.lock.Unlock()
+			if !hold {
+				pods.replace(nodeSpec)
+			}
+		}).Return(nil)
 	client.On("Status").Return(nil)
 	client.On("Scheme").Return(nil)
 	client.On("RESTMapper").Return(nil)
@@ -154,6 +242,7 @@ func newMockAPISuccessful(ipAddr string, port uint16) api.Client {
 	infoReply := fmt.Sprintf("%s%s", constants.NodeIDPrefix, id)
 	infoClient := &apimocks.InfoClient{}
 	infoClient.On("GetNodeID", mock.Anything).Return(infoReply, nil)
+	infoClient.On("GetNodeVersion", mock.Anything).Return(&info.GetNodeVersionReply{Version: "avalanche/1.7.4"}, nil)
 
 	client := &apimocks.Client{}
 	client.On("HealthAPI").Return(healthClient)
@@ -177,12 +266,18 @@ func newDefaultTestNetwork(t *testing.T) (network.Network, error) {
 }
 
 func newTestNetworkWithConfig(conf network.Config) (network.Network, error) {
+	return newTestNetworkWithPods(conf, newTestPods())
+}
+
+func newTestNetworkWithPods(conf network.Config, pods *testPods) (network.Network, error) {
 	return newNetwork(networkParams{
 		conf:          conf,
 		log:           logging.NoLog{},
-		k8sClient:     newMockK8sClient(),
+		k8sClient:     newMockK8sClient(pods),
 		dnsChecker:    newDNSChecker(),
 		apiClientFunc: newMockAPISuccessful,
+		podLogs:       &mocks.PodLogStreamer{},
+		pods:          newMockPodGetter(pods),
 	})
 }
 
@@ -211,6 +306,88 @@ func TestHealthy(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestHealthReport tests that the health report has every node's health
+func TestHealthReport(t *testing.T) {
+	assert := assert.New(t)
+	conf := defaultTestNetworkConfig(t)
+	n, err := newTestNetworkWithConfig(conf)
+	assert.NoError(err)
+
+	report, err := n.HealthReport(context.Background())
+	assert.NoError(err)
+	assert.Len(report, len(conf.NodeConfigs))
+	for _, nodeHealth := range report {
+		assert.True(nodeHealth.Healthy)
+		assert.NotNil(nodeHealth.Reply)
+	}
+
+	cleanup(n)
+	_, err = n.HealthReport(context.Background())
+	assert.EqualValues(network.ErrStopped, err)
+}
+
+// TestDescribe tests that the network's description
+// has every node and marshals to JSON
+func TestDescribe(t *testing.T) {
+	assert := assert.New(t)
+	conf := defaultTestNetworkConfig(t)
+	conf.Flags = map[string]interface{}{
+		"network-flag": "value",
+	}
+	n, err := newTestNetworkWithConfig(conf)
+	assert.NoError(err)
+
+	info, err := n.Describe()
+	assert.NoError(err)
+	assert.EqualValues(network.GenesisHash(defaultTestGenesis), info.GenesisHash)
+	assert.Len(info.Nodes, len(conf.NodeConfigs))
+	assert.Len(info.Beacons, 1)
+	for name, nodeInfo := range info.Nodes {
+		node, err := n.GetNode(name)
+		assert.NoError(err)
+		assert.EqualValues(name, nodeInfo.Name)
+		assert.EqualValues(node.GetNodeID().PrefixedString(constants.NodeIDPrefix), nodeInfo.NodeID)
+		assert.EqualValues(network.NodeStatusRunning, nodeInfo.Status)
+		assert.EqualValues(name, nodeInfo.PodName)
+		assert.EqualValues("somerepo/someimage:testingversion", nodeInfo.Image)
+		assert.EqualValues("value", nodeInfo.Flags["network-flag"])
+	}
+	_, err = json.Marshal(info)
+	assert.NoError(err)
+
+	cleanup(n)
+	_, err = n.Describe()
+	assert.EqualValues(network.ErrStopped, err)
+}
+
+// TestWatchHealth tests that WatchHealth reports the health
+// of every node and stops when its context is cancelled
+func TestWatchHealth(t *testing.T) {
+	assert := assert.New(t)
+	conf := defaultTestNetworkConfig(t)
+	n, err := newTestNetworkWithConfig(conf)
+	assert.NoError(err)
+	defer cleanup(n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	transitions, err := n.WatchHealth(ctx, 10*time.Millisecond)
+	assert.NoError(err)
+	seen := make(map[string]struct{})
+	for range conf.NodeConfigs {
+		select {
+		case transition := <-transitions:
+			assert.True(transition.Healthy)
+			seen[transition.NodeName] = struct{}{}
+		case <-time.After(5 * time.Second):
+			assert.FailNow("didn't get transition")
+		}
+	}
+	assert.Len(seen, len(conf.NodeConfigs))
+	cancel()
+	for range transitions {
+	}
+}
+
 // TestNetworkDefault tests the default operations on a network:
 // * it creates a network and waits until it's healthy
 // * it adds a new node
@@ -282,6 +459,417 @@ func TestNetworkDefault(t *testing.T) {
 	assert.Len(names, netSize)
 }
 
+// TestRestartNode tests that restarting a node updates its
+// k8s object with the new config and keeps its identity
+func TestRestartNode(t *testing.T) {
+	assert := assert.New(t)
+	conf := defaultTestNetworkConfig(t)
+	n, err := newTestNetworkWithConfig(conf)
+	assert.NoError(err)
+	defer cleanup(n)
+
+	names, err := n.GetNodeNames()
+	assert.NoError(err)
+	name := names[0]
+	oldNode, err := n.GetNode(name)
+	assert.NoError(err)
+	oldSpec := oldNode.(*Node).GetK8sObjSpec()
+
+	err = n.RestartNode(context.Background(), name, &node.Config{
+		Flags: map[string]interface{}{
+			"new-flag": "value",
+		},
+	})
+	assert.NoError(err)
+	newNode, err := n.GetNode(name)
+	assert.NoError(err)
+	newSpec := newNode.(*Node).GetK8sObjSpec()
+	assert.NotSame(oldSpec, newSpec)
+	assert.EqualValues(oldSpec.Spec.Certificates, newSpec.Spec.Certificates)
+	assert.Contains(newSpec.Spec.Env, corev1.EnvVar{Name: convertKey("new-flag"), Value: "value"})
+
+	// Can't change a node's identity
+	_, key, err := staking.NewCertAndKeyBytes()
+	assert.NoError(err)
+	assert.Error(n.RestartNode(context.Background(), name, &node.Config{StakingKey: string(key)}))
+	// Invalid config
+	assert.Error(n.RestartNode(context.Background(), name, &node.Config{ConfigFile: `{"network-id":1}`}))
+	assert.Error(n.RestartNode(context.Background(), name, &node.Config{ConfigFile: "not json"}))
+	// Unknown node
+	assert.Error(n.RestartNode(context.Background(), "not a node", nil))
+}
+
+// TestRestartNodeNewPod checks that restarting a node waits until
+// the node's pod is replaced, and that a node removed while its pod
+// is waited for isn't updated
+func TestRestartNodeNewPod(t *testing.T) {
+	assert := assert.New(t)
+	pods := newTestPods()
+	n, err := newTestNetworkWithPods(defaultTestNetworkConfig(t), pods)
+	assert.NoError(err)
+	defer cleanup(n)
+
+	names, err := n.GetNodeNames()
+	assert.NoError(err)
+	name := names[0]
+	oldNode, err := n.GetNode(name)
+	assert.NoError(err)
+	oldClient := oldNode.GetAPIClient()
+
+	pods.lock.Lock()
+	pods.holdUpdates = true
+	pods.lock.Unlock()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- n.RestartNode(context.Background(), name, nil)
+	}()
+	select {
+	case err := <-errCh:
+		assert.FailNow("node restarted before its pod was replaced", err)
+	case <-time.After(time.Second):
+	}
+	impl := n.(*networkImpl)
+	impl.nodesLock.RLock()
+	nodeSpec := impl.nodes[name].k8sObjSpec
+	impl.nodesLock.RUnlock()
+	pods.replace(nodeSpec)
+	select {
+	case err := <-errCh:
+		assert.NoError(err)
+	case <-time.After(2 * nodeReachableCheckFreq):
+		assert.FailNow("node didn't restart after its pod was replaced")
+	}
+	newNode, err := n.GetNode(name)
+	assert.NoError(err)
+	assert.NotSame(oldClient, newNode.GetAPIClient())
+
+	assert.NoError(n.RemoveNode(name))
+	assert.Error(impl.waitForNode(context.Background(), nodeSpec, ""))
+}
+
+// TestUpgradeNode checks that an upgraded node's pod
+// gets the new image tag, and keeps its identity
+func TestUpgradeNode(t *testing.T) {
+	assert := assert.New(t)
+	n, err := newDefaultTestNetwork(t)
+	assert.NoError(err)
+	defer cleanup(n)
+
+	names, err := n.GetNodeNames()
+	assert.NoError(err)
+	name := names[0]
+	oldNode, err := n.GetNode(name)
+	assert.NoError(err)
+	oldSpec := oldNode.(*Node).GetK8sObjSpec()
+
+	upgrade, err := n.UpgradeNode(context.Background(), name, "v1.7.5")
+	assert.NoError(err)
+	assert.Equal(name, upgrade.NodeName)
+	assert.NotNil(upgrade.OldVersion)
+	assert.NotNil(upgrade.NewVersion)
+	newNode, err := n.GetNode(name)
+	assert.NoError(err)
+	newSpec := newNode.(*Node).GetK8sObjSpec()
+	assert.Equal("v1.7.5", newSpec.Spec.Tag)
+	assert.Equal(oldSpec.Spec.Image, newSpec.Spec.Image)
+	assert.EqualValues(oldSpec.Spec.Certificates, newSpec.Spec.Certificates)
+	assert.Equal(oldSpec.Spec.Tag, upgrade.PreviousBinaryOrTag)
+
+	// A node that can't report its version is upgraded anyway
+	infoClient := &apimocks.InfoClient{}
+	infoClient.On("GetNodeVersion", mock.Anything).Return(nil, errors.New("connection refused"))
+	client := &apimocks.Client{}
+	client.On("InfoAPI").Return(infoClient)
+	impl := n.(*networkImpl)
+	impl.nodesLock.Lock()
+	impl.nodes[name].apiClient = client
+	impl.nodesLock.Unlock()
+	upgrade, err = n.UpgradeNode(context.Background(), name, "v1.7.6")
+	assert.NoError(err)
+	assert.Nil(upgrade.OldVersion)
+	assert.NotNil(upgrade.NewVersion)
+	assert.Equal("v1.7.5", upgrade.PreviousBinaryOrTag)
+
+	_, err = n.UpgradeNode(context.Background(), "not a node", "v1.7.5")
+	assert.Error(err)
+}
+
+// TestUpgradeNodeNewImage checks that an upgraded node's version
+// is only read once its pod runs the new image
+func TestUpgradeNodeNewImage(t *testing.T) {
+	assert := assert.New(t)
+	pods := newTestPods()
+	n, err := newTestNetworkWithPods(defaultTestNetworkConfig(t), pods)
+	assert.NoError(err)
+	defer cleanup(n)
+
+	names, err := n.GetNodeNames()
+	assert.NoError(err)
+	name := names[0]
+	oldNode, err := n.GetNode(name)
+	assert.NoError(err)
+	oldSpec := oldNode.(*Node).GetK8sObjSpec()
+
+	pods.lock.Lock()
+	pods.holdUpdates = true
+	pods.lock.Unlock()
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := n.UpgradeNode(context.Background(), name, "v1.7.5")
+		errCh <- err
+	}()
+	assert.Eventually(func() bool {
+		pods.lock.Lock()
+		defer pods.lock.Unlock()
+		return len(pods.held) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	// The pod is replaced, but still runs the old image
+	pods.replace(oldSpec)
+	select {
+	case err := <-errCh:
+		assert.FailNow("node upgraded before its pod ran the new image", err)
+	case <-time.After(nodeReachableCheckFreq + time.Second):
+	}
+	pods.lock.Lock()
+	newSpec := pods.held[0]
+	pods.lock.Unlock()
+	pods.replace(newSpec)
+	select {
+	case err := <-errCh:
+		assert.NoError(err)
+	case <-time.After(2 * nodeReachableCheckFreq):
+		assert.FailNow("node wasn't upgraded after its pod ran the new image")
+	}
+}
+
+// TestRollingUpgrade checks that all nodes get the new image tag
+func TestRollingUpgrade(t *testing.T) {
+	assert := assert.New(t)
+	n, err := newDefaultTestNetwork(t)
+	assert.NoError(err)
+	defer cleanup(n)
+
+	names, err := n.GetNodeNames()
+	assert.NoError(err)
+	upgrades, err := n.RollingUpgrade(context.Background(), "v1.7.5", network.RollingUpgradeOptions{BatchSize: len(names)})
+	assert.NoError(err)
+	assert.Len(upgrades, len(names))
+	for _, name := range names {
+		node, err := n.GetNode(name)
+		assert.NoError(err)
+		assert.Equal("v1.7.5", node.(*Node).GetK8sObjSpec().Spec.Tag)
+	}
+	_, err = n.RollingUpgrade(context.Background(), "v1.7.5", network.RollingUpgradeOptions{BatchSize: -1})
+	assert.Error(err)
+}
+
+// TestCreateSubnet checks that a new subnet's validators are restarted
+// together, and that it waits until their new pods are healthy
+func TestCreateSubnet(t *testing.T) {
+	assert := assert.New(t)
+	conf := defaultTestNetworkConfig(t)
+	conf.FundedPrivateKey = "PrivateKey-ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN"
+	subnetID := ids.GenerateTestID()
+	keystoreClient := &apimocks.KeystoreClient{}
+	keystoreClient.On("CreateUser", mock.Anything, mock.Anything).Return(true, nil)
+	keystoreClient.On("DeleteUser", mock.Anything, mock.Anything).Return(true, nil)
+	pChainClient := &apimocks.PChainClient{}
+	pChainClient.On("ImportKey", mock.Anything, mock.Anything, conf.FundedPrivateKey).Return("P-custom1address", nil)
+	pChainClient.On("CreateSubnet", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(subnetID, nil)
+	pChainClient.On("GetTxStatus", mock.Anything, mock.Anything, true).Return(&platformvm.GetTxStatusResponse{Status: status.Committed}, nil)
+	pChainClient.On("GetCurrentValidators", mock.Anything, constants.PrimaryNetworkID, mock.Anything).Return(
+		func(_ context.Context, _ ids.ID, nodeIDs []ids.ShortID) []interface{} {
+			return []interface{}{
+				map[string]interface{}{
+					"nodeID":  nodeIDs[0].PrefixedString(constants.NodeIDPrefix),
+					"endTime": "12345",
+				},
+			}
+		},
+		nil,
+	)
+	pChainClient.On("AddSubnetValidator", mock.Anything, mock.Anything, mock.Anything, mock.Anything, subnetID.String(), mock.Anything, uint64(network.SubnetValidatorWeight), mock.Anything, uint64(12345)).Return(ids.GenerateTestID(), nil)
+	apiClientFunc := func(ipAddr string, port uint16) api.Client {
+		client := newMockAPISuccessful(ipAddr, port).(*apimocks.Client)
+		client.On("KeystoreAPI").Return(keystoreClient)
+		client.On("PChainAPI").Return(pChainClient)
+		return client
+	}
+	pods := newTestPods()
+	n, err := newNetwork(networkParams{
+		conf:          conf,
+		log:           logging.NoLog{},
+		k8sClient:     newMockK8sClient(pods),
+		dnsChecker:    newDNSChecker(),
+		apiClientFunc: apiClientFunc,
+		podLogs:       &mocks.PodLogStreamer{},
+		pods:          newMockPodGetter(pods),
+	})
+	assert.NoError(err)
+	defer cleanup(n)
+
+	names, err := n.GetNodeNames()
+	assert.NoError(err)
+	sort.Strings(names)
+	validatorNames := names[1:3]
+	// The validators' pods are only replaced once both were updated
+	pods.lock.Lock()
+	pods.holdUpdates = true
+	pods.lock.Unlock()
+	type result struct {
+		subnetID ids.ID
+		err      error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		subnetID, err := n.CreateSubnet(context.Background(), nil, 0, validatorNames)
+		resultCh <- result{subnetID: subnetID, err: err}
+	}()
+	assert.Eventually(func() bool {
+		pods.lock.Lock()
+		defer pods.lock.Unlock()
+		return len(pods.held) == len(validatorNames)
+	}, 5*time.Second, 10*time.Millisecond)
+	pods.lock.Lock()
+	held := pods.held
+	pods.lock.Unlock()
+	for _, nodeSpec := range held {
+		pods.replace(nodeSpec)
+	}
+	select {
+	case res := <-resultCh:
+		assert.NoError(res.err)
+		assert.EqualValues(subnetID, res.subnetID)
+	case <-time.After(2 * nodeReachableCheckFreq):
+		assert.FailNow("subnet wasn't created after the validators' pods were replaced")
+	}
+	// The validators' new clients were checked for health
+	for _, name := range validatorNames {
+		validator, err := n.GetNode(name)
+		assert.NoError(err)
+		validator.GetAPIClient().(*apimocks.Client).AssertCalled(t, "HealthAPI")
+	}
+	keystoreClient.AssertNumberOfCalls(t, "DeleteUser", 1)
+}
+
+// TestGetNodeLogs checks that a node's logs are read from its pod,
+// and filtered by the query
+func TestGetNodeLogs(t *testing.T) {
+	assert := assert.New(t)
+	podLogs := &mocks.PodLogStreamer{}
+	pods := newTestPods()
+	n, err := newNetwork(networkParams{
+		conf:          defaultTestNetworkConfig(t),
+		log:           logging.NoLog{},
+		k8sClient:     newMockK8sClient(pods),
+		dnsChecker:    newDNSChecker(),
+		apiClientFunc: newMockAPISuccessful,
+		podLogs:       podLogs,
+		pods:          newMockPodGetter(pods),
+	})
+	assert.NoError(err)
+	defer cleanup(n)
+
+	names, err := n.GetNodeNames()
+	assert.NoError(err)
+	name := names[0]
+	node, err := n.GetNode(name)
+	assert.NoError(err)
+	spec := node.(*Node).GetK8sObjSpec()
+	logs := "2022-01-02T15:04:05.000000001Z INFO [01-02|15:04:05] <P Chain> bootstrapping finished\n" +
+		"2022-01-02T15:04:06Z WARN [01-02|15:04:06] <C Chain> peer benched\n" +
+		"2022-01-02T15:04:07Z panic: not a log line\n"
+	podLogs.On("StreamPodLogs", mock.Anything, spec.Namespace, spec.Spec.DeploymentName, mock.Anything).Return(
+		func(context.Context, string, string, *corev1.PodLogOptions) io.ReadCloser {
+			return io.NopCloser(strings.NewReader(logs))
+		},
+		nil,
+	)
+
+	lines, err := n.GetNodeLogs(context.Background(), name, network.LogQuery{})
+	assert.NoError(err)
+	assert.Len(lines, 3)
+	assert.Equal(time.Date(2022, 1, 2, 15, 4, 5, 1, time.UTC), lines[0].Time)
+	assert.Equal("INFO", lines[0].Level)
+	assert.Equal("INFO [01-02|15:04:05] <P Chain> bootstrapping finished", lines[0].Text)
+	assert.Equal("", lines[2].Level)
+
+	lines, err = n.GetNodeLogs(context.Background(), name, network.LogQuery{Level: "warn"})
+	assert.NoError(err)
+	assert.Len(lines, 1)
+	assert.Contains(lines[0].Text, "peer benched")
+
+	// The tail is left to the API unless lines are filtered by level
+	lines, err = n.GetNodeLogs(context.Background(), name, network.LogQuery{Tail: 2})
+	assert.NoError(err)
+	assert.Len(lines, 2)
+	podLogs.AssertCalled(t, "StreamPodLogs", mock.Anything, spec.Namespace, spec.Spec.DeploymentName, mock.MatchedBy(
+		func(opts *corev1.PodLogOptions) bool {
+			return opts.Timestamps && opts.TailLines != nil && *opts.TailLines == 2
+		}),
+	)
+
+	_, err = n.GetNodeLogs(context.Background(), name, network.LogQuery{Level: "LOUD"})
+	assert.Error(err)
+	_, err = n.GetNodeLogs(context.Background(), "not a node", network.LogQuery{})
+	assert.Error(err)
+}
+
+// Returns the next event on [events], or fails the test if
+// there isn't one within a second
+func nextEvent(t *testing.T, events <-chan network.Event) network.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		assert.FailNow(t, "didn't get event")
+		return network.Event{}
+	}
+}
+
+// TestEvents tests that the network sends events
+// when nodes are added and removed, and when it stops
+func TestEvents(t *testing.T) {
+	assert := assert.New(t)
+	conf := defaultTestNetworkConfig(t)
+	n, err := newTestNetworkWithConfig(conf)
+	assert.NoError(err)
+	events, unsubscribe := n.Subscribe()
+	defer unsubscribe()
+
+	stakingCert, stakingKey, err := staking.NewCertAndKeyBytes()
+	assert.NoError(err)
+	newNodeConfig := node.Config{
+		Name:        "new-node",
+		StakingKey:  string(stakingKey),
+		StakingCert: string(stakingCert),
+		ImplSpecificConfig: utils.NewK8sNodeConfigJsonRaw(
+			"chain.avax.network/v1alpha1",
+			"new-node",
+			"avaplatform/avalanchego",
+			"Avalanchego",
+			"ci-network-runner",
+			"9.99.9999",
+		),
+	}
+	_, err = n.AddNode(newNodeConfig)
+	assert.NoError(err)
+	event := nextEvent(t, events)
+	assert.EqualValues(network.NodeAdded, event.Type)
+	assert.EqualValues(newNodeConfig.Name, event.NodeName)
+
+	assert.NoError(n.RemoveNode(newNodeConfig.Name))
+	event = nextEvent(t, events)
+	assert.EqualValues(network.NodeRemoved, event.Type)
+	assert.EqualValues(newNodeConfig.Name, event.NodeName)
+
+	cleanup(n)
+	for range conf.NodeConfigs {
+		assert.EqualValues(network.NodeRemoved, nextEvent(t, events).Type)
+	}
+	assert.EqualValues(network.NetworkStopped, nextEvent(t, events).Type)
+}
+
 // TestWrongNetworkConfigs checks configs that are expected to be invalid at network creation time
 // This is adapted from the local test suite
 func TestWrongNetworkConfigs(t *testing.T) {
@@ -5,6 +5,7 @@ import (
 	"github.com/ava-labs/avalanche-network-runner/network/node"
 	k8sapi "github.com/ava-labs/avalanchego-operator/api/v1alpha1"
 	"github.com/ava-labs/avalanchego/ids"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 var _ node.Node = &Node{}
@@ -32,6 +33,8 @@ type Node struct {
 	apiClient api.Client
 	// K8s description of this node
 	k8sObjSpec *k8sapi.Avalanchego
+	// UID of this node's pod, once it's reachable
+	podUID types.UID
 }
 
 // See node.Node
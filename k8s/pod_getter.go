@@ -0,0 +1,28 @@
+package k8s
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var _ podGetter = &defaultPodGetter{}
+
+// podGetter gets pods. The avalanchego-operator doesn't say in an
+// Avalanchego object's status which pod runs it, so the pod is
+// checked to tell a node's new pod from the one it replaced.
+type podGetter interface {
+	// Returns pod [podName] in [namespace]
+	GetPod(ctx context.Context, namespace string, podName string) (*corev1.Pod, error)
+}
+
+// defaultPodGetter gets pods with a Kubernetes clientset
+type defaultPodGetter struct {
+	clientset kubernetes.Interface
+}
+
+func (g *defaultPodGetter) GetPod(ctx context.Context, namespace string, podName string) (*corev1.Pod, error) {
+	return g.clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+}
@@ -0,0 +1,34 @@
+package k8s
+
+import (
+	"context"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var _ podLogStreamer = &defaultPodLogStreamer{}
+
+// podLogStreamer streams the logs of a pod.
+// The controller-runtime client can't get pod logs,
+// so this uses the Kubernetes API directly.
+type podLogStreamer interface {
+	// Returns the logs of pod [podName] in [namespace]
+	// described by [opts]. The caller must close them.
+	StreamPodLogs(ctx context.Context, namespace string, podName string, opts *corev1.PodLogOptions) (io.ReadCloser, error)
+}
+
+// defaultPodLogStreamer streams pod logs with a Kubernetes clientset
+type defaultPodLogStreamer struct {
+	clientset kubernetes.Interface
+}
+
+func (s *defaultPodLogStreamer) StreamPodLogs(
+	ctx context.Context,
+	namespace string,
+	podName string,
+	opts *corev1.PodLogOptions,
+) (io.ReadCloser, error) {
+	return s.clientset.CoreV1().Pods(namespace).GetLogs(podName, opts).Stream(ctx)
+}
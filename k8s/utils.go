@@ -97,12 +97,8 @@ func buildK8sObjSpec(log logging.Logger, genesis []byte, c node.Config) (*k8sapi
 			Key:  base64.StdEncoding.EncodeToString([]byte(c.StakingKey)),
 		},
 	}
-	var k8sConf ObjectSpec
-	if err := json.Unmarshal(c.ImplSpecificConfig, &k8sConf); err != nil {
-		return nil, fmt.Errorf("Unmarshalling an expected k8s.ObjectSpec failed: %w", err)
-	}
-
-	if err := validateObjectSpec(k8sConf); err != nil {
+	k8sConf, err := objectSpecFromConfig(c)
+	if err != nil {
 		return nil, err
 	}
 
@@ -138,6 +134,19 @@ func buildK8sObjSpec(log logging.Logger, genesis []byte, c node.Config) (*k8sapi
 	}, nil
 }
 
+// Returns the k8s-specific config of the node with config [c].
+// Returns an error if it can't be parsed or is invalid.
+func objectSpecFromConfig(c node.Config) (ObjectSpec, error) {
+	var k8sConf ObjectSpec
+	if err := json.Unmarshal(c.ImplSpecificConfig, &k8sConf); err != nil {
+		return ObjectSpec{}, fmt.Errorf("Unmarshalling an expected k8s.ObjectSpec failed: %w", err)
+	}
+	if err := validateObjectSpec(k8sConf); err != nil {
+		return ObjectSpec{}, err
+	}
+	return k8sConf, nil
+}
+
 // Validates an ObjectSpec.
 // The tag value can be empty so not checked.
 func validateObjectSpec(k8sobj ObjectSpec) error {
@@ -0,0 +1,308 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/ava-labs/avalanche-network-runner/api"
+	"github.com/ava-labs/avalanche-network-runner/network"
+	"github.com/ava-labs/avalanche-network-runner/network/node"
+	"github.com/ava-labs/avalanchego/config"
+	"github.com/ava-labs/avalanchego/ids"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// AvalancheGo loads VM plugins from this directory in its build directory
+	pluginsDirName = "plugins"
+	// Build directory in the root directory of a node that VM plugins
+	// were installed on. Its plugins directory has the installed plugins,
+	// and links to the plugins of the node's binary.
+	nodeBuildDirName = "build"
+)
+
+// See network.Network
+// The plugin is installed in a build directory in each validator's root
+// directory, so nodes that share a binary don't share installed plugins.
+func (ln *localNetwork) CreateBlockchain(
+	ctx context.Context,
+	subnetID ids.ID,
+	vmID ids.ID,
+	name string,
+	genesis []byte,
+	pluginBinaryPath string,
+) (*network.Blockchain, error) {
+	ln.lock.RLock()
+	if ln.isStopped() {
+		ln.lock.RUnlock()
+		return nil, network.ErrStopped
+	}
+	client, err := ln.pChainClient()
+	ln.lock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := network.NewPChainWallet(ctx, client, ln.fundedPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer ln.closeWallet(wallet)
+	validatorIDs, err := wallet.SubnetValidators(ctx, subnetID)
+	if err != nil {
+		return nil, err
+	}
+
+	ln.lock.Lock()
+	validatorNames, err := ln.nodeNames(validatorIDs)
+	if err == nil && len(validatorNames) == 0 {
+		err = fmt.Errorf("subnet %s has no validators in this network", subnetID)
+	}
+	var restartedNames []string
+	if err == nil && pluginBinaryPath != "" {
+		restartedNames, err = ln.installPlugin(ctx, validatorNames, vmID, pluginBinaryPath)
+	}
+	ln.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	// Restarted nodes must be up to issue and accept the transaction
+	if len(restartedNames) > 0 {
+		if err := ln.awaitNodesHealthy(ctx, restartedNames); err != nil {
+			return nil, err
+		}
+	}
+
+	chainID, err := wallet.CreateBlockchain(ctx, subnetID, vmID, name, genesis)
+	if err != nil {
+		return nil, err
+	}
+	ln.log.Info("created blockchain %s on subnet %s", chainID, subnetID)
+
+	blockchain := &network.Blockchain{
+		ID:        chainID,
+		SubnetID:  subnetID,
+		VMID:      vmID,
+		Clients:   make(map[string]api.Client, len(validatorNames)),
+		Endpoints: make(map[string]string, len(validatorNames)),
+	}
+	ln.lock.RLock()
+	for _, nodeName := range validatorNames {
+		node, ok := ln.nodes[nodeName]
+		if !ok {
+			ln.lock.RUnlock()
+			return nil, fmt.Errorf("node %q was removed", nodeName)
+		}
+		blockchain.Clients[nodeName] = node.client
+		blockchain.Endpoints[nodeName] = network.ChainEndpoint(node.GetURL(), node.apiPort, chainID)
+	}
+	ln.lock.RUnlock()
+
+	errGr, ctx := errgroup.WithContext(ctx)
+	for nodeName, client := range blockchain.Clients {
+		nodeName, client := nodeName, client
+		errGr.Go(func() error {
+			if err := network.WaitForChain(ctx, client, chainID); err != nil {
+				return fmt.Errorf("node %q: %w", nodeName, err)
+			}
+			return nil
+		})
+	}
+	if err := errGr.Wait(); err != nil {
+		return nil, err
+	}
+	return blockchain, nil
+}
+
+// Returns the sorted names of the nodes in this network with the given
+// IDs. IDs of nodes that aren't in this network are ignored.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) nodeNames(nodeIDs []ids.ShortID) ([]string, error) {
+	if ln.isStopped() {
+		return nil, network.ErrStopped
+	}
+	wanted := ids.ShortSet{}
+	wanted.Add(nodeIDs...)
+	names := []string{}
+	for name, node := range ln.nodes {
+		if wanted.Contains(node.nodeID) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Copies the VM plugin at [pluginBinaryPath] into the plugins directory
+// in the build directory of each node in [nodeNames], as the plugin of
+// VM [vmID]. AvalancheGo only loads plugins when it starts, so restarts
+// each node that didn't already load that plugin. Returns the names
+// of the restarted nodes.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) installPlugin(
+	ctx context.Context,
+	nodeNames []string,
+	vmID ids.ID,
+	pluginBinaryPath string,
+) ([]string, error) {
+	plugin, err := os.ReadFile(pluginBinaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read plugin binary: %w", err)
+	}
+	// Node name --> Its new config, which is nil since only
+	// the plugins it loads change
+	restarts := make(map[string]*node.Config)
+	for _, nodeName := range nodeNames {
+		node, ok := ln.nodes[nodeName]
+		if !ok {
+			return nil, fmt.Errorf("node %q not found", nodeName)
+		}
+		loadedDir, err := loadedPluginDir(node)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't find plugins directory of node %q: %w", nodeName, err)
+		}
+		existing, err := os.ReadFile(filepath.Join(loadedDir, vmID.String()))
+		switch {
+		case err == nil && bytes.Equal(existing, plugin):
+			continue
+		case err != nil && !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("couldn't read plugin of VM %s in %s: %w", vmID, loadedDir, err)
+		}
+		dir := filepath.Join(node.rootDir, nodeBuildDirName, pluginsDirName)
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("couldn't create plugins directory of node %q: %w", nodeName, err)
+		}
+		dst := filepath.Join(dir, vmID.String())
+		ln.log.Info("installing plugin of VM %s to %s", vmID, dst)
+		// Don't overwrite the binary in place, in case it's running
+		tmp := dst + ".tmp"
+		if err := copyFile(pluginBinaryPath, tmp); err != nil {
+			return nil, fmt.Errorf("couldn't copy plugin binary to %s: %w", tmp, err)
+		}
+		if err := os.Rename(tmp, dst); err != nil {
+			return nil, fmt.Errorf("couldn't move plugin binary to %s: %w", dst, err)
+		}
+		restarts[nodeName] = nil
+	}
+	if len(restarts) == 0 {
+		return nil, nil
+	}
+	if err := ln.restartNodes(ctx, restarts); err != nil {
+		return nil, err
+	}
+	restartedNames := make([]string, 0, len(restarts))
+	for nodeName := range restarts {
+		restartedNames = append(restartedNames, nodeName)
+	}
+	sort.Strings(restartedNames)
+	return restartedNames, nil
+}
+
+// Returns the directory that [node] loads VM plugins from: the plugins
+// directory in its build directory if plugins were installed on it,
+// or else the plugins directory of its binary.
+func loadedPluginDir(node *localNode) (string, error) {
+	dir := filepath.Join(node.rootDir, nodeBuildDirName, pluginsDirName)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir, nil
+	}
+	return pluginDir(node.config)
+}
+
+// Returns the flags that make [node] load VM plugins from its build
+// directory, if plugins were installed on it. Before that, links each
+// plugin of the node's binary that wasn't installed into the build
+// directory's plugins directory. This is done each time the node starts,
+// since its binary may have changed, e.g. because it was upgraded.
+// Returns no flags if no plugins were installed on [node].
+func nodeBuildDirFlags(node *localNode) ([]string, error) {
+	buildDir := filepath.Join(node.rootDir, nodeBuildDirName)
+	dir := filepath.Join(buildDir, pluginsDirName)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read plugins directory of node %q: %w", node.name, err)
+	}
+	// Installed plugins are regular files. Links are to the plugins
+	// of the binary the node last ran, so they're replaced.
+	installed := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if err := os.Remove(path); err != nil {
+				return nil, fmt.Errorf("couldn't remove link to plugin: %w", err)
+			}
+			continue
+		}
+		installed[entry.Name()] = struct{}{}
+	}
+	srcDir, err := pluginDir(node.config)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't find plugins directory of node %q's binary: %w", node.name, err)
+	}
+	srcEntries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read plugins directory of node %q's binary: %w", node.name, err)
+	}
+	for _, entry := range srcEntries {
+		if _, ok := installed[entry.Name()]; ok || entry.IsDir() {
+			continue
+		}
+		if err := os.Symlink(filepath.Join(srcDir, entry.Name()), filepath.Join(dir, entry.Name())); err != nil {
+			return nil, fmt.Errorf("couldn't link plugin: %w", err)
+		}
+	}
+	return []string{fmt.Sprintf("--%s=%s", config.BuildDirKey, buildDir)}, nil
+}
+
+// Returns the directory that a node with config [nodeConfig] loads
+// VM plugins from, unless plugins were installed on it. Like
+// AvalancheGo, looks for the build directory in the node's flags,
+// then in its config file, then in the directory of the node's
+// binary and that directory's parent.
+func pluginDir(nodeConfig node.Config) (string, error) {
+	if buildDir, ok := nodeConfig.Flags[config.BuildDirKey]; ok {
+		return filepath.Join(fmt.Sprint(buildDir), pluginsDirName), nil
+	}
+	if len(nodeConfig.ConfigFile) != 0 {
+		var configFile map[string]interface{}
+		if err := json.Unmarshal([]byte(nodeConfig.ConfigFile), &configFile); err != nil {
+			return "", fmt.Errorf("couldn't unmarshal config file: %w", err)
+		}
+		if buildDir, ok := configFile[config.BuildDirKey]; ok {
+			return filepath.Join(fmt.Sprint(buildDir), pluginsDirName), nil
+		}
+	}
+	var localNodeConfig NodeConfig
+	if err := json.Unmarshal(nodeConfig.ImplSpecificConfig, &localNodeConfig); err != nil {
+		return "", fmt.Errorf("couldn't unmarshal local.NodeConfig: %w", err)
+	}
+	binaryPath, err := exec.LookPath(localNodeConfig.BinaryPath)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(binaryPath); err == nil {
+		binaryPath = resolved
+	}
+	binaryPath, err = filepath.Abs(binaryPath)
+	if err != nil {
+		return "", err
+	}
+	binaryDir := filepath.Dir(binaryPath)
+	for _, buildDir := range []string{binaryDir, filepath.Dir(binaryDir)} {
+		dir := filepath.Join(buildDir, pluginsDirName)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("no %s directory next to %s", pluginsDirName, binaryPath)
+}
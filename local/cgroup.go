@@ -0,0 +1,158 @@
+package local
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Where the cgroup v2 hierarchy is mounted
+	defaultCgroupRoot = "/sys/fs/cgroup"
+	// Name of the cgroup, under the cgroup root, that
+	// holds the cgroups of nodes with resource limits
+	cgroupParentName = "avalanche-network-runner"
+	// Period, in microseconds, over which a node's CPU quota applies
+	cgroupCPUPeriod = 100000
+	maxIOWeight     = 10000
+	// How many times, and how often, removing a cgroup is tried
+	// while the processes killed in it are still exiting
+	cgroupRemoveAttempts  = 50
+	cgroupRemoveRetryFreq = 10 * time.Millisecond
+)
+
+// Returns true if [config] limits the resources of the node's process
+func (config NodeConfig) hasResourceLimits() bool {
+	return config.CPUQuota != 0 || config.MemoryMax != 0 || config.IOWeight != 0
+}
+
+// Returns an error if the resource limits in [config] are invalid
+func (config NodeConfig) validateResourceLimits() error {
+	switch {
+	case config.CPUQuota < 0:
+		return fmt.Errorf("CPU quota must be non-negative but is %v", config.CPUQuota)
+	case config.CPUQuota != 0 && config.CPUQuota*cgroupCPUPeriod < 1:
+		return fmt.Errorf("CPU quota %v is too small", config.CPUQuota)
+	case config.IOWeight > maxIOWeight:
+		return fmt.Errorf("IO weight must be between 1 and %d but is %d", maxIOWeight, config.IOWeight)
+	}
+	return nil
+}
+
+// A cgroup v2 that a node's process, and the VM plugins it starts, run in
+type nodeCgroup struct {
+	// Path of the cgroup's directory
+	path string
+}
+
+// Returns the path of the cgroup, under [root], of node [nodeName]'s process [pid]
+func nodeCgroupPath(root string, nodeName string, pid int) string {
+	return filepath.Join(root, cgroupParentName, fmt.Sprintf("%s-%d", nodeName, pid))
+}
+
+// Creates a cgroup under [root] with the resource limits in [config],
+// and moves process [pid] of node [nodeName] into it.
+// The process must be moved before it starts any other process,
+// since processes it already started stay in their cgroup.
+func newNodeCgroup(root string, nodeName string, pid int, config NodeConfig) (*nodeCgroup, error) {
+	if runtime.GOOS != "linux" {
+		return nil, errors.New("resource limits need cgroup v2, which is only available on Linux")
+	}
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("resource limits need cgroup v2 mounted at %s: %w", root, err)
+	}
+	controllers := []string{}
+	limits := map[string]string{}
+	if config.CPUQuota != 0 {
+		controllers = append(controllers, "+cpu")
+		limits["cpu.max"] = fmt.Sprintf("%d %d", int64(config.CPUQuota*cgroupCPUPeriod), cgroupCPUPeriod)
+	}
+	if config.MemoryMax != 0 {
+		controllers = append(controllers, "+memory")
+		limits["memory.max"] = strconv.FormatUint(config.MemoryMax, 10)
+	}
+	if config.IOWeight != 0 {
+		controllers = append(controllers, "+io")
+		limits["io.weight"] = fmt.Sprintf("default %d", config.IOWeight)
+	}
+
+	// The parent holds no processes, so it can pass
+	// controllers on to the cgroups of the nodes
+	parent := filepath.Join(root, cgroupParentName)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return nil, fmt.Errorf("couldn't create cgroup %s: %w", parent, err)
+	}
+	if err := writeCgroupFile(parent, "cgroup.subtree_control", strings.Join(controllers, " ")); err != nil {
+		return nil, err
+	}
+	cgroup := &nodeCgroup{path: nodeCgroupPath(root, nodeName, pid)}
+	if err := os.Mkdir(cgroup.path, 0o755); err != nil {
+		return nil, fmt.Errorf("couldn't create cgroup %s: %w", cgroup.path, err)
+	}
+	for fileName, value := range limits {
+		if err := writeCgroupFile(cgroup.path, fileName, value); err != nil {
+			_ = cgroup.remove()
+			return nil, err
+		}
+	}
+	if err := writeCgroupFile(cgroup.path, "cgroup.procs", strconv.Itoa(pid)); err != nil {
+		_ = cgroup.remove()
+		return nil, err
+	}
+	return cgroup, nil
+}
+
+// Returns the cgroup, under [root], that the runner put process [pid]
+// in because it has resource limits, or nil if it isn't in one
+func nodeCgroupOf(root string, pid int) *nodeCgroup {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	// The cgroup v2 line is "0::<path relative to the root>"
+	prefix := "0::/" + cgroupParentName + "/"
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, prefix) {
+			return &nodeCgroup{path: filepath.Join(root, strings.TrimPrefix(line, "0::"))}
+		}
+	}
+	return nil
+}
+
+// Kills the processes left in this cgroup and removes it.
+// Should be called after the node's process exits.
+func (cg *nodeCgroup) remove() error {
+	// VM plugins may outlive the node, and a cgroup with
+	// processes in it can't be removed. Kernels older than
+	// 5.14 have no cgroup.kill, so errors are ignored.
+	if f, err := os.OpenFile(filepath.Join(cg.path, "cgroup.kill"), os.O_WRONLY, 0); err == nil {
+		_, _ = f.WriteString("1")
+		_ = f.Close()
+	}
+	// On a cgroup filesystem this removes just the directory,
+	// since the files in it can't be removed on their own
+	var err error
+	for i := 0; i < cgroupRemoveAttempts; i++ {
+		if err = os.RemoveAll(cg.path); err == nil {
+			return nil
+		}
+		time.Sleep(cgroupRemoveRetryFreq)
+	}
+	return fmt.Errorf("couldn't remove cgroup %s: %w", cg.path, err)
+}
+
+// Writes [value] to the interface file [fileName] of the cgroup at [path]
+func writeCgroupFile(path string, fileName string, value string) error {
+	if err := os.WriteFile(filepath.Join(path, fileName), []byte(value), 0o644); err != nil {
+		return fmt.Errorf("couldn't write %q to %s of cgroup %s: %w", value, fileName, path, err)
+	}
+	return nil
+}
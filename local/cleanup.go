@@ -0,0 +1,119 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ava-labs/avalanche-network-runner/network"
+)
+
+// Returned by Start after the network's directories were removed
+var ErrCleanedUp = errors.New("network's directories were removed")
+
+// See Network
+func (ln *localNetwork) Cleanup() error {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	return ln.cleanup()
+}
+
+// Assumes [ln.lock] is held
+func (ln *localNetwork) cleanup() error {
+	if !ln.isStopped() {
+		return ErrNotStopped
+	}
+	if ln.cleanedUp {
+		return nil
+	}
+	keep := make([]string, 0, len(ln.userPaths))
+	for path := range ln.userPaths {
+		keep = append(keep, path)
+	}
+	ln.log.Info("removing network directory %s", ln.rootDir)
+	if err := removeAllExcept(ln.rootDir, keep); err != nil {
+		return fmt.Errorf("couldn't remove network directory %s: %w", ln.rootDir, err)
+	}
+	// The stopped nodes' databases are gone
+	ln.stoppedNodes = nil
+	ln.cleanedUp = true
+	return nil
+}
+
+// Applies [ln.cleanupPolicy] to this network after stopping it.
+// [stopErr] is the error that stopping it returned, if any.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) cleanupOnStop(stopErr error) error {
+	switch ln.cleanupPolicy {
+	case network.CleanupAlways:
+	case network.CleanupOnSuccess:
+		if stopErr != nil || ln.failed.GetValue() {
+			ln.log.Info("keeping network directory %s for inspection", ln.rootDir)
+			return nil
+		}
+	default:
+		return nil
+	}
+	return ln.cleanup()
+}
+
+// Remembers the DB and logs directories of [node] that were given in
+// its config file, rather than created by the network in [ln.rootDir],
+// so that cleanup doesn't remove them.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) keepUserPaths(node *localNode) {
+	if ln.userPaths == nil {
+		ln.userPaths = make(map[string]struct{})
+	}
+	if filepath.Clean(node.dbDir) != filepath.Clean(node.rootDir) {
+		ln.userPaths[node.dbDir] = struct{}{}
+	}
+	if filepath.Clean(node.logsDir) != filepath.Join(node.rootDir, defaultLogsDirName) {
+		ln.userPaths[node.logsDir] = struct{}{}
+	}
+}
+
+// Removes [path] and everything under it, except the paths
+// in [keep], everything under them and their parent directories
+func removeAllExcept(path string, keep []string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	hasKept := false
+	for _, keptPath := range keep {
+		keptPath, err := filepath.Abs(keptPath)
+		if err != nil {
+			return err
+		}
+		if isWithin(keptPath, path) {
+			return nil
+		}
+		if isWithin(path, keptPath) {
+			hasKept = true
+		}
+	}
+	if !hasKept {
+		return os.RemoveAll(path)
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := removeAllExcept(filepath.Join(path, entry.Name()), keep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns true if [path] is [dir] or is under [dir].
+// Both must be absolute.
+func isWithin(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,142 @@
+package local
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/ava-labs/avalanche-network-runner/network"
+)
+
+// Extra delay of the traffic that a link drops. Nodes talk over TCP,
+// so a dropped packet is retransmitted rather than lost. This is
+// the minimum retransmission timeout on Linux.
+const retransmissionTimeout = 200 * time.Millisecond
+
+// LinkConditions says how traffic sent from a node to
+// another node is shaped when fault injection is enabled.
+// The zero value doesn't shape traffic, so giving it to a link
+// exempts the link from the default conditions.
+type LinkConditions struct {
+	// One-way delay of the traffic
+	Latency time.Duration
+	// The delay of each chunk of traffic varies from
+	// Latency - Jitter to Latency + Jitter. Since nodes talk
+	// over TCP, traffic is still delivered in order, so a chunk
+	// is never delivered before the chunks sent before it.
+	Jitter time.Duration
+	// Bytes per second the link carries.
+	// If 0, the bandwidth isn't limited.
+	BandwidthBps uint64
+	// Probability, from 0 to 1, that a chunk of traffic is dropped.
+	// Since nodes talk over TCP, a dropped chunk is delayed by a
+	// retransmission timeout rather than lost.
+	DropRate float64
+}
+
+// Returns an error if these conditions are invalid
+func (c LinkConditions) validate() error {
+	switch {
+	case c.Latency < 0:
+		return fmt.Errorf("negative latency %s", c.Latency)
+	case c.Jitter < 0:
+		return fmt.Errorf("negative jitter %s", c.Jitter)
+	case c.DropRate < 0 || c.DropRate > 1:
+		return fmt.Errorf("drop rate %v isn't between 0 and 1", c.DropRate)
+	}
+	return nil
+}
+
+// Returns how long to delay a chunk of traffic sent over a link with
+// these conditions, not counting the time taken to transmit it
+func (c LinkConditions) delay() time.Duration {
+	delay := c.Latency
+	if c.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(2*c.Jitter)+1)) - c.Jitter
+	}
+	if c.DropRate > 0 && rand.Float64() < c.DropRate {
+		delay += retransmissionTimeout
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// Returns how long it takes to transmit [numBytes] over a link
+// with these conditions. 0 if the bandwidth isn't limited.
+func (c LinkConditions) transmissionTime(numBytes int) time.Duration {
+	if c.BandwidthBps == 0 {
+		return 0
+	}
+	return time.Duration(uint64(numBytes) * uint64(time.Second) / c.BandwidthBps)
+}
+
+// See Network
+func (ln *localNetwork) SetLinkConditions(from string, to string, conditions LinkConditions) error {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	if err := ln.checkLink(from, to); err != nil {
+		return err
+	}
+	if err := conditions.validate(); err != nil {
+		return fmt.Errorf("invalid conditions for link from node %q to node %q: %w", from, to, err)
+	}
+	ln.log.Info("setting conditions of link from node %q to node %q to %+v", from, to, conditions)
+	ln.proxies.setConditions(link{src: from, dst: to}, conditions)
+	return nil
+}
+
+// See Network
+func (ln *localNetwork) ClearLinkConditions(from string, to string) error {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	if err := ln.checkLink(from, to); err != nil {
+		return err
+	}
+	ln.log.Info("clearing conditions of link from node %q to node %q", from, to)
+	ln.proxies.clearConditions(link{src: from, dst: to})
+	return nil
+}
+
+// Returns an error if the conditions of the link from the
+// node named [from] to the node named [to] can't be changed
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) checkLink(from string, to string) error {
+	if ln.proxies == nil {
+		return ErrNoFaultInjection
+	}
+	if ln.isStopped() {
+		return network.ErrStopped
+	}
+	for _, nodeName := range []string{from, to} {
+		if _, ok := ln.nodes[nodeName]; !ok {
+			return fmt.Errorf("node %q not found", nodeName)
+		}
+	}
+	if from == to {
+		return fmt.Errorf("node %q has no link to itself", from)
+	}
+	return nil
+}
+
+// See Network
+func (ln *localNetwork) SetDefaultLinkConditions(conditions LinkConditions) error {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	if ln.proxies == nil {
+		return ErrNoFaultInjection
+	}
+	if ln.isStopped() {
+		return network.ErrStopped
+	}
+	if err := conditions.validate(); err != nil {
+		return fmt.Errorf("invalid default link conditions: %w", err)
+	}
+	ln.log.Info("setting default link conditions to %+v", conditions)
+	ln.proxies.setDefaultConditions(conditions)
+	return nil
+}
@@ -0,0 +1,383 @@
+package local
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanche-network-runner/network"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// Files in a node's root directory that everything
+	// the node writes to its stdout and stderr is appended to
+	stdoutLogFileName = "stdout.log"
+	stderrLogFileName = "stderr.log"
+	// Number of lines of each node's output kept in memory
+	nodeLogBufferLines = 1000
+	// How often the files a node's output is appended to are read
+	logTailFreq = 50 * time.Millisecond
+)
+
+// The last lines a node wrote to its stdout and stderr,
+// including the lines written by its previous processes
+type nodeLogs struct {
+	lock sync.Mutex
+	// Ring buffer of at most [nodeLogBufferLines] lines
+	lines []keptLine
+	// Index in [lines] of the oldest line once [lines] is full
+	oldest int
+	// Number of lines ever added, including those no longer kept
+	numAdded uint64
+	// Closed and replaced when a line is added
+	addedCh chan struct{}
+	// When the node's current process was started.
+	// Zero if the node was started by another process.
+	processStart time.Time
+}
+
+// A line kept in nodeLogs
+type keptLine struct {
+	line network.LogLine
+	// When the line was read. The line's time is when it was logged,
+	// which may be long before, e.g. if it was read from a node that
+	// was attached to, and is only to the second.
+	readAt time.Time
+}
+
+func newNodeLogs() *nodeLogs {
+	return &nodeLogs{addedCh: make(chan struct{})}
+}
+
+// Adds [line], which was read at [readAt]
+func (l *nodeLogs) add(line network.LogLine, readAt time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.numAdded++
+	close(l.addedCh)
+	l.addedCh = make(chan struct{})
+	kept := keptLine{line: line, readAt: readAt}
+	if len(l.lines) < nodeLogBufferLines {
+		l.lines = append(l.lines, kept)
+		return
+	}
+	l.lines[l.oldest] = kept
+	l.oldest = (l.oldest + 1) % len(l.lines)
+}
+
+// Records that a new process of the node was started at [t]
+func (l *nodeLogs) setProcessStart(t time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.processStart = t
+}
+
+// Returns the lines kept, oldest first.
+// Assumes [l.lock] is held.
+func (l *nodeLogs) linesKept() []keptLine {
+	lines := make([]keptLine, 0, len(l.lines))
+	lines = append(lines, l.lines[l.oldest:]...)
+	return append(lines, l.lines[:l.oldest]...)
+}
+
+// Returns the lines that match [query], oldest first.
+// Assumes [query] is valid.
+func (l *nodeLogs) get(query network.LogQuery) []network.LogLine {
+	l.lock.Lock()
+	kept := l.linesKept()
+	l.lock.Unlock()
+
+	lines := make([]network.LogLine, len(kept))
+	for i, kept := range kept {
+		lines[i] = kept.line
+	}
+	return network.FilterLogLines(lines, query)
+}
+
+// Returns the lines kept that were written by the node's current
+// process and added after the first [numSeen] lines ever added, oldest
+// first. Also returns the number of lines added so far, and a channel
+// that's closed when another line is added.
+func (l *nodeLogs) linesAfter(numSeen uint64) ([]network.LogLine, uint64, <-chan struct{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	lines := l.linesKept()
+	// Lines before the first [numSeen] may no longer be kept
+	if numUnseen := l.numAdded - numSeen; numUnseen < uint64(len(lines)) {
+		lines = lines[uint64(len(lines))-numUnseen:]
+	}
+	current := make([]network.LogLine, 0, len(lines))
+	for _, kept := range lines {
+		if !kept.readAt.Before(l.processStart) {
+			current = append(current, kept.line)
+		}
+	}
+	return current, l.numAdded, l.addedCh
+}
+
+// Calls a function with each line appended to a file. A node writes
+// its output to files rather than to pipes read by this process, so
+// that it doesn't get a SIGPIPE once this process exits, and so that
+// it's never held up by how fast its output is read.
+type fileTailer struct {
+	file   *os.File
+	onLine func(readAt time.Time, text string)
+	// Closed to stop tailing once the rest of the file is read
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	// Closed when tailing stopped
+	doneCh chan struct{}
+}
+
+// Starts calling [onLine] with each line of the file at [path] after its
+// first [offset] bytes, including lines appended later, until Close is
+// called. [onLine] is called from one goroutine at a time.
+func tailFile(path string, offset int64, onLine func(readAt time.Time, text string)) (*fileTailer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open log file: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("couldn't seek log file: %w", err)
+	}
+	t := &fileTailer{
+		file:   file,
+		onLine: onLine,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	go t.tail()
+	return t, nil
+}
+
+func (t *fileTailer) tail() {
+	defer close(t.doneCh)
+	defer t.file.Close()
+
+	reader := bufio.NewReader(t.file)
+	// Read after the last newline
+	partial := ""
+	stopping := false
+	for {
+		text, err := reader.ReadString('\n')
+		partial += text
+		if err == nil {
+			t.onLine(time.Now(), strings.TrimSuffix(partial, "\n"))
+			partial = ""
+			continue
+		}
+		if err != io.EOF || stopping {
+			break
+		}
+		// Read the rest of the file once more after
+		// being stopped, since it may have grown
+		select {
+		case <-t.stopCh:
+			stopping = true
+		case <-time.After(logTailFreq):
+		}
+	}
+	if len(partial) > 0 {
+		t.onLine(time.Now(), partial)
+	}
+}
+
+// Reads the rest of the file, including an unterminated last line, and
+// stops tailing it. Should be called once the node's process has exited.
+func (t *fileTailer) Close() error {
+	t.stopOnce.Do(func() { close(t.stopCh) })
+	<-t.doneCh
+	return nil
+}
+
+// Starts adding the lines that [node]'s process appends to its log files
+// after their first [stdoutOffset] and [stderrOffset] bytes to
+// [node.logs]. The returned tailers should be closed once it exits.
+func tailNodeLogs(node *localNode, stdoutOffset int64, stderrOffset int64) ([]io.Closer, error) {
+	stdout, err := tailFile(filepath.Join(node.rootDir, stdoutLogFileName), stdoutOffset, func(readAt time.Time, text string) {
+		node.logs.add(newNodeLogLine(readAt, network.StdoutStream, text), readAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := tailFile(filepath.Join(node.rootDir, stderrLogFileName), stderrOffset, func(readAt time.Time, text string) {
+		node.logs.add(newNodeLogLine(readAt, network.StderrStream, text), readAt)
+	})
+	if err != nil {
+		_ = stdout.Close()
+		return nil, err
+	}
+	return []io.Closer{stdout, stderr}, nil
+}
+
+// Returns the line [text] that a node wrote to [stream], read at [readAt].
+// Its time is the one it was logged at, if it's an avalanchego log line,
+// since lines already in the log file when it's first read, e.g. after
+// attaching to the node, are read long after they're written.
+func newNodeLogLine(readAt time.Time, stream string, text string) network.LogLine {
+	line := network.NewLogLine(readAt, stream, text)
+	if record, ok := network.ParseLogLine(text, readAt); ok {
+		line.Time = record.Time
+	}
+	return line
+}
+
+// Opens the file at [path], which a node's process appends its output
+// to, creating it if needed. Also returns its size, which is where the
+// process' output starts.
+func openLogFile(path string) (*os.File, int64, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, 0, fmt.Errorf("couldn't open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, 0, fmt.Errorf("couldn't stat log file: %w", err)
+	}
+	return file, info.Size(), nil
+}
+
+// Returns the last [maxLines] lines of the file at [path]
+// after its first [offset] bytes, or nil if it can't be read
+func lastLines(path string, offset int64, maxLines int) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	tail := newLineTail(maxLines)
+	if _, err := io.Copy(tail, io.NewSectionReader(file, offset, math.MaxInt64-offset)); err != nil {
+		return nil
+	}
+	return tail.Lines()
+}
+
+// See Network
+func (ln *localNetwork) WaitForLog(ctx context.Context, nodeName string, re *regexp.Regexp) (network.LogLine, error) {
+	ln.lock.RLock()
+	if ln.isStopped() {
+		ln.lock.RUnlock()
+		return network.LogLine{}, network.ErrStopped
+	}
+	node, ok := ln.nodes[nodeName]
+	if !ok {
+		ln.lock.RUnlock()
+		return network.LogLine{}, fmt.Errorf("node %q not found in network", nodeName)
+	}
+	closedOnStopCh := ln.closedOnStopCh
+	ln.lock.RUnlock()
+
+	return waitForLog(ctx, node, re, closedOnStopCh)
+}
+
+// See Network
+func (ln *localNetwork) WaitForNetworkLog(ctx context.Context, re *regexp.Regexp, all bool) (map[string]network.LogLine, error) {
+	ln.lock.RLock()
+	if ln.isStopped() {
+		ln.lock.RUnlock()
+		return nil, network.ErrStopped
+	}
+	nodes := make([]*localNode, 0, len(ln.nodes))
+	for _, node := range ln.nodes {
+		nodes = append(nodes, node)
+	}
+	closedOnStopCh := ln.closedOnStopCh
+	ln.lock.RUnlock()
+
+	if len(nodes) == 0 {
+		return nil, errors.New("network has no nodes")
+	}
+	// Cancelled once any node writes a matching line, unless [all]
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	lock := sync.Mutex{}
+	matches := make(map[string]network.LogLine, len(nodes))
+	errGr, errGrCtx := errgroup.WithContext(waitCtx)
+	for _, node := range nodes {
+		node := node
+		errGr.Go(func() error {
+			line, err := waitForLog(errGrCtx, node, re, closedOnStopCh)
+			lock.Lock()
+			defer lock.Unlock()
+
+			// Another node may have written a matching line first
+			if !all && len(matches) > 0 {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("node %q: %w", node.name, err)
+			}
+			matches[node.name] = line
+			if !all {
+				cancel()
+			}
+			return nil
+		})
+	}
+	if err := errGr.Wait(); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// Waits until the current process of [node] writes a line that [re]
+// matches, [ctx] is done or [closedOnStopCh] is closed
+func waitForLog(
+	ctx context.Context,
+	node *localNode,
+	re *regexp.Regexp,
+	closedOnStopCh <-chan struct{},
+) (network.LogLine, error) {
+	numSeen := uint64(0)
+	for {
+		lines, numAdded, addedCh := node.logs.linesAfter(numSeen)
+		for _, line := range lines {
+			if re.MatchString(line.Text) {
+				return line, nil
+			}
+		}
+		numSeen = numAdded
+		select {
+		case <-addedCh:
+		case <-closedOnStopCh:
+			return network.LogLine{}, network.ErrStopped
+		case <-ctx.Done():
+			return network.LogLine{}, fmt.Errorf("no line matching %q: %w", re, ctx.Err())
+		}
+	}
+}
+
+// See network.Network
+func (ln *localNetwork) GetNodeLogs(_ context.Context, nodeName string, query network.LogQuery) ([]network.LogLine, error) {
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid log query: %w", err)
+	}
+
+	ln.lock.RLock()
+	defer ln.lock.RUnlock()
+
+	if ln.isStopped() {
+		return nil, network.ErrStopped
+	}
+	node, ok := ln.nodes[nodeName]
+	if !ok {
+		return nil, fmt.Errorf("node %q not found in network", nodeName)
+	}
+	return node.logs.get(query), nil
+}
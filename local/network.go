@@ -4,14 +4,18 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/ava-labs/avalanche-network-runner/api"
@@ -19,6 +23,8 @@ import (
 	"github.com/ava-labs/avalanche-network-runner/network/node"
 	"github.com/ava-labs/avalanche-network-runner/utils"
 	"github.com/ava-labs/avalanchego/config"
+	"github.com/ava-labs/avalanchego/ids"
+	avalancheutils "github.com/ava-labs/avalanchego/utils"
 	avalancheconstants "github.com/ava-labs/avalanchego/utils/constants"
 	"github.com/ava-labs/avalanchego/utils/logging"
 	"github.com/ava-labs/avalanchego/utils/wrappers"
@@ -32,13 +38,22 @@ const (
 	stakingCertFileName   = "staking.crt"
 	genesisFileName       = "genesis.json"
 	stopTimeout           = 30 * time.Second
-	healthCheckFreq       = 3 * time.Second
 	defaultNumNodes       = 5
+	// How often Healthy queries the health of nodes that aren't healthy yet
+	defaultHealthCheckFreq = 3 * time.Second
+	// Time a node has to exit after being sent a SIGTERM, before
+	// its process group is killed
+	defaultStopGracePeriod = 10 * time.Second
+	// Number of lines of a node's stderr kept to explain why it exited
+	stderrTailLines = 20
+	// Name of the directory in a node's root directory that
+	// its logs are written to, unless its config file says otherwise
+	defaultLogsDirName = "logs"
 )
 
 // interface compliance
 var (
-	_ network.Network    = (*localNetwork)(nil)
+	_ Network            = (*localNetwork)(nil)
 	_ NodeProcessCreator = (*nodeProcessCreator)(nil)
 
 	warnFlags = map[string]struct{}{
@@ -48,6 +63,115 @@ var (
 	}
 )
 
+var (
+	ErrNodePaused = errors.New("node is paused")
+	ErrNotStopped = errors.New("network isn't stopped")
+)
+
+// NodesKilledError is returned when stopping nodes that didn't exit
+// within the grace period after being sent a SIGTERM, so their process
+// groups were killed
+type NodesKilledError struct {
+	NodeNames []string
+}
+
+func (e *NodesKilledError) Error() string {
+	return fmt.Sprintf("killed nodes that didn't stop in time: %s", strings.Join(e.NodeNames, ", "))
+}
+
+// Network is a local Avalanche network.
+// In addition to the methods of network.Network, it
+// can pause and resume the processes running its nodes,
+// start again after being stopped and, if fault injection
+// is enabled, be partitioned and have its traffic shaped.
+type Network interface {
+	network.Network
+	// Start the nodes that were running when Stop() was called, with the
+	// same names, configs, ports and directories, so that they keep their
+	// databases. Beacons are started first. Doesn't wait for the nodes
+	// to be healthy. If a node fails to start, the nodes already started
+	// are stopped again, and the network stays stopped.
+	// Returns ErrNotStopped if the network is running, or ErrCleanedUp
+	// if its directories were removed.
+	Start(ctx context.Context) error
+	// Remove the network's root directory, which has each node's
+	// database, logs, config files and staking key/cert, unless the
+	// node's config file put its database or logs elsewhere. Database
+	// and logs directories given in config files are never removed.
+	// The network's config may also say to do this when it's stopped.
+	// Returns ErrNotStopped if the network is running.
+	Cleanup() error
+	// Send a SIGSTOP to the process of the node with this name.
+	// The node stops responding but its TCP connections stay open.
+	// Healthy reports a paused node as unhealthy with ErrNodePaused.
+	// Returns ErrStopped if Stop() was previously called.
+	PauseNode(name string) error
+	// Send a SIGCONT to the process of the node with this name,
+	// which must have been paused with PauseNode.
+	// Returns ErrStopped if Stop() was previously called.
+	ResumeNode(name string) error
+	// Stop the network and save a snapshot named [name] of each
+	// node's database, staking key/cert, config files and genesis,
+	// along with the network's config, so that an equivalent network
+	// can be started with LoadSnapshot.
+	// The snapshot is saved in ~/.avalanche-network-runner/snapshots/[name].
+	// Returns the snapshot's directory. The network's cleanup policy
+	// is applied once the snapshot is saved.
+	// Returns ErrStopped if Stop() was previously called.
+	SaveSnapshot(ctx context.Context, name string) (string, error)
+	// Partition the network into [groups] of node names. Nodes in
+	// different groups can't send each other messages until Heal is
+	// called, but stay connected, as if the network between them were
+	// down. Nodes that aren't in any group, including nodes added
+	// later, form one more group. Replaces the current partition.
+	// Returns ErrNoFaultInjection if the network's config didn't
+	// enable fault injection.
+	// Returns ErrStopped if Stop() was previously called.
+	Partition(groups ...[]string) error
+	// Let all nodes send each other messages again, including
+	// the messages held while the network was partitioned.
+	// Returns ErrNoFaultInjection if the network's config didn't
+	// enable fault injection.
+	// Returns ErrStopped if Stop() was previously called.
+	Heal() error
+	// Shape the traffic that the node named [from] sends to the node
+	// named [to] by [conditions]. These take precedence over the default
+	// conditions, so if [conditions] is the zero value, the link's traffic
+	// isn't shaped at all. Applies to traffic sent from now on.
+	// Returns ErrNoFaultInjection if the network's config didn't
+	// enable fault injection.
+	// Returns ErrStopped if Stop() was previously called.
+	SetLinkConditions(from string, to string, conditions LinkConditions) error
+	// Remove the conditions that SetLinkConditions gave the link from
+	// the node named [from] to the node named [to], so that the default
+	// conditions apply to it again. Applies to traffic sent from now on.
+	// Returns ErrNoFaultInjection if the network's config didn't
+	// enable fault injection.
+	// Returns ErrStopped if Stop() was previously called.
+	ClearLinkConditions(from string, to string) error
+	// Shape the traffic between nodes by [conditions], unless the
+	// link it's sent over has conditions of its own. Applies to
+	// traffic sent from now on, including by nodes added later.
+	// Returns ErrNoFaultInjection if the network's config didn't
+	// enable fault injection.
+	// Returns ErrStopped if Stop() was previously called.
+	SetDefaultLinkConditions(conditions LinkConditions) error
+	// Wait until the process of the node named [nodeName] writes a line
+	// to its stdout or stderr that [re] matches, and return that line.
+	// Lines its current process wrote before this call are matched too,
+	// as long as they're among the last lines kept in memory, so that
+	// the line isn't missed if it was written just before this call.
+	// Returns an error if [ctx] is done first or the network stops.
+	// Returns ErrStopped if Stop() was previously called.
+	WaitForLog(ctx context.Context, nodeName string, re *regexp.Regexp) (network.LogLine, error)
+	// Like WaitForLog, but waits for any node of the network to write
+	// a matching line or, if [all], for each node to write one. Only
+	// the nodes in the network when this is called are waited for.
+	// Node name --> The line it wrote. Has one line unless [all].
+	// Returns ErrStopped if Stop() was previously called.
+	WaitForNetworkLog(ctx context.Context, re *regexp.Regexp, all bool) (map[string]network.LogLine, error)
+}
+
 // network keeps information uses for network management, and accessing all the nodes
 type localNetwork struct {
 	lock sync.RWMutex
@@ -74,6 +198,40 @@ type localNetwork struct {
 	rootDir string
 	// Flags to apply to all nodes if not present
 	flags map[string]interface{}
+	// Sends this network's events to subscribers
+	events *network.EventBroker
+	// Pays for P-Chain transactions. May be empty.
+	fundedPrivateKey string
+	// Where SaveSnapshot saves snapshots.
+	// If empty, the default snapshots directory is used.
+	snapshotsDir string
+	// The nodes that were running when this network was last
+	// stopped, which Start starts again. Node name --> Node.
+	stoppedNodes map[string]*localNode
+	// The nodes of the stopped network whose state was in [rootDir]
+	// when this network was created. Nodes resumed from their
+	// directories keep their ports. Node name --> Node's state.
+	previousNodes map[string]nodeState
+	// When to remove [rootDir]
+	cleanupPolicy network.CleanupPolicy
+	// Time a node has to exit after being sent a
+	// SIGTERM, before its process group is killed
+	stopGracePeriod time.Duration
+	// How often Healthy queries the health of nodes that aren't healthy yet
+	healthCheckFreq time.Duration
+	// Directories given in nodes' config files,
+	// which cleanup doesn't remove
+	userPaths map[string]struct{}
+	// True if [rootDir] was removed by cleanup
+	cleanedUp bool
+	// Set when a node exits unexpectedly or is reported unhealthy.
+	// Reset when the network is started again.
+	failed avalancheutils.AtomicBool
+	// The proxies that nodes connect to each other through.
+	// Nil unless fault injection is enabled.
+	proxies *linkProxies
+	// Gives nodes their API and P2P ports
+	ports *portAllocator
 }
 
 var (
@@ -99,6 +257,8 @@ func init() {
 		Name:        "my network",
 		NodeConfigs: make([]node.Config, defaultNumNodes),
 		LogLevel:    "INFO",
+		// Funded on the P-Chain in the default genesis
+		FundedPrivateKey: "PrivateKey-ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN",
 	}
 
 	genesis, err := fs.ReadFile(configsDir, "genesis.json")
@@ -134,7 +294,12 @@ func init() {
 
 // NodeProcessCreator is an interface for new node process creation
 type NodeProcessCreator interface {
-	NewNodeProcess(config node.Config, args ...string) (NodeProcess, error)
+	// Returns a process that runs the node described by [config] with
+	// [args]. Unless they're nil, the process must write its stdout and
+	// stderr directly to the files [stdout] and [stderr], rather than
+	// through pipes read by this process, so that it keeps running if
+	// this process exits.
+	NewNodeProcess(config node.Config, stdout *os.File, stderr *os.File, args ...string) (NodeProcess, error)
 }
 
 type nodeProcessCreator struct {
@@ -147,38 +312,67 @@ type nodeProcessCreator struct {
 	// If this node's stderr is redirected, it will be to here.
 	// In practice this is usually os.Stderr, but for testing can be replaced.
 	stderr io.Writer
+	// Where the cgroup v2 hierarchy is mounted. If empty, it's [defaultCgroupRoot].
+	// Can be replaced for testing.
+	cgroupRoot string
 }
 
 // NewNodeProcess creates a new process of the passed binary
 // If the config has redirection set to `true` for either StdErr or StdOut,
 // the output will be redirected and colored
-func (npc *nodeProcessCreator) NewNodeProcess(config node.Config, args ...string) (NodeProcess, error) {
+func (npc *nodeProcessCreator) NewNodeProcess(
+	config node.Config,
+	stdout *os.File,
+	stderr *os.File,
+	args ...string,
+) (NodeProcess, error) {
 	var localNodeConfig NodeConfig
 	if err := json.Unmarshal(config.ImplSpecificConfig, &localNodeConfig); err != nil {
 		return nil, fmt.Errorf("couldn't unmarshal local.NodeConfig: %w", err)
 	}
+	if err := localNodeConfig.validateResourceLimits(); err != nil {
+		return nil, fmt.Errorf("invalid resource limits: %w", err)
+	}
+	if localNodeConfig.RedirectLevel != "" {
+		if _, err := logging.ToLevel(localNodeConfig.RedirectLevel); err != nil {
+			return nil, fmt.Errorf("invalid redirect level: %w", err)
+		}
+	}
+	cgroupRoot := npc.cgroupRoot
+	if cgroupRoot == "" {
+		cgroupRoot = defaultCgroupRoot
+	}
 	// Start the AvalancheGo node and pass it the flags defined above
 	cmd := exec.Command(localNodeConfig.BinaryPath, args...)
+	// Put the node and the VM plugins it starts in their own
+	// process group, so that they can all be killed together
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	// Passing the files themselves, rather than other writers, gives them
+	// to the process without a pipe between it and this process
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	if stderr != nil {
+		cmd.Stderr = stderr
+	}
+	process := &nodeProcessImpl{
+		cmd:        cmd,
+		stdout:     stdout,
+		stderr:     stderr,
+		nodeName:   config.Name,
+		cgroupRoot: cgroupRoot,
+		limits:     localNodeConfig,
+	}
 	// assign a new color to this process (might not be used if the localNodeConfig isn't set for it)
 	color := npc.colorPicker.NextColor()
-	// Optionally redirect stdout and stderr
+	// Optionally print stdout and stderr, colored or as JSON, as the config says
 	if localNodeConfig.RedirectStdout {
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			return nil, fmt.Errorf("Could not create stdout pipe: %s", err)
-		}
-		// redirect stdout and assign a color to the text
-		utils.ColorAndPrepend(stdout, npc.stdout, config.Name, color)
+		process.stdoutPrinter = newOutputPrinter(config.Name, network.StdoutStream, color, localNodeConfig, npc.stdout)
 	}
 	if localNodeConfig.RedirectStderr {
-		stderr, err := cmd.StderrPipe()
-		if err != nil {
-			return nil, fmt.Errorf("Could not create stderr pipe: %s", err)
-		}
-		// redirect stderr and assign a color to the text
-		utils.ColorAndPrepend(stderr, npc.stderr, config.Name, color)
+		process.stderrPrinter = newOutputPrinter(config.Name, network.StderrStream, color, localNodeConfig, npc.stderr)
 	}
-	return &nodeProcessImpl{cmd: cmd}, nil
+	return process, nil
 }
 
 type beaconList map[string]struct{}
@@ -199,15 +393,32 @@ func (l beaconList) String() string {
 	return s.String()
 }
 
+// Returns a copy of [l] that doesn't contain [beacon]
+func (l beaconList) without(beacon string) beaconList {
+	newList := make(beaconList, len(l))
+	for b := range l {
+		if b != beacon {
+			newList[b] = struct{}{}
+		}
+	}
+	return newList
+}
+
 // NewNetwork call newNetwork with no mocking
 func NewNetwork(
 	log logging.Logger,
 	networkConfig network.Config,
-) (network.Network, error) {
+) (Network, error) {
 	return NewNetworkWithDir(log, networkConfig, "")
 }
 
-func NewNetworkWithDir(log logging.Logger, networkConfig network.Config, networkDir string) (network.Network, error) {
+// NewNetworkWithDir returns a new network whose nodes' directories are
+// in [networkDir]. If [networkDir] is empty, a temporary directory is used.
+// Nodes whose directories already exist in [networkDir] resume from them,
+// keeping their databases, so a network that was stopped can be resumed
+// by creating it again with the same config and directory. They also
+// keep the ports they had, if those are still free.
+func NewNetworkWithDir(log logging.Logger, networkConfig network.Config, networkDir string) (Network, error) {
 	return newNetwork(log, networkConfig, api.NewAPIClient, &nodeProcessCreator{
 		colorPicker: utils.NewColorPicker(),
 		stdout:      os.Stdout,
@@ -222,7 +433,7 @@ func newNetwork(
 	newAPIClientF api.NewAPIClientF,
 	nodeProcessCreator NodeProcessCreator,
 	networkDir string,
-) (network.Network, error) {
+) (Network, error) {
 	if err := networkConfig.Validate(); err != nil {
 		return nil, fmt.Errorf("config failed validation: %w", err)
 	}
@@ -245,6 +456,15 @@ func newNetwork(
 		newAPIClientF:      newAPIClientF,
 		nodeProcessCreator: nodeProcessCreator,
 		flags:              networkConfig.Flags,
+		events:             network.NewEventBroker(),
+		fundedPrivateKey:   networkConfig.FundedPrivateKey,
+		cleanupPolicy:      networkConfig.CleanupPolicy,
+		stopGracePeriod:    defaultStopGracePeriod,
+		healthCheckFreq:    defaultHealthCheckFreq,
+		ports:              newPortAllocator(networkConfig.Ports),
+	}
+	if networkConfig.FaultInjection {
+		net.proxies = newLinkProxies(log)
 	}
 
 	// Sort node configs so beacons start first
@@ -267,16 +487,28 @@ func newNetwork(
 		}
 	} else {
 		net.rootDir = networkDir
+		// It may have been removed by cleanup
+		if err := os.MkdirAll(net.rootDir, 0o755); err != nil {
+			return nil, err
+		}
+		if err := net.readPreviousState(); err != nil {
+			return nil, err
+		}
 	}
 
-	for _, nodeConfig := range nodeConfigs {
-		if _, err := net.addNode(nodeConfig); err != nil {
-			if err := net.stop(context.Background()); err != nil {
-				// Clean up nodes already created
-				log.Debug("error stopping network: %s", err)
-			}
-			return nil, fmt.Errorf("error adding node %s: %s", nodeConfig.Name, err)
+	// Nodes that exit before this returns are supervised with the lock
+	net.lock.Lock()
+	defer net.lock.Unlock()
+
+	if err := net.addNodes(nodeConfigs); err != nil {
+		// Clean up nodes already created
+		if err := net.stop(context.Background()); err != nil {
+			log.Debug("error stopping network: %s", err)
 		}
+		if err := net.cleanupOnStop(err); err != nil {
+			log.Debug("error cleaning up network: %s", err)
+		}
+		return nil, err
 	}
 	return net, nil
 }
@@ -303,7 +535,7 @@ func newNetwork(
 func NewDefaultNetwork(
 	log logging.Logger,
 	binaryPath string,
-) (network.Network, error) {
+) (Network, error) {
 	return newDefaultNetwork(log, binaryPath, api.NewAPIClient, &nodeProcessCreator{
 		colorPicker: utils.NewColorPicker(),
 		stdout:      os.Stdout,
@@ -316,7 +548,7 @@ func newDefaultNetwork(
 	binaryPath string,
 	newAPIClientF api.NewAPIClientF,
 	nodeProcessCreator NodeProcessCreator,
-) (network.Network, error) {
+) (Network, error) {
 	config := NewDefaultConfig(binaryPath)
 	return newNetwork(log, config, newAPIClientF, nodeProcessCreator, "")
 }
@@ -342,27 +574,97 @@ func (ln *localNetwork) AddNode(nodeConfig node.Config) (node.Node, error) {
 }
 
 // Assumes [ln.lock] is held.
-// TODO make this method shorter
 func (ln *localNetwork) addNode(nodeConfig node.Config) (node.Node, error) {
-	if ln.isStopped() {
-		return nil, network.ErrStopped
+	node, err := ln.newNode(nodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := ln.startNode(node); err != nil {
+		ln.ports.release(node.apiPort, node.p2pPort)
+		return nil, err
 	}
+	ln.registerNode(node)
+	return node, nil
+}
 
-	for flagName, flagVal := range ln.flags {
-		if nodeConfig.Flags == nil {
-			nodeConfig.Flags = make(map[string]interface{})
+// Adds the nodes with configs [nodeConfigs] to this network.
+// The beacons are started first, one at a time, as by addNode. Since
+// the beacon list no longer changes after that, the other nodes are
+// then started in parallel, unless fault injection is enabled, in which
+// case each node bootstraps from the nodes started before it, so they're
+// all started one at a time. If a node fails to start, returns an error
+// after the other nodes are started; the nodes that started are in
+// this network, so stopping the network stops them.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) addNodes(nodeConfigs []node.Config) error {
+	nonBeacons := []*localNode{}
+	// Names of the nodes in [nonBeacons], which aren't in [ln.nodes] yet
+	nonBeaconNames := map[string]struct{}{}
+	// Called if the nodes in [nonBeacons] won't be started
+	releaseNonBeacons := func() {
+		for _, node := range nonBeacons {
+			ln.ports.release(node.apiPort, node.p2pPort)
 		}
-		// If the same flag is given in network config and node config,
-		// the flag in the node config takes precedence
-		if val, ok := nodeConfig.Flags[flagName]; !ok {
-			nodeConfig.Flags[flagName] = flagVal
+	}
+	for _, nodeConfig := range nodeConfigs {
+		if nodeConfig.IsBeacon || ln.proxies != nil {
+			if _, err := ln.addNode(nodeConfig); err != nil {
+				releaseNonBeacons()
+				return fmt.Errorf("error adding node %s: %w", nodeConfig.Name, err)
+			}
+			continue
+		}
+		node, err := ln.newNode(nodeConfig)
+		if err != nil {
+			releaseNonBeacons()
+			return fmt.Errorf("error adding node %s: %w", nodeConfig.Name, err)
+		}
+		if _, ok := nonBeaconNames[node.name]; ok {
+			ln.ports.release(node.apiPort, node.p2pPort)
+			releaseNonBeacons()
+			return fmt.Errorf("repeated node name %s", node.name)
+		}
+		nonBeaconNames[node.name] = struct{}{}
+		nonBeacons = append(nonBeacons, node)
+	}
+
+	started := make([]bool, len(nonBeacons))
+	errGr := errgroup.Group{}
+	// [ln.lock] is held while the nodes are started, so startNode
+	// doesn't take it, which is what lets the nodes start in parallel
+	for i, node := range nonBeacons {
+		i, node := i, node
+		errGr.Go(func() error {
+			if err := ln.startNode(node); err != nil {
+				return fmt.Errorf("error adding node %s: %w", node.name, err)
+			}
+			started[i] = true
+			return nil
+		})
+	}
+	err := errGr.Wait()
+	for i, node := range nonBeacons {
+		if started[i] {
+			ln.registerNode(node)
 		} else {
-			ln.log.Info(
-				"not overwriting node config flag %s (value %v) with network config flag (value %v)",
-				flagName, val, flagVal,
-			)
+			ln.ports.release(node.apiPort, node.p2pPort)
 		}
 	}
+	return err
+}
+
+// Returns a node with config [nodeConfig] that can be started with
+// startNode. Gives it the network's flags, a name if it has none,
+// a root directory, ports if its config doesn't give them and,
+// if it's a beacon, adds it to the beacon lists.
+// Assumes [ln.lock] is held.
+// TODO make this method shorter
+func (ln *localNetwork) newNode(nodeConfig node.Config) (*localNode, error) {
+	if ln.isStopped() {
+		return nil, network.ErrStopped
+	}
+
+	ln.addNetworkFlags(&nodeConfig)
 
 	// If no name was given, use default name pattern
 	if len(nodeConfig.Name) == 0 {
@@ -385,7 +687,8 @@ func (ln *localNetwork) addNode(nodeConfig node.Config) (node.Node, error) {
 	nodeRootDir := filepath.Join(ln.rootDir, nodeConfig.Name)
 	if err := os.Mkdir(nodeRootDir, 0o755); err != nil {
 		if os.IsExist(err) {
-			ln.log.Warn("node root directory %s already exists", nodeRootDir)
+			// The node keeps its database, unless the config file moves it
+			ln.log.Info("resuming node %q from existing directory %s", nodeConfig.Name, nodeRootDir)
 		} else {
 			return nil, fmt.Errorf("error creating temp dir: %w", err)
 		}
@@ -410,7 +713,7 @@ func (ln *localNetwork) addNode(nodeConfig node.Config) (node.Node, error) {
 	}
 
 	// Tell the node to put the log directory in [tmpDir/logs], unless given in config file
-	logsDir := filepath.Join(nodeRootDir, "logs")
+	logsDir := filepath.Join(nodeRootDir, defaultLogsDirName)
 	if logsDirIntf, ok := configFile[config.LogsDirKey]; ok {
 		if logsDirFromConfig, ok := logsDirIntf.(string); ok {
 			logsDir = logsDirFromConfig
@@ -419,64 +722,181 @@ func (ln *localNetwork) addNode(nodeConfig node.Config) (node.Node, error) {
 		}
 	}
 
-	// Use random free API port, unless given in node config flags or config file
-	var (
-		apiPort uint16
-		err     error
+	// Use free API and P2P ports, unless given in node config flags or config file
+	previous := ln.previousNodes[nodeConfig.Name]
+	apiPort, err := ln.assignPort(nodeConfig, configFile, config.HTTPPortKey, previous.APIPort)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get API port: %w", err)
+	}
+	p2pPort, err := ln.assignPort(nodeConfig, configFile, config.StakingPortKey, previous.P2PPort)
+	if err != nil {
+		ln.ports.release(apiPort)
+		return nil, fmt.Errorf("couldn't get P2P port: %w", err)
+	}
+
+	// Parse this node's ID
+	nodeID, err := utils.ToNodeID([]byte(nodeConfig.StakingKey), []byte(nodeConfig.StakingCert))
+	if err != nil {
+		ln.ports.release(apiPort, p2pPort)
+		return nil, fmt.Errorf("couldn't create node ID: %w", err)
+	}
+
+	// If this node is a beacon, add its IP/ID to the beacon lists.
+	// Note that startNode leaves a node's own IP/ID out of the
+	// bootstrap flags it gives the node, so this node won't try
+	// to use itself as a beacon.
+	if nodeConfig.IsBeacon {
+		ln.bootstrapIDs[nodeID.PrefixedString(avalancheconstants.NodeIDPrefix)] = struct{}{}
+		ln.bootstrapIPs[fmt.Sprintf("127.0.0.1:%d", p2pPort)] = struct{}{}
+	}
+
+	ln.log.Info(
+		"adding node %q with tmp dir at %s, logs at %s, DB at %s, P2P port %d, API port %d",
+		nodeConfig.Name, nodeRootDir, logsDir, dbPath, p2pPort, apiPort,
 	)
-	if apiPortIntf, ok := nodeConfig.Flags[config.HTTPPortKey]; ok {
-		if apiPortFromNodeConfigFlags, ok := apiPortIntf.(int); ok {
-			apiPort = uint16(apiPortFromNodeConfigFlags)
-		} else {
-			return nil, fmt.Errorf("expected flag %q to be int but got %T", config.HTTPPortKey, apiPortIntf)
-		}
-	} else if apiPortIntf, ok := configFile[config.HTTPPortKey]; ok {
-		if apiPortFromConfigFile, ok := apiPortIntf.(float64); ok {
-			apiPort = uint16(apiPortFromConfigFile)
-		} else {
-			return nil, fmt.Errorf("expected flag %q to be float64 but got %T", config.HTTPPortKey, apiPortIntf)
+
+	// Create a wrapper for this node so we can reference it later
+	node := &localNode{
+		name:    nodeConfig.Name,
+		nodeID:  nodeID,
+		config:  nodeConfig,
+		client:  ln.newAPIClientF("localhost", apiPort),
+		rootDir: nodeRootDir,
+		dbDir:   dbPath,
+		logsDir: logsDir,
+		apiPort: apiPort,
+		p2pPort: p2pPort,
+		logs:    newNodeLogs(),
+	}
+	return node, nil
+}
+
+// Returns the port named [portKey] of the node with config [nodeConfig]
+// and config file [configFile], and assigns it to the node. The port
+// given in the node's flags or config file, if any, is used. Otherwise
+// the node keeps [previousPort], the port it had before the network was
+// stopped, if it's non-zero and available, or is given a free port.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) assignPort(
+	nodeConfig node.Config,
+	configFile map[string]interface{},
+	portKey string,
+	previousPort uint16,
+) (uint16, error) {
+	if portIntf, ok := nodeConfig.Flags[portKey]; ok {
+		portFromNodeConfigFlags, ok := portIntf.(int)
+		if !ok {
+			return 0, fmt.Errorf("expected flag %q to be int but got %T", portKey, portIntf)
 		}
-	} else {
-		// Use a random free port.
-		// Note: it is possible but unlikely for getFreePort to return the same port multiple times.
-		apiPort, err = getFreePort()
-		if err != nil {
-			return nil, fmt.Errorf("couldn't get free API port: %w", err)
+		port := uint16(portFromNodeConfigFlags)
+		return port, ln.ports.reserve(port)
+	}
+	if portIntf, ok := configFile[portKey]; ok {
+		portFromConfigFile, ok := portIntf.(float64)
+		if !ok {
+			return 0, fmt.Errorf("expected flag %q to be float64 but got %T", portKey, portIntf)
 		}
+		port := uint16(portFromConfigFile)
+		return port, ln.ports.reserve(port)
+	}
+	if previousPort != 0 && ln.ports.isAvailable(previousPort) {
+		return previousPort, ln.ports.reserve(previousPort)
 	}
+	return ln.ports.allocate()
+}
 
-	// Use a random free P2P (staking) port, unless given in node config flags or config file
-	var p2pPort uint16
-	if p2pPortIntf, ok := nodeConfig.Flags[config.StakingPortKey]; ok {
-		if p2pPortFromNodeConfigFlags, ok := p2pPortIntf.(int); ok {
-			p2pPort = uint16(p2pPortFromNodeConfigFlags)
-		} else {
-			return nil, fmt.Errorf("expected flag %q to be int but got %T", config.StakingPortKey, p2pPortIntf)
+// Returns true if the port named [portKey] is given
+// in [nodeConfig]'s flags or config file
+func portGiven(nodeConfig node.Config, portKey string) bool {
+	if _, ok := nodeConfig.Flags[portKey]; ok {
+		return true
+	}
+	var configFile map[string]interface{}
+	// A config file that isn't valid JSON gives no ports
+	_ = json.Unmarshal([]byte(nodeConfig.ConfigFile), &configFile)
+	_, ok := configFile[portKey]
+	return ok
+}
+
+// Returns true if [nodeConfig]'s flags or config file give
+// the port named [portKey] a value other than [port]
+func portChanged(nodeConfig node.Config, portKey string, port uint16) bool {
+	if portIntf, ok := nodeConfig.Flags[portKey]; ok {
+		givenPort, ok := portIntf.(int)
+		return !ok || uint16(givenPort) != port
+	}
+	var configFile map[string]interface{}
+	// A config file that isn't valid JSON gives no ports
+	_ = json.Unmarshal([]byte(nodeConfig.ConfigFile), &configFile)
+	if portIntf, ok := configFile[portKey]; ok {
+		givenPort, ok := portIntf.(float64)
+		return !ok || uint16(givenPort) != port
+	}
+	return false
+}
+
+// Adds [node], which was started, to this network
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) registerNode(node *localNode) {
+	ln.nodes[node.name] = node
+	ln.keepUserPaths(node)
+	ln.events.Publish(network.Event{Type: network.NodeAdded, NodeName: node.name})
+	ln.writeState()
+}
+
+// Gives each flag in [ln.flags] to [nodeConfig], unless
+// [nodeConfig] already defines that flag.
+func (ln *localNetwork) addNetworkFlags(nodeConfig *node.Config) {
+	for flagName, flagVal := range ln.flags {
+		if nodeConfig.Flags == nil {
+			nodeConfig.Flags = make(map[string]interface{})
 		}
-	} else if p2pPortIntf, ok := configFile[config.StakingPortKey]; ok {
-		if p2pPortFromConfigFile, ok := p2pPortIntf.(float64); ok {
-			p2pPort = uint16(p2pPortFromConfigFile)
+		// If the same flag is given in network config and node config,
+		// the flag in the node config takes precedence
+		if val, ok := nodeConfig.Flags[flagName]; !ok {
+			nodeConfig.Flags[flagName] = flagVal
 		} else {
-			return nil, fmt.Errorf("expected flag %q to be float64 but got %T", config.StakingPortKey, p2pPortIntf)
-		}
-	} else {
-		// Use a random free port.
-		// Note: it is possible but unlikely for getFreePort to return the same port multiple times.
-		p2pPort, err = getFreePort()
-		if err != nil {
-			return nil, fmt.Errorf("couldn't get free P2P port: %w", err)
+			ln.log.Info(
+				"not overwriting node config flag %s (value %v) with network config flag (value %v)",
+				flagName, val, flagVal,
+			)
 		}
 	}
+}
+
+// Writes [node]'s staking key/cert, genesis and config files to
+// [node.rootDir] and starts a new process for [node] using [node.config],
+// [node]'s directories and [node]'s ports.
+// Assumes [ln.lock] is held. May be called for different
+// nodes concurrently, as long as the beacon lists don't change.
+// So it must not take [ln.lock], nor call anything that does,
+// since the concurrent calls are made while their caller holds it.
+func (ln *localNetwork) startNode(node *localNode) error {
+	nodeConfig := node.config
 
 	// Flags for AvalancheGo
 	flags := []string{
 		fmt.Sprintf("--%s=%d", config.NetworkNameKey, ln.networkID),
-		fmt.Sprintf("--%s=%s", config.DBPathKey, dbPath),
-		fmt.Sprintf("--%s=%s", config.LogsDirKey, logsDir),
-		fmt.Sprintf("--%s=%d", config.HTTPPortKey, apiPort),
-		fmt.Sprintf("--%s=%d", config.StakingPortKey, p2pPort),
-		fmt.Sprintf("--%s=%s", config.BootstrapIPsKey, ln.bootstrapIPs),
-		fmt.Sprintf("--%s=%s", config.BootstrapIDsKey, ln.bootstrapIDs),
+		fmt.Sprintf("--%s=%s", config.DBPathKey, node.dbDir),
+		fmt.Sprintf("--%s=%s", config.LogsDirKey, node.logsDir),
+		fmt.Sprintf("--%s=%d", config.HTTPPortKey, node.apiPort),
+		fmt.Sprintf("--%s=%d", config.StakingPortKey, node.p2pPort),
+	}
+	if ln.proxies != nil {
+		proxiedFlags, err := ln.proxiedFlags(node)
+		if err != nil {
+			return err
+		}
+		flags = append(flags, proxiedFlags...)
+	} else {
+		// A beacon shouldn't try to bootstrap from itself
+		bootstrapIPs := ln.bootstrapIPs.without(fmt.Sprintf("127.0.0.1:%d", node.p2pPort))
+		bootstrapIDs := ln.bootstrapIDs.without(node.nodeID.PrefixedString(avalancheconstants.NodeIDPrefix))
+		flags = append(
+			flags,
+			fmt.Sprintf("--%s=%s", config.BootstrapIPsKey, bootstrapIPs),
+			fmt.Sprintf("--%s=%s", config.BootstrapIDsKey, bootstrapIDs),
+		)
 	}
 
 	for flagName, flagVal := range nodeConfig.Flags {
@@ -485,89 +905,193 @@ func (ln *localNetwork) addNode(nodeConfig node.Config) (node.Node, error) {
 		}
 		flags = append(flags, fmt.Sprintf("--%s=%v", flagName, flagVal))
 	}
-
-	// Parse this node's ID
-	nodeID, err := utils.ToNodeID([]byte(nodeConfig.StakingKey), []byte(nodeConfig.StakingCert))
+	// Comes after the node's flags, so it replaces
+	// any build directory they give
+	buildDirFlags, err := nodeBuildDirFlags(node)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't create node ID: %w", err)
-	}
-
-	// If this node is a beacon, add its IP/ID to the beacon lists.
-	// Note that we do this *after* we set this node's bootstrap IPs/IDs
-	// so this node won't try to use itself as a beacon.
-	if nodeConfig.IsBeacon {
-		ln.bootstrapIDs[nodeID.PrefixedString(avalancheconstants.NodeIDPrefix)] = struct{}{}
-		ln.bootstrapIPs[fmt.Sprintf("127.0.0.1:%d", p2pPort)] = struct{}{}
+		return err
 	}
-
-	ln.log.Info(
-		"adding node %q with tmp dir at %s, logs at %s, DB at %s, P2P port %d, API port %d",
-		nodeConfig.Name, nodeRootDir, logsDir, dbPath, p2pPort, apiPort,
-	)
+	flags = append(flags, buildDirFlags...)
 
 	// Write this node's staking key/cert to disk.
-	stakingKeyFilePath := filepath.Join(nodeRootDir, stakingKeyFileName)
+	stakingKeyFilePath := filepath.Join(node.rootDir, stakingKeyFileName)
 	if err := createFileAndWrite(stakingKeyFilePath, []byte(nodeConfig.StakingKey)); err != nil {
-		return nil, fmt.Errorf("error creating/writing staking key: %w", err)
+		return fmt.Errorf("error creating/writing staking key: %w", err)
 	}
 	flags = append(flags, fmt.Sprintf("--%s=%s", config.StakingKeyPathKey, stakingKeyFilePath))
-	stakingCertFilePath := filepath.Join(nodeRootDir, stakingCertFileName)
+	stakingCertFilePath := filepath.Join(node.rootDir, stakingCertFileName)
 	if err := createFileAndWrite(stakingCertFilePath, []byte(nodeConfig.StakingCert)); err != nil {
-		return nil, fmt.Errorf("error creating/writing staking cert: %w", err)
+		return fmt.Errorf("error creating/writing staking cert: %w", err)
 	}
 	flags = append(flags, fmt.Sprintf("--%s=%s", config.StakingCertPathKey, stakingCertFilePath))
 
 	// Write this node's config file to disk if one is given.
-	configFilePath := filepath.Join(nodeRootDir, configFileName)
+	configFilePath := filepath.Join(node.rootDir, configFileName)
 	if len(nodeConfig.ConfigFile) != 0 {
 		if err := createFileAndWrite(configFilePath, []byte(nodeConfig.ConfigFile)); err != nil {
-			return nil, fmt.Errorf("error creating/writing config file: %w", err)
+			return fmt.Errorf("error creating/writing config file: %w", err)
 		}
 		flags = append(flags, fmt.Sprintf("--%s=%s", config.ConfigFileKey, configFilePath))
 	}
 
 	// Write this node's genesis file to disk.
-	genesisFilePath := filepath.Join(nodeRootDir, genesisFileName)
+	genesisFilePath := filepath.Join(node.rootDir, genesisFileName)
 	if err := createFileAndWrite(genesisFilePath, ln.genesis); err != nil {
-		return nil, fmt.Errorf("error creating/writing genesis file: %w", err)
+		return fmt.Errorf("error creating/writing genesis file: %w", err)
 	}
 	flags = append(flags, fmt.Sprintf("--%s=%s", config.GenesisConfigFileKey, genesisFilePath))
 
 	// Write this node's C-Chain file to disk if one is given.
 	if len(nodeConfig.CChainConfigFile) != 0 {
-		cChainConfigFilePath := filepath.Join(nodeRootDir, "C", configFileName)
+		cChainConfigFilePath := filepath.Join(node.rootDir, "C", configFileName)
 		if err := createFileAndWrite(cChainConfigFilePath, []byte(nodeConfig.CChainConfigFile)); err != nil {
-			return nil, fmt.Errorf("error creating/writing C-Chain config file: %w", err)
+			return fmt.Errorf("error creating/writing C-Chain config file: %w", err)
 		}
-		flags = append(flags, fmt.Sprintf("--%s=%s", config.ChainConfigDirKey, nodeRootDir))
+		flags = append(flags, fmt.Sprintf("--%s=%s", config.ChainConfigDirKey, node.rootDir))
 	}
 
 	var localNodeConfig NodeConfig
 	if err := json.Unmarshal(nodeConfig.ImplSpecificConfig, &localNodeConfig); err != nil {
-		return nil, fmt.Errorf("Unmarshalling an expected local.NodeConfig object failed: %w", err)
+		return fmt.Errorf("Unmarshalling an expected local.NodeConfig object failed: %w", err)
+	}
+
+	// The node appends everything it writes to its stdout and stderr
+	// to files in its root directory, which are tailed to keep its
+	// last lines in memory
+	stdout, stdoutOffset, err := openLogFile(filepath.Join(node.rootDir, stdoutLogFileName))
+	if err != nil {
+		return err
+	}
+	// The process has its own copy of each file once it's started
+	defer stdout.Close()
+	stderr, stderrOffset, err := openLogFile(filepath.Join(node.rootDir, stderrLogFileName))
+	if err != nil {
+		return err
 	}
+	defer stderr.Close()
+	closeOnExit, err := tailNodeLogs(node, stdoutOffset, stderrOffset)
+	if err != nil {
+		return err
+	}
+	node.logs.setProcessStart(time.Now())
 
 	// Start the AvalancheGo node and pass it the flags defined above
-	nodeProcess, err := ln.nodeProcessCreator.NewNodeProcess(nodeConfig, flags...)
+	nodeProcess, err := ln.nodeProcessCreator.NewNodeProcess(nodeConfig, stdout, stderr, flags...)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't create new node process: %s", err)
+		closeAll(closeOnExit)
+		return fmt.Errorf("couldn't create new node process: %s", err)
 	}
 	ln.log.Debug("starting node %q with \"%s %s\"", nodeConfig.Name, localNodeConfig.BinaryPath, flags)
 	if err := nodeProcess.Start(); err != nil {
-		return nil, fmt.Errorf("could not execute cmd \"%s %s\": %w", localNodeConfig.BinaryPath, flags, err)
+		closeAll(closeOnExit)
+		return fmt.Errorf("could not execute cmd \"%s %s\": %w", localNodeConfig.BinaryPath, flags, err)
 	}
+	node.process = nodeProcess
+	node.startTime = processStartTime(nodeProcess.PID())
+	node.flags = flags
+	node.exit = newProcessExit()
+	go ln.superviseNode(node, nodeProcess, node.exit, closeOnExit)
+	return nil
+}
 
-	// Create a wrapper for this node so we can reference it later
-	node := &localNode{
-		name:    nodeConfig.Name,
-		nodeID:  nodeID,
-		client:  ln.newAPIClientF("localhost", apiPort),
-		process: nodeProcess,
-		apiPort: apiPort,
-		p2pPort: p2pPort,
+// Waits for [process], which runs [node], to exit, closes [closeOnExit]
+// and records how it exited in [exit]. If the process failed to bind
+// a port this network gave it, starts the node again with new ports, at
+// most [maxPortRetries] times. Otherwise, if the node wasn't removed,
+// stopped or restarted by this network, logs that it exited unexpectedly.
+// Assumes [ln.lock] isn't held.
+func (ln *localNetwork) superviseNode(node *localNode, process NodeProcess, exit *processExit, closeOnExit []io.Closer) {
+	err := process.Wait()
+	closeAll(closeOnExit)
+	exit.err = err
+	exit.exitCode = exitCode(err)
+	// Decided before the exit is seen, so that Healthy
+	// can wait for the node to be started again
+	retryPorts := node.portRetries < maxPortRetries &&
+		(!portGiven(node.config, config.HTTPPortKey) || !portGiven(node.config, config.StakingPortKey)) &&
+		failedToBindPort(node, err)
+	if retryPorts {
+		exit.closedOnPortRetryCh = make(chan struct{})
 	}
-	ln.nodes[node.name] = node
-	return node, nil
+	ln.events.Publish(network.Event{
+		Type:     network.NodeExited,
+		NodeName: node.name,
+		ExitCode: exit.exitCode,
+		Err:      err,
+	})
+	close(exit.closedOnExitCh)
+	if retryPorts {
+		retried := ln.retryWithNewPorts(node, process)
+		close(exit.closedOnPortRetryCh)
+		if retried {
+			return
+		}
+	}
+
+	ln.lock.RLock()
+	defer ln.lock.RUnlock()
+
+	// If the node is still in the network with this process,
+	// nobody asked it to exit.
+	if ln.nodes[node.name] == node && node.process == process && !ln.isStopped() {
+		ln.failed.SetValue(true)
+		ln.log.Error("%s", exit.unexpectedExitErr(node.name))
+	}
+}
+
+// Starts [node], whose [process] failed to bind its ports, again with
+// new ports in place of the ones that aren't given in its config.
+// Returns false if the node was removed, stopped or restarted since
+// [process] exited, or if it couldn't be started again.
+// Assumes [ln.lock] isn't held.
+func (ln *localNetwork) retryWithNewPorts(node *localNode, process NodeProcess) bool {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	if ln.nodes[node.name] != node || node.process != process || ln.isStopped() {
+		return false
+	}
+	node.portRetries++
+	// Closes the node's proxies and the old client's C-Chain websocket
+	// connection. The process already exited, so it can't fail.
+	_ = ln.stopNode(context.Background(), node)
+	oldP2PPort := node.p2pPort
+	if !portGiven(node.config, config.HTTPPortKey) {
+		port, err := ln.ports.allocate()
+		if err != nil {
+			ln.log.Error("couldn't get new API port for node %q: %s", node.name, err)
+			return false
+		}
+		ln.ports.release(node.apiPort)
+		node.apiPort = port
+	}
+	if !portGiven(node.config, config.StakingPortKey) {
+		port, err := ln.ports.allocate()
+		if err != nil {
+			ln.log.Error("couldn't get new P2P port for node %q: %s", node.name, err)
+			return false
+		}
+		ln.ports.release(node.p2pPort)
+		node.p2pPort = port
+	}
+	// Nodes that already started keep trying the old port of a beacon
+	// until they've bootstrapped from the other beacons
+	oldBeaconIP := fmt.Sprintf("127.0.0.1:%d", oldP2PPort)
+	if _, ok := ln.bootstrapIPs[oldBeaconIP]; ok && node.config.IsBeacon {
+		delete(ln.bootstrapIPs, oldBeaconIP)
+		ln.bootstrapIPs[fmt.Sprintf("127.0.0.1:%d", node.p2pPort)] = struct{}{}
+	}
+	ln.log.Warn(
+		"node %q failed to bind its ports; starting it again with P2P port %d, API port %d",
+		node.name, node.p2pPort, node.apiPort,
+	)
+	node.client = ln.newAPIClientF("localhost", node.apiPort)
+	if err := ln.startNode(node); err != nil {
+		ln.log.Error("couldn't start node %q again with new ports: %s", node.name, err)
+		return false
+	}
+	ln.writeState()
+	return true
 }
 
 // See network.Network
@@ -575,6 +1099,39 @@ func (ln *localNetwork) Healthy(ctx context.Context) chan error {
 	ln.lock.RLock()
 	defer ln.lock.RUnlock()
 
+	nodes := make([]*localNode, 0, len(ln.nodes))
+	for _, node := range ln.nodes {
+		nodes = append(nodes, node)
+	}
+	return ln.nodesHealthy(ctx, nodes)
+}
+
+// Like Healthy, but only waits for the nodes named [nodeNames]
+// Assumes [ln.lock] isn't held.
+func (ln *localNetwork) awaitNodesHealthy(ctx context.Context, nodeNames []string) error {
+	ln.lock.RLock()
+	if ln.isStopped() {
+		ln.lock.RUnlock()
+		return network.ErrStopped
+	}
+	nodes := make([]*localNode, len(nodeNames))
+	for i, nodeName := range nodeNames {
+		node, ok := ln.nodes[nodeName]
+		if !ok {
+			ln.lock.RUnlock()
+			return fmt.Errorf("node %q not found", nodeName)
+		}
+		nodes[i] = node
+	}
+	healthyChan := ln.nodesHealthy(ctx, nodes)
+	ln.lock.RUnlock()
+	return <-healthyChan
+}
+
+// Returns a channel that, as for Healthy, gets an error if one of
+// [nodes] doesn't become healthy, and is closed once they all are.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) nodesHealthy(ctx context.Context, nodes []*localNode) chan error {
 	healthyChan := make(chan error, 1)
 
 	// Return unhealthy if the network is stopped
@@ -583,28 +1140,68 @@ func (ln *localNetwork) Healthy(ctx context.Context) chan error {
 		return healthyChan
 	}
 
-	nodes := make([]*localNode, 0, len(ln.nodes))
-	for _, node := range ln.nodes {
-		nodes = append(nodes, node)
-	}
+	// Replaced if the network is started again
+	closedOnStopCh := ln.closedOnStopCh
+	healthCheckFreq := ln.healthCheckFreq
 	go func() {
-		errGr, ctx := errgroup.WithContext(ctx)
+		// [groupCtx] is also cancelled once a node fails. The other nodes
+		// are then no longer waited on, but aren't reported unhealthy.
+		errGr, groupCtx := errgroup.WithContext(ctx)
 		for _, node := range nodes {
 			node := node
 			errGr.Go(func() error {
-				// Every constants.HealthCheckInterval, query node for health status.
-				// Do this until ctx timeout
+				// Query node for health status right away, then every
+				// [healthCheckFreq]. Do this until ctx timeout
+				exit := ln.nodeExit(node)
+				checkAfter := time.Duration(0)
 				for {
 					select {
-					case <-ln.closedOnStopCh:
+					case <-closedOnStopCh:
 						return network.ErrStopped
-					case <-ctx.Done():
-						return fmt.Errorf("node %q failed to become healthy within timeout", node.GetName())
-					case <-time.After(healthCheckFreq):
+					case <-exit.closedOnExitCh:
+						// The node may be started again with new ports
+						if exit.closedOnPortRetryCh != nil {
+							select {
+							case <-exit.closedOnPortRetryCh:
+							case <-groupCtx.Done():
+								if ctx.Err() == nil {
+									return groupCtx.Err()
+								}
+							}
+							if newExit := ln.nodeExit(node); newExit != exit {
+								exit = newExit
+								continue
+							}
+						}
+						return ln.nodeUnhealthy(node, exit.unexpectedExitErr(node.name))
+					case <-groupCtx.Done():
+						if ctx.Err() == nil {
+							return groupCtx.Err()
+						}
+						if ln.isPaused(node) {
+							return ln.nodeUnhealthy(node, fmt.Errorf("node %q failed to become healthy: %w", node.GetName(), ErrNodePaused))
+						}
+						return ln.nodeUnhealthy(node, fmt.Errorf("node %q failed to become healthy within timeout", node.GetName()))
+					case <-time.After(checkAfter):
+					}
+					checkAfter = healthCheckFreq
+					// Handled above if the node exited, or the network
+					// stopped, while waiting
+					select {
+					case <-closedOnStopCh:
+						continue
+					case <-exit.closedOnExitCh:
+						continue
+					default:
 					}
-					health, err := node.client.HealthAPI().Health(ctx)
+					// A paused node can't respond, so don't wait for the timeout
+					if ln.isPaused(node) {
+						return ln.nodeUnhealthy(node, fmt.Errorf("node %q failed to become healthy: %w", node.GetName(), ErrNodePaused))
+					}
+					health, err := ln.nodeClient(node).HealthAPI().Health(groupCtx)
 					if err == nil && health.Healthy {
 						ln.log.Debug("node %q became healthy", node.name)
+						ln.events.Publish(network.Event{Type: network.NodeHealthy, NodeName: node.name})
 						return nil
 					}
 				}
@@ -619,6 +1216,129 @@ func (ln *localNetwork) Healthy(ctx context.Context) chan error {
 	return healthyChan
 }
 
+// See network.Network
+// Paused nodes and nodes that exited aren't queried.
+func (ln *localNetwork) HealthReport(ctx context.Context) (map[string]network.NodeHealth, error) {
+	ln.lock.RLock()
+	if ln.isStopped() {
+		ln.lock.RUnlock()
+		return nil, network.ErrStopped
+	}
+	report := make(map[string]network.NodeHealth, len(ln.nodes))
+	// Node name --> Its API client
+	clients := make(map[string]api.Client, len(ln.nodes))
+	for name, node := range ln.nodes {
+		switch {
+		case node.exit.exited():
+			report[name] = network.NewNodeHealth(nil, node.exit.unexpectedExitErr(name))
+		case node.paused:
+			report[name] = network.NewNodeHealth(nil, fmt.Errorf("node %q: %w", name, ErrNodePaused))
+		default:
+			clients[name] = node.client
+		}
+	}
+	ln.lock.RUnlock()
+
+	var (
+		reportLock sync.Mutex
+		wg         sync.WaitGroup
+	)
+	for name, client := range clients {
+		name, client := name, client
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nodeHealth := network.NewNodeHealth(client.HealthAPI().Health(ctx))
+			reportLock.Lock()
+			report[name] = nodeHealth
+			reportLock.Unlock()
+		}()
+	}
+	wg.Wait()
+	return report, nil
+}
+
+// See network.Network
+func (ln *localNetwork) WatchHealth(ctx context.Context, interval time.Duration) (<-chan network.HealthTransition, error) {
+	ln.lock.RLock()
+	defer ln.lock.RUnlock()
+
+	if ln.isStopped() {
+		return nil, network.ErrStopped
+	}
+	return network.WatchHealth(ctx, ln, interval, ln.events)
+}
+
+// See network.Network
+func (ln *localNetwork) Describe() (network.Info, error) {
+	ln.lock.RLock()
+	defer ln.lock.RUnlock()
+
+	if ln.isStopped() {
+		return network.Info{}, network.ErrStopped
+	}
+	info := network.Info{
+		NetworkID:   ln.networkID,
+		GenesisHash: network.GenesisHash(ln.genesis),
+		Beacons:     []network.BeaconInfo{},
+		Nodes:       make(map[string]network.NodeInfo, len(ln.nodes)),
+	}
+	for name, node := range ln.nodes {
+		nodeID := node.nodeID.PrefixedString(avalancheconstants.NodeIDPrefix)
+		// Validated when the node was added
+		var localNodeConfig NodeConfig
+		_ = json.Unmarshal(node.config.ImplSpecificConfig, &localNodeConfig)
+		nodeInfo := network.NodeInfo{
+			Name:       name,
+			NodeID:     nodeID,
+			URI:        fmt.Sprintf("http://%s:%d", node.GetURL(), node.apiPort),
+			APIPort:    node.apiPort,
+			P2PPort:    node.p2pPort,
+			IsBeacon:   node.config.IsBeacon,
+			RootDir:    node.rootDir,
+			DBDir:      node.dbDir,
+			LogsDir:    node.logsDir,
+			BinaryPath: localNodeConfig.BinaryPath,
+			Flags:      flagsMap(node.flags),
+			Status:     network.NodeStatusRunning,
+		}
+		switch {
+		case node.exit.exited():
+			nodeInfo.Status = network.NodeStatusExited
+			nodeInfo.Error = node.exit.unexpectedExitErr(name).Error()
+		case node.paused:
+			nodeInfo.Status = network.NodeStatusPaused
+			nodeInfo.PID = node.process.PID()
+		default:
+			nodeInfo.PID = node.process.PID()
+		}
+		info.Nodes[name] = nodeInfo
+		if node.config.IsBeacon {
+			info.Beacons = append(info.Beacons, network.BeaconInfo{
+				NodeName: name,
+				NodeID:   nodeID,
+				IP:       fmt.Sprintf("127.0.0.1:%d", node.p2pPort),
+			})
+		}
+	}
+	sort.Slice(info.Beacons, func(i, j int) bool {
+		return info.Beacons[i].NodeName < info.Beacons[j].NodeName
+	})
+	return info, nil
+}
+
+// Publishes a NodeUnhealthy event for [node] and returns [err]
+func (ln *localNetwork) nodeUnhealthy(node *localNode, err error) error {
+	ln.failed.SetValue(true)
+	ln.events.Publish(network.Event{Type: network.NodeUnhealthy, NodeName: node.name, Err: err})
+	return err
+}
+
+// See network.Network
+func (ln *localNetwork) Subscribe() (<-chan network.Event, func()) {
+	return ln.events.Subscribe()
+}
+
 // See network.Network
 func (ln *localNetwork) GetNode(nodeName string) (node.Node, error) {
 	ln.lock.RLock()
@@ -632,6 +1352,9 @@ func (ln *localNetwork) GetNode(nodeName string) (node.Node, error) {
 	if !ok {
 		return nil, fmt.Errorf("node %q not found in network", nodeName)
 	}
+	if node.exit.exited() {
+		return nil, node.exit.unexpectedExitErr(nodeName)
+	}
 	return node, nil
 }
 
@@ -673,7 +1396,13 @@ func (ln *localNetwork) Stop(ctx context.Context) error {
 	ln.lock.Lock()
 	defer ln.lock.Unlock()
 
-	return ln.stop(ctx)
+	err := ln.stop(ctx)
+	if err == network.ErrStopped {
+		return err
+	}
+	errs := wrappers.Errs{}
+	errs.Add(err, ln.cleanupOnStop(err))
+	return errs.Err
 }
 
 // Assumes [net.lock] is held
@@ -684,28 +1413,117 @@ func (ln *localNetwork) stop(ctx context.Context) error {
 	}
 	ctx, cancel := context.WithTimeout(ctx, stopTimeout)
 	defer cancel()
+	// Remember the nodes so that Start can start them again
+	ln.stoppedNodes = make(map[string]*localNode, len(ln.nodes))
+	nodes := make([]*localNode, 0, len(ln.nodes))
+	for nodeName, node := range ln.nodes {
+		ln.stoppedNodes[nodeName] = node
+		nodes = append(nodes, node)
+		delete(ln.nodes, nodeName)
+	}
+	// Stop the nodes in parallel so that a node
+	// that's slow to stop doesn't hold up the others
+	stopErrs := make([]error, len(nodes))
+	wg := sync.WaitGroup{}
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *localNode) {
+			defer wg.Done()
+			stopErrs[i] = ln.stopNode(ctx, node)
+		}(i, node)
+	}
+	wg.Wait()
+
 	errs := wrappers.Errs{}
-	for nodeName := range ln.nodes {
-		select {
-		case <-ctx.Done():
-			// In practice we'll probably never time out here,
-			// and the caller probably won't cancel a call
-			// to stop(), but we include this to respect the
-			// network.Network interface.
-			return ctx.Err()
-		default:
-		}
-		if err := ln.removeNode(nodeName); err != nil {
-			ln.log.Error("error stopping node %q: %s", nodeName, err)
+	killed := &NodesKilledError{}
+	for i, node := range nodes {
+		ln.events.Publish(network.Event{Type: network.NodeRemoved, NodeName: node.name})
+		var killedErr *NodesKilledError
+		switch err := stopErrs[i]; {
+		case errors.As(err, &killedErr):
+			killed.NodeNames = append(killed.NodeNames, killedErr.NodeNames...)
+		case err != nil:
+			ln.log.Error("error stopping node %q: %s", node.name, err)
 			errs.Add(err)
 		}
 	}
+	if len(killed.NodeNames) > 0 {
+		sort.Strings(killed.NodeNames)
+		errs.Add(killed)
+	}
 	close(ln.closedOnStopCh)
+	ln.writeState()
+	ln.events.Publish(network.Event{Type: network.NetworkStopped})
 	ln.log.Info("done stopping network")
 	return errs.Err
 }
 
-// Sends a SIGTERM to the given node and removes it from this network
+// See Network
+func (ln *localNetwork) Start(ctx context.Context) error {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	return ln.start(ctx)
+}
+
+// Assumes [ln.lock] is held
+func (ln *localNetwork) start(ctx context.Context) error {
+	if !ln.isStopped() {
+		return ErrNotStopped
+	}
+	if ln.cleanedUp {
+		return ErrCleanedUp
+	}
+	ln.log.Info("starting network with %d nodes", len(ln.stoppedNodes))
+
+	// Start beacons first, like newNetwork
+	nodeNames := make([]string, 0, len(ln.stoppedNodes))
+	for nodeName := range ln.stoppedNodes {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Slice(nodeNames, func(i, j int) bool {
+		iBeacon := ln.stoppedNodes[nodeNames[i]].config.IsBeacon
+		jBeacon := ln.stoppedNodes[nodeNames[j]].config.IsBeacon
+		if iBeacon != jBeacon {
+			return iBeacon
+		}
+		return nodeNames[i] < nodeNames[j]
+	})
+
+	ln.closedOnStopCh = make(chan struct{})
+	ln.failed.SetValue(false)
+	for _, nodeName := range nodeNames {
+		node := ln.stoppedNodes[nodeName]
+		err := ctx.Err()
+		if err == nil {
+			// The old client's C-Chain websocket connection was closed by stopNode
+			node.client = ln.newAPIClientF("localhost", node.apiPort)
+			node.paused = false
+			err = ln.startNode(node)
+		}
+		if err != nil {
+			// Leave the network stopped, with all of its nodes
+			for startedName, started := range ln.nodes {
+				if err := ln.stopNode(context.Background(), started); err != nil {
+					ln.log.Warn("error stopping node %q: %s", startedName, err)
+				}
+				delete(ln.nodes, startedName)
+			}
+			close(ln.closedOnStopCh)
+			return fmt.Errorf("couldn't start node %q: %w", nodeName, err)
+		}
+		ln.nodes[nodeName] = node
+		ln.events.Publish(network.Event{Type: network.NodeAdded, NodeName: nodeName})
+	}
+	ln.stoppedNodes = nil
+	ln.writeState()
+	ln.events.Publish(network.Event{Type: network.NetworkStarted})
+	return nil
+}
+
+// Sends a SIGTERM to the given node and removes it from this network.
+// If the node doesn't exit within the grace period, kills it and
+// returns a *NodesKilledError.
 func (ln *localNetwork) RemoveNode(nodeName string) error {
 	ln.lock.Lock()
 	defer ln.lock.Unlock()
@@ -724,18 +1542,536 @@ func (ln *localNetwork) removeNode(nodeName string) error {
 		return fmt.Errorf("node %q not found", nodeName)
 	}
 	delete(ln.nodes, nodeName)
+	err := ln.stopNode(context.Background(), node)
+	ln.ports.release(node.apiPort, node.p2pPort)
+	ln.writeState()
+	ln.events.Publish(network.Event{Type: network.NodeRemoved, NodeName: nodeName})
+	return err
+}
+
+// Sends a SIGTERM to [node]'s process and waits for it to exit.
+// If it doesn't exit within [ln.stopGracePeriod], or before [ctx] is
+// done, kills its process group and returns a *NodesKilledError.
+// Does nothing if the process already exited.
+// Assumes [net.lock] is held. May be called for
+// different nodes concurrently.
+func (ln *localNetwork) stopNode(ctx context.Context, node *localNode) error {
+	defer ln.proxies.closeNode(node.name)
 	// cchain eth api uses a websocket connection and must be closed before stopping the node,
 	// to avoid errors logs at client
 	node.client.CChainEthAPI().Close()
+	if node.exit.exited() {
+		ln.log.Debug("not stopping node %q, which already exited", node.name)
+		return nil
+	}
+	// A paused process won't handle the SIGTERM until it's resumed
+	if node.paused {
+		if err := node.process.Resume(); err != nil {
+			return fmt.Errorf("error sending SIGCONT to node %s: %w", node.name, err)
+		}
+		node.paused = false
+	}
 	if err := node.process.Stop(); err != nil {
-		return fmt.Errorf("error sending SIGTERM to node %s: %w", nodeName, err)
+		return fmt.Errorf("error sending SIGTERM to node %s: %w", node.name, err)
+	}
+	gracePeriod := time.NewTimer(ln.stopGracePeriod)
+	defer gracePeriod.Stop()
+	select {
+	// Closed by [ln.superviseNode]
+	case <-node.exit.closedOnExitCh:
+	case <-gracePeriod.C:
+		ln.log.Warn("node %q didn't stop within %s; killing it", node.name, ln.stopGracePeriod)
+		return ln.killNode(node)
+	case <-ctx.Done():
+		ln.log.Warn("node %q didn't stop in time (%s); killing it", node.name, ctx.Err())
+		return ln.killNode(node)
+	}
+	// Processes started by another process have no known exit status
+	if node.exit.err != nil && !errors.Is(node.exit.err, errExitStatusUnknown) {
+		return fmt.Errorf("node %q stopped with error: %w", node.name, node.exit.err)
+	}
+	return nil
+}
+
+// Sends a SIGKILL to the process group of [node], which includes the
+// VM plugins it started, and waits for it to exit.
+// Assumes [net.lock] is held.
+func (ln *localNetwork) killNode(node *localNode) error {
+	// The process may have exited since the grace period ended
+	if err := node.process.Kill(); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("error sending SIGKILL to node %s: %w", node.name, err)
+	}
+	<-node.exit.closedOnExitCh
+	return &NodesKilledError{NodeNames: []string{node.name}}
+}
+
+// Returns the exit code of a process whose Wait() returned [err],
+// or -1 if it's unknown
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// See network.Network
+func (ln *localNetwork) RestartNode(ctx context.Context, nodeName string, newConfig *node.Config) error {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	return ln.restartNode(ctx, nodeName, newConfig)
+}
+
+// Stops the node named [nodeName] and starts it again with the same
+// name, staking key/cert, root directory, DB directory, logs directory
+// and ports. If [newConfig] is non-nil, its non-empty fields replace
+// the node's implementation-specific config, config file, C-Chain config
+// file and flags. If the node isn't started again, it's kept with
+// its ports, and its exit records the error.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) restartNode(ctx context.Context, nodeName string, newConfig *node.Config) error {
+	return ln.restartNodes(ctx, map[string]*node.Config{nodeName: newConfig})
+}
+
+// Restarts each node named in [newConfigs] as restartNode does, with
+// its new config. The nodes are restarted in parallel, unless fault
+// injection is enabled, in which case a node bootstraps from the nodes
+// that are running when it starts, so they're restarted one at a time.
+// No node is restarted if a new config is invalid. If a node fails to
+// restart, returns an error after the other nodes are restarted.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) restartNodes(ctx context.Context, newConfigs map[string]*node.Config) error {
+	if ln.isStopped() {
+		return network.ErrStopped
+	}
+	nodeNames := make([]string, 0, len(newConfigs))
+	for nodeName := range newConfigs {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+	nodes := make([]*localNode, len(nodeNames))
+	nodeConfigs := make([]node.Config, len(nodeNames))
+	for i, nodeName := range nodeNames {
+		node, ok := ln.nodes[nodeName]
+		if !ok {
+			return fmt.Errorf("node %q not found", nodeName)
+		}
+		newConfig := newConfigs[nodeName]
+		nodeConfig, err := node.config.WithUpdates(newConfig)
+		if err != nil {
+			return err
+		}
+		if newConfig != nil {
+			if newConfig.Flags != nil {
+				ln.addNetworkFlags(&nodeConfig)
+			}
+			if err := nodeConfig.Validate(ln.networkID); err != nil {
+				return fmt.Errorf("new config for node %q failed validation: %w", nodeName, err)
+			}
+			// The runner's port flags come before the node's, so the node
+			// would listen on ports its client and proxies don't know of
+			if portChanged(nodeConfig, config.HTTPPortKey, node.apiPort) ||
+				portChanged(nodeConfig, config.StakingPortKey, node.p2pPort) {
+				return fmt.Errorf("can't change API or P2P port of node %q", nodeName)
+			}
+		}
+		nodes[i] = node
+		nodeConfigs[i] = nodeConfig
+	}
+
+	defer ln.writeState()
+	if ln.proxies != nil {
+		for i, node := range nodes {
+			if err := ln.restartProcess(ctx, node, nodeConfigs[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	errGr := errgroup.Group{}
+	// As in addNodes, [ln.lock] is held while the nodes are restarted,
+	// and each goroutine only changes its own node
+	for i, node := range nodes {
+		node, nodeConfig := node, nodeConfigs[i]
+		errGr.Go(func() error {
+			return ln.restartProcess(ctx, node, nodeConfig)
+		})
+	}
+	return errGr.Wait()
+}
+
+// Stops [node]'s process and starts a new one with config [nodeConfig].
+// If it isn't started, [node]'s exit records the error.
+// Assumes [ln.lock] is held, and doesn't take it.
+func (ln *localNetwork) restartProcess(ctx context.Context, node *localNode, nodeConfig node.Config) error {
+	ln.log.Info("restarting node %q", node.name)
+	if err := ln.stopNode(ctx, node); err != nil {
+		// This happens if the process had already exited or exited with
+		// a non-zero code. Either way it's gone, so we can start it again.
+		ln.log.Warn("error stopping node %q: %s", node.name, err)
+	}
+	select {
+	case <-ctx.Done():
+		node.exit = newFailedStartExit(ctx.Err())
+		return ctx.Err()
+	default:
+	}
+
+	node.config = nodeConfig
+	// The old client's C-Chain websocket connection was closed above
+	node.client = ln.newAPIClientF("localhost", node.apiPort)
+	if err := ln.startNode(node); err != nil {
+		err = fmt.Errorf("couldn't restart node %q: %w", node.name, err)
+		node.exit = newFailedStartExit(err)
+		return err
+	}
+	return nil
+}
+
+// See network.Network
+// [newBinaryPath] is the path of the AvalancheGo binary to run the node with.
+func (ln *localNetwork) UpgradeNode(ctx context.Context, nodeName string, newBinaryPath string) (*network.NodeUpgrade, error) {
+	ln.lock.RLock()
+	if ln.isStopped() {
+		ln.lock.RUnlock()
+		return nil, network.ErrStopped
+	}
+	n, ok := ln.nodes[nodeName]
+	if !ok {
+		ln.lock.RUnlock()
+		return nil, fmt.Errorf("node %q not found", nodeName)
+	}
+	oldClient := n.client
+	ln.lock.RUnlock()
+
+	// Don't stop the node if it can't be started again
+	if _, err := os.Stat(newBinaryPath); err != nil {
+		return nil, fmt.Errorf("couldn't find binary for node %q: %w", nodeName, err)
+	}
+	// The node may not respond, e.g. if it exited, and
+	// that shouldn't keep it from being upgraded
+	oldVersion, err := oldClient.InfoAPI().GetNodeVersion(ctx)
+	if err != nil {
+		ln.log.Warn("couldn't get version of node %q before upgrading it: %s", nodeName, err)
+		oldVersion = nil
+	}
+
+	// Only hold the lock to swap the node's binary and restart it
+	ln.lock.Lock()
+	n, ok = ln.nodes[nodeName]
+	if !ok {
+		ln.lock.Unlock()
+		return nil, fmt.Errorf("node %q not found", nodeName)
+	}
+	var localNodeConfig NodeConfig
+	if err := json.Unmarshal(n.config.ImplSpecificConfig, &localNodeConfig); err != nil {
+		ln.lock.Unlock()
+		return nil, fmt.Errorf("Unmarshalling an expected local.NodeConfig object failed: %w", err)
+	}
+	upgrade := &network.NodeUpgrade{
+		NodeName:            nodeName,
+		OldVersion:          oldVersion,
+		PreviousBinaryOrTag: localNodeConfig.BinaryPath,
+	}
+	localNodeConfig.BinaryPath = newBinaryPath
+	implSpecificConfig, err := json.Marshal(localNodeConfig)
+	if err != nil {
+		ln.lock.Unlock()
+		return nil, err
+	}
+	ln.log.Info("upgrading node %q to %s", nodeName, newBinaryPath)
+	if err := ln.restartNode(ctx, nodeName, &node.Config{ImplSpecificConfig: implSpecificConfig}); err != nil {
+		ln.lock.Unlock()
+		return nil, err
+	}
+	client := n.client
+	exit := n.exit
+	ln.lock.Unlock()
+
+	// Don't hold the lock while waiting for the node to be healthy.
+	// Stop waiting if the new binary exits, e.g. because it can't
+	// migrate the node's database.
+	healthyCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-exit.closedOnExitCh:
+			cancel()
+		case <-healthyCtx.Done():
+		}
+	}()
+	if err := network.WaitForHealthy(healthyCtx, client); err != nil {
+		if exit.exited() {
+			return upgrade, exit.unexpectedExitErr(nodeName)
+		}
+		return upgrade, fmt.Errorf("node %q didn't become healthy after upgrade: %w", nodeName, err)
+	}
+	upgrade.NewVersion, err = client.InfoAPI().GetNodeVersion(ctx)
+	if err != nil {
+		return upgrade, fmt.Errorf("couldn't get version of upgraded node %q: %w", nodeName, err)
+	}
+	return upgrade, nil
+}
+
+// See network.Network
+func (ln *localNetwork) RollingUpgrade(ctx context.Context, newBinaryPath string, opts network.RollingUpgradeOptions) ([]*network.NodeUpgrade, error) {
+	return network.RollingUpgrade(ctx, ln, newBinaryPath, opts)
+}
+
+// See Network
+func (ln *localNetwork) PauseNode(nodeName string) error {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	if ln.isStopped() {
+		return network.ErrStopped
+	}
+	node, ok := ln.nodes[nodeName]
+	if !ok {
+		return fmt.Errorf("node %q not found", nodeName)
+	}
+	if node.paused {
+		return fmt.Errorf("node %q: %w", nodeName, ErrNodePaused)
+	}
+	ln.log.Info("pausing node %q", nodeName)
+	if err := node.process.Pause(); err != nil {
+		return fmt.Errorf("error sending SIGSTOP to node %s: %w", nodeName, err)
+	}
+	node.paused = true
+	ln.writeState()
+	return nil
+}
+
+// See Network
+func (ln *localNetwork) ResumeNode(nodeName string) error {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	if ln.isStopped() {
+		return network.ErrStopped
+	}
+	node, ok := ln.nodes[nodeName]
+	if !ok {
+		return fmt.Errorf("node %q not found", nodeName)
 	}
-	if err := node.process.Wait(); err != nil {
-		return fmt.Errorf("node %q stopped with error: %w", nodeName, err)
+	if !node.paused {
+		return fmt.Errorf("node %q isn't paused", nodeName)
 	}
+	ln.log.Info("resuming node %q", nodeName)
+	if err := node.process.Resume(); err != nil {
+		return fmt.Errorf("error sending SIGCONT to node %s: %w", nodeName, err)
+	}
+	node.paused = false
+	ln.writeState()
 	return nil
 }
 
+// See network.Network
+func (ln *localNetwork) CreateSubnet(
+	ctx context.Context,
+	controlKeys []string,
+	threshold uint32,
+	validatorNodes []string,
+) (ids.ID, error) {
+	ln.lock.RLock()
+	if ln.isStopped() {
+		ln.lock.RUnlock()
+		return ids.Empty, network.ErrStopped
+	}
+	validatorIDs := make([]ids.ShortID, len(validatorNodes))
+	for i, nodeName := range validatorNodes {
+		node, ok := ln.nodes[nodeName]
+		if !ok {
+			ln.lock.RUnlock()
+			return ids.Empty, fmt.Errorf("node %q not found", nodeName)
+		}
+		validatorIDs[i] = node.nodeID
+	}
+	client, err := ln.pChainClient()
+	ln.lock.RUnlock()
+	if err != nil {
+		return ids.Empty, err
+	}
+
+	// Don't hold the lock while waiting for transactions to be accepted
+	wallet, err := network.NewPChainWallet(ctx, client, ln.fundedPrivateKey)
+	if err != nil {
+		return ids.Empty, err
+	}
+	defer ln.closeWallet(wallet)
+	subnetID, err := wallet.CreateSubnet(ctx, controlKeys, threshold)
+	if err != nil {
+		return ids.Empty, err
+	}
+	ln.log.Info("created subnet %s", subnetID)
+	for i, nodeID := range validatorIDs {
+		if _, err := wallet.AddSubnetValidator(ctx, subnetID, nodeID, network.SubnetValidatorWeight); err != nil {
+			return ids.Empty, fmt.Errorf("couldn't add node %q as validator of subnet %s: %w", validatorNodes[i], subnetID, err)
+		}
+		ln.log.Info("added node %q as validator of subnet %s", validatorNodes[i], subnetID)
+	}
+
+	// Validators must be restarted to sync the subnet
+	ln.lock.Lock()
+	if ln.isStopped() {
+		ln.lock.Unlock()
+		return ids.Empty, network.ErrStopped
+	}
+	newConfigs := make(map[string]*node.Config, len(validatorNodes))
+	for _, nodeName := range validatorNodes {
+		n, ok := ln.nodes[nodeName]
+		if !ok {
+			ln.lock.Unlock()
+			return ids.Empty, fmt.Errorf("node %q not found", nodeName)
+		}
+		flags, err := network.WhitelistSubnet(n.config, subnetID)
+		if err != nil {
+			ln.lock.Unlock()
+			return ids.Empty, fmt.Errorf("couldn't whitelist subnet for node %q: %w", nodeName, err)
+		}
+		newConfigs[nodeName] = &node.Config{Flags: flags}
+	}
+	err = ln.restartNodes(ctx, newConfigs)
+	ln.lock.Unlock()
+	if err != nil {
+		return ids.Empty, err
+	}
+	// Don't hold the lock while waiting for the validators to come back up
+	if err := ln.awaitNodesHealthy(ctx, validatorNodes); err != nil {
+		return ids.Empty, err
+	}
+	return subnetID, nil
+}
+
+// See network.Network
+func (ln *localNetwork) AddValidator(
+	ctx context.Context,
+	nodeName string,
+	stakeAmount uint64,
+	start time.Time,
+	end time.Time,
+	rewardAddr string,
+) (ids.ID, error) {
+	wallet, nodeID, err := ln.stakingWallet(ctx, nodeName)
+	if err != nil {
+		return ids.Empty, err
+	}
+	defer ln.closeWallet(wallet)
+	txID, err := wallet.AddValidator(ctx, nodeID, stakeAmount, start, end, rewardAddr)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("couldn't add node %q as validator: %w", nodeName, err)
+	}
+	ln.log.Info("added node %q as validator in tx %s", nodeName, txID)
+	return txID, nil
+}
+
+// See network.Network
+func (ln *localNetwork) AddDelegator(
+	ctx context.Context,
+	nodeName string,
+	stakeAmount uint64,
+	start time.Time,
+	end time.Time,
+	rewardAddr string,
+) (ids.ID, error) {
+	wallet, nodeID, err := ln.stakingWallet(ctx, nodeName)
+	if err != nil {
+		return ids.Empty, err
+	}
+	defer ln.closeWallet(wallet)
+	txID, err := wallet.AddDelegator(ctx, nodeID, stakeAmount, start, end, rewardAddr)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("couldn't delegate to node %q: %w", nodeName, err)
+	}
+	ln.log.Info("delegated to node %q in tx %s", nodeName, txID)
+	return txID, nil
+}
+
+// Returns a wallet that pays with the funded key, and
+// the ID of the node named [nodeName]
+// Assumes [ln.lock] isn't held.
+func (ln *localNetwork) stakingWallet(ctx context.Context, nodeName string) (*network.PChainWallet, ids.ShortID, error) {
+	ln.lock.RLock()
+	if ln.isStopped() {
+		ln.lock.RUnlock()
+		return nil, ids.ShortEmpty, network.ErrStopped
+	}
+	node, ok := ln.nodes[nodeName]
+	if !ok {
+		ln.lock.RUnlock()
+		return nil, ids.ShortEmpty, fmt.Errorf("node %q not found", nodeName)
+	}
+	nodeID := node.nodeID
+	client, err := ln.pChainClient()
+	ln.lock.RUnlock()
+	if err != nil {
+		return nil, ids.ShortEmpty, err
+	}
+	wallet, err := network.NewPChainWallet(ctx, client, ln.fundedPrivateKey)
+	if err != nil {
+		return nil, ids.ShortEmpty, err
+	}
+	return wallet, nodeID, nil
+}
+
+// Deletes [wallet]'s keystore user. Failing to do so only leaves
+// the user in the keystore, so it's logged rather than returned.
+func (ln *localNetwork) closeWallet(wallet *network.PChainWallet) {
+	if err := wallet.Close(); err != nil {
+		ln.log.Warn("couldn't close P-Chain wallet: %s", err)
+	}
+}
+
+// Returns the API client of a running node, to issue P-Chain
+// transactions through. The same node is picked each time,
+// as long as it keeps running.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) pChainClient() (api.Client, error) {
+	names := make([]string, 0, len(ln.nodes))
+	for name := range ln.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		node := ln.nodes[name]
+		if !node.paused && !node.exit.exited() {
+			return node.client, nil
+		}
+	}
+	return nil, errors.New("no running node to issue transactions through")
+}
+
+// Returns how [node]'s current process exited.
+// Assumes [ln.lock] isn't held.
+func (ln *localNetwork) nodeExit(node *localNode) *processExit {
+	ln.lock.RLock()
+	defer ln.lock.RUnlock()
+
+	return node.exit
+}
+
+// Returns the API client of [node]'s current process.
+// Assumes [ln.lock] isn't held.
+func (ln *localNetwork) nodeClient(node *localNode) api.Client {
+	ln.lock.RLock()
+	defer ln.lock.RUnlock()
+
+	return node.client
+}
+
+// Returns true if [node] is paused.
+// Assumes [ln.lock] isn't held.
+func (ln *localNetwork) isPaused(node *localNode) bool {
+	ln.lock.RLock()
+	defer ln.lock.RUnlock()
+
+	return node.paused
+}
+
 // Assumes [net.lock] is held
 func (ln *localNetwork) isStopped() bool {
 	select {
@@ -6,8 +6,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	gonet "net"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,16 +27,23 @@ import (
 	"github.com/ava-labs/avalanche-network-runner/network/node"
 	"github.com/ava-labs/avalanche-network-runner/utils"
 	"github.com/ava-labs/avalanchego/api/health"
+	"github.com/ava-labs/avalanchego/api/info"
+	"github.com/ava-labs/avalanchego/config"
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/staking"
 	"github.com/ava-labs/avalanchego/utils/constants"
 	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/platformvm/status"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 const (
 	defaultHealthyTimeout = 5 * time.Second
+	// How often the tests that wait for unhealthy
+	// nodes make their networks query the nodes
+	testHealthCheckFreq = 10 * time.Millisecond
 )
 
 var (
@@ -35,19 +51,22 @@ var (
 	_ NodeProcessCreator = &localTestFailedStartProcessCreator{}
 	_ NodeProcessCreator = &localTestProcessUndefNodeProcessCreator{}
 	_ NodeProcessCreator = &localTestFlagCheckProcessCreator{}
+	_ NodeProcessCreator = &localTestFlagRecorderProcessCreator{}
+	_ NodeProcessCreator = &localTestCrashingProcessCreator{}
+	_ NodeProcessCreator = &localTestCrashingBinaryProcessCreator{}
 	_ api.NewAPIClientF  = newMockAPISuccessful
 	_ api.NewAPIClientF  = newMockAPIUnhealthy
 )
 
 type localTestSuccessfulNodeProcessCreator struct{}
 
-func (*localTestSuccessfulNodeProcessCreator) NewNodeProcess(config node.Config, flags ...string) (NodeProcess, error) {
+func (*localTestSuccessfulNodeProcessCreator) NewNodeProcess(config node.Config, _ *os.File, _ *os.File, flags ...string) (NodeProcess, error) {
 	return newMockProcessSuccessful(config, flags...)
 }
 
 type localTestFailedStartProcessCreator struct{}
 
-func (*localTestFailedStartProcessCreator) NewNodeProcess(config node.Config, flags ...string) (NodeProcess, error) {
+func (*localTestFailedStartProcessCreator) NewNodeProcess(config node.Config, _ *os.File, _ *os.File, flags ...string) (NodeProcess, error) {
 	process := &mocks.NodeProcess{}
 	process.On("Start").Return(errors.New("Start failed"))
 	process.On("Wait").Return(nil)
@@ -57,7 +76,7 @@ func (*localTestFailedStartProcessCreator) NewNodeProcess(config node.Config, fl
 
 type localTestProcessUndefNodeProcessCreator struct{}
 
-func (*localTestProcessUndefNodeProcessCreator) NewNodeProcess(config node.Config, flags ...string) (NodeProcess, error) {
+func (*localTestProcessUndefNodeProcessCreator) NewNodeProcess(config node.Config, _ *os.File, _ *os.File, flags ...string) (NodeProcess, error) {
 	return newMockProcessUndef(config, flags...)
 }
 
@@ -66,7 +85,7 @@ type localTestFlagCheckProcessCreator struct {
 	assert        *assert.Assertions
 }
 
-func (lt *localTestFlagCheckProcessCreator) NewNodeProcess(config node.Config, flags ...string) (NodeProcess, error) {
+func (lt *localTestFlagCheckProcessCreator) NewNodeProcess(config node.Config, _ *os.File, _ *os.File, flags ...string) (NodeProcess, error) {
 	if ok := lt.assert.EqualValues(lt.expectedFlags, config.Flags); !ok {
 		return nil, errors.New("assertion failed: flags not equal value")
 	}
@@ -97,8 +116,11 @@ func newMockAPIUnhealthy(ipAddr string, port uint16) api.Client {
 	healthReply := &health.APIHealthReply{Healthy: false}
 	healthClient := &apimocks.HealthClient{}
 	healthClient.On("Health", mock.Anything).Return(healthReply, nil)
+	ethClient := &apimocks.EthClient{}
+	ethClient.On("Close").Return()
 	client := &apimocks.Client{}
 	client.On("HealthAPI").Return(healthClient)
+	client.On("CChainEthAPI").Return(ethClient)
 	return client
 }
 
@@ -106,12 +128,65 @@ func newMockProcessUndef(node.Config, ...string) (NodeProcess, error) {
 	return &mocks.NodeProcess{}, nil
 }
 
-// Returns a NodeProcess that always returns nil
+// Incremented to give each mock process a unique PID.
+// Starts high so that mock PIDs aren't those of real processes
+// that the test's network could attach to.
+var nextMockPID int32 = 1 << 30
+
+// Returns a NodeProcess that always returns nil.
+// Its Wait method returns once Stop is called.
 func newMockProcessSuccessful(node.Config, ...string) (NodeProcess, error) {
+	return newMockProcess(int(atomic.AddInt32(&nextMockPID, 1)), func() error { return nil }), nil
+}
+
+// Like newMockProcessSuccessful, but the process' PID is
+// [pid] and its Start method returns what [start] returns
+func newMockProcess(pid int, start func() error) *mocks.NodeProcess {
 	process := &mocks.NodeProcess{}
-	process.On("Start").Return(nil)
-	process.On("Wait").Return(nil)
-	process.On("Stop").Return(nil)
+	closedOnStopCh := make(chan time.Time)
+	var stopOnce sync.Once
+	process.On("Start").Return(start)
+	process.On("Wait").WaitUntil(closedOnStopCh).Return(nil)
+	process.On("Stop").Run(func(mock.Arguments) {
+		stopOnce.Do(func() { close(closedOnStopCh) })
+	}).Return(nil)
+	process.On("Kill").Run(func(mock.Arguments) {
+		stopOnce.Do(func() { close(closedOnStopCh) })
+	}).Return(nil)
+	process.On("Pause").Return(nil)
+	process.On("Resume").Return(nil)
+	process.On("PID").Return(pid)
+	return process
+}
+
+// Creates processes that ignore SIGTERM for the nodes in [hungNodeNames]
+type localTestHungProcessCreator struct {
+	hungNodeNames map[string]struct{}
+	lock          sync.Mutex
+	// Node name --> Its latest process
+	processes map[string]*mocks.NodeProcess
+}
+
+func (lt *localTestHungProcessCreator) NewNodeProcess(config node.Config, _ *os.File, _ *os.File, flags ...string) (NodeProcess, error) {
+	var process *mocks.NodeProcess
+	if _, ok := lt.hungNodeNames[config.Name]; ok {
+		// Wait only returns once the process is killed
+		process = &mocks.NodeProcess{}
+		closedOnKillCh := make(chan time.Time)
+		var killOnce sync.Once
+		process.On("Start").Return(nil)
+		process.On("Wait").WaitUntil(closedOnKillCh).Return(errors.New("signal: killed"))
+		process.On("Stop").Return(nil)
+		process.On("Kill").Run(func(mock.Arguments) {
+			killOnce.Do(func() { close(closedOnKillCh) })
+		}).Return(nil)
+		process.On("PID").Return(int(atomic.AddInt32(&nextMockPID, 1)))
+	} else {
+		process = newMockProcess(int(atomic.AddInt32(&nextMockPID, 1)), func() error { return nil })
+	}
+	lt.lock.Lock()
+	lt.processes[config.Name] = process
+	lt.lock.Unlock()
 	return process, nil
 }
 
@@ -125,6 +200,7 @@ func TestNewNetworkEmpty(t *testing.T) {
 		networkConfig,
 		newMockAPISuccessful,
 		&localTestProcessUndefNodeProcessCreator{},
+		"",
 	)
 	assert.NoError(err)
 	// Assert that GetNodeNames() returns an empty list
@@ -149,10 +225,10 @@ func newLocalTestOneNodeCreator(assert *assert.Assertions, networkConfig network
 
 // Assert that the node's config is being passed correctly
 // to the function that starts the node process.
-func (lt *localTestOneNodeCreator) NewNodeProcess(config node.Config, flags ...string) (NodeProcess, error) {
+func (lt *localTestOneNodeCreator) NewNodeProcess(config node.Config, _ *os.File, _ *os.File, flags ...string) (NodeProcess, error) {
 	lt.assert.True(config.IsBeacon)
 	lt.assert.EqualValues(lt.networkConfig.NodeConfigs[0], config)
-	return lt.successCreator.NewNodeProcess(config, flags...)
+	return lt.successCreator.NewNodeProcess(config, nil, nil, flags...)
 }
 
 // Start a network with one node.
@@ -166,6 +242,7 @@ func TestNewNetworkOneNode(t *testing.T) {
 		networkConfig,
 		newMockAPISuccessful,
 		creator,
+		"",
 	)
 	assert.NoError(err)
 
@@ -189,6 +266,7 @@ func TestNewNetworkFailToStartNode(t *testing.T) {
 		networkConfig,
 		newMockAPISuccessful,
 		&localTestFailedStartProcessCreator{},
+		"",
 	)
 	assert.Error(err)
 }
@@ -409,6 +487,12 @@ func TestWrongNetworkConfigs(t *testing.T) {
 				},
 			},
 		},
+		"unknown cleanup policy": {
+			config: network.Config{
+				Genesis:       refNetworkConfig.Genesis,
+				CleanupPolicy: "sometimes",
+			},
+		},
 		"repeated name": {
 			config: network.Config{
 				Genesis: "{\"networkID\": 0}",
@@ -431,10 +515,16 @@ func TestWrongNetworkConfigs(t *testing.T) {
 			},
 		},
 	}
+	invalidPortRange := testNetworkConfig(t)
+	invalidPortRange.Ports = network.PortRange{Min: 20000, Max: 19999}
+	tests["invalid port range"] = struct{ config network.Config }{invalidPortRange}
+	repeatedPort := testNetworkConfig(t)
+	repeatedPort.Flags = map[string]interface{}{config.HTTPPortKey: 20000}
+	tests["repeated port"] = struct{ config network.Config }{repeatedPort}
 	assert := assert.New(t)
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			_, err := newNetwork(logging.NoLog{}, tt.config, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{})
+			_, err := newNetwork(logging.NoLog{}, tt.config, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
 			assert.Error(err)
 		})
 	}
@@ -445,7 +535,7 @@ func TestInvalidImplSpecificConfig(t *testing.T) {
 	assert := assert.New(t)
 	networkConfig := testNetworkConfig(t)
 	networkConfig.NodeConfigs[0].ImplSpecificConfig = json.RawMessage("just a string")
-	_, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{})
+	_, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
 	assert.Error(err)
 }
 
@@ -454,9 +544,10 @@ func TestInvalidImplSpecificConfig(t *testing.T) {
 func TestUnhealthyNetwork(t *testing.T) {
 	assert := assert.New(t)
 	networkConfig := testNetworkConfig(t)
-	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPIUnhealthy, &localTestSuccessfulNodeProcessCreator{})
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPIUnhealthy, &localTestSuccessfulNodeProcessCreator{}, "")
 	assert.NoError(err)
-	assert.Error(awaitNetworkHealthy(net, defaultHealthyTimeout))
+	net.(*localNetwork).healthCheckFreq = testHealthCheckFreq
+	assert.Error(awaitNetworkHealthy(net, 100*time.Millisecond))
 }
 
 // Create a network without giving names to nodes.
@@ -467,7 +558,7 @@ func TestGeneratedNodesNames(t *testing.T) {
 	for i := range networkConfig.NodeConfigs {
 		networkConfig.NodeConfigs[i].Name = ""
 	}
-	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{})
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
 	assert.NoError(err)
 	nodeNameMap := make(map[string]bool)
 	nodeNames, err := net.GetNodeNames()
@@ -530,7 +621,7 @@ func TestGenerateDefaultNetwork(t *testing.T) {
 func TestNetworkFromConfig(t *testing.T) {
 	assert := assert.New(t)
 	networkConfig := testNetworkConfig(t)
-	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{})
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
 	assert.NoError(err)
 	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
 	runningNodes := make(map[string]struct{})
@@ -551,7 +642,7 @@ func TestNetworkNodeOps(t *testing.T) {
 	// Start a new, empty network
 	emptyNetworkConfig, err := emptyNetworkConfig()
 	assert.NoError(err)
-	net, err := newNetwork(logging.NoLog{}, emptyNetworkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{})
+	net, err := newNetwork(logging.NoLog{}, emptyNetworkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
 	assert.NoError(err)
 	runningNodes := make(map[string]struct{})
 
@@ -586,7 +677,7 @@ func TestNodeNotFound(t *testing.T) {
 	emptyNetworkConfig, err := emptyNetworkConfig()
 	assert.NoError(err)
 	networkConfig := testNetworkConfig(t)
-	net, err := newNetwork(logging.NoLog{}, emptyNetworkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{})
+	net, err := newNetwork(logging.NoLog{}, emptyNetworkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
 	assert.NoError(err)
 	_, err = net.AddNode(networkConfig.NodeConfigs[0])
 	assert.NoError(err)
@@ -616,7 +707,7 @@ func TestStoppedNetwork(t *testing.T) {
 	emptyNetworkConfig, err := emptyNetworkConfig()
 	assert.NoError(err)
 	networkConfig := testNetworkConfig(t)
-	net, err := newNetwork(logging.NoLog{}, emptyNetworkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{})
+	net, err := newNetwork(logging.NoLog{}, emptyNetworkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
 	assert.NoError(err)
 	_, err = net.AddNode(networkConfig.NodeConfigs[0])
 	assert.NoError(err)
@@ -647,7 +738,7 @@ func TestStoppedNetwork(t *testing.T) {
 func TestGetAllNodes(t *testing.T) {
 	assert := assert.New(t)
 	networkConfig := testNetworkConfig(t)
-	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{})
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
 	assert.NoError(err)
 
 	nodes, err := net.GetAllNodes()
@@ -687,7 +778,7 @@ func TestFlags(t *testing.T) {
 			"test2-node-config-flag":   "config",
 		},
 		assert: assert,
-	})
+	}, "")
 	if ok := assert.NoError(err); !ok {
 		t.Fatal("assertion failed")
 	}
@@ -709,7 +800,7 @@ func TestFlags(t *testing.T) {
 		// after creating the network, only node configs should exist
 		expectedFlags: flags,
 		assert:        assert,
-	})
+	}, "")
 	if ok := assert.NoError(err); !ok {
 		t.Fatal("assertion failed")
 	}
@@ -730,7 +821,7 @@ func TestFlags(t *testing.T) {
 		// after creating the network, only flags from the network config should exist
 		expectedFlags: flags,
 		assert:        assert,
-	})
+	}, "")
 	assert.NoError(err)
 	err = nw.Stop(context.Background())
 	assert.NoError(err)
@@ -783,7 +874,18 @@ func TestChildCmdRedirection(t *testing.T) {
 		ImplSpecificConfig: json.RawMessage(`{"binaryPath":"echo","redirectStdout":true,"redirectStderr":true}`),
 		Name:               mockNodeName,
 	}
-	proc, err := npc.NewNodeProcess(testConfig, testOutput)
+	// the output is printed from the files the process writes it to
+	stdout, _, err := openLogFile(filepath.Join(t.TempDir(), stdoutLogFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	stderr, _, err := openLogFile(filepath.Join(t.TempDir(), stderrLogFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+	proc, err := npc.NewNodeProcess(testConfig, stdout, stderr, testOutput)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -793,16 +895,13 @@ func TestChildCmdRedirection(t *testing.T) {
 
 	// lock read access to the buffer
 	<-buf.writtenCh
-	newResult := buf.String()
 
-	// wait for the process to finish.
-	// Note that, according to the specification of StdoutPipe
-	// and StderrPipe, we have to wait until after we read from
-	// the pipe before calling Wait.
-	// See https://pkg.go.dev/os/exec#Cmd.StdoutPipe
+	// wait for the process to finish, which
+	// prints the rest of its output
 	if err = proc.Wait(); err != nil {
 		t.Fatal(err)
 	}
+	newResult := buf.String()
 
 	// now do the checks:
 	// the new string should contain the node name
@@ -876,7 +975,7 @@ func testNetworkConfig(t *testing.T) network.Config {
 			Name:               fmt.Sprintf("node%d", i),
 			ImplSpecificConfig: utils.NewLocalNodeConfigJsonRaw("pepito"),
 		}
-		cert, key, err := staking.NewCertAndKeyBytes()
+		cert, key, err := testStakingCertAndKey(i)
 		assert.NoError(err)
 		nodeConfig.StakingCert = string(cert)
 		nodeConfig.StakingKey = string(key)
@@ -887,6 +986,29 @@ func testNetworkConfig(t *testing.T) network.Config {
 	return networkConfig
 }
 
+var (
+	testStakingCertsLock sync.Mutex
+	// Staking certs and keys given to the nodes of test networks.
+	// Generating them takes a while, so they're shared by all the tests.
+	testStakingCerts, testStakingKeys [][]byte
+)
+
+// Returns the staking cert and key of the [i]th node of test networks
+func testStakingCertAndKey(i int) ([]byte, []byte, error) {
+	testStakingCertsLock.Lock()
+	defer testStakingCertsLock.Unlock()
+
+	for len(testStakingCerts) <= i {
+		cert, key, err := staking.NewCertAndKeyBytes()
+		if err != nil {
+			return nil, nil, err
+		}
+		testStakingCerts = append(testStakingCerts, cert)
+		testStakingKeys = append(testStakingKeys, key)
+	}
+	return testStakingCerts[i], testStakingKeys[i], nil
+}
+
 // Returns nil when all the nodes in [net] are healthy,
 // or an error if one doesn't become healthy within
 // the timeout.
@@ -896,3 +1018,2631 @@ func awaitNetworkHealthy(net network.Network, timeout time.Duration) error {
 	healthyCh := net.Healthy(ctx)
 	return <-healthyCh
 }
+
+// localTestFlagRecorderProcessCreator creates processes
+// that always succeed, and records the config and flags of
+// the last process created for each node.
+type localTestFlagRecorderProcessCreator struct {
+	// Nodes may be started concurrently
+	lock    sync.Mutex
+	configs map[string]node.Config
+	flags   map[string][]string
+}
+
+func newLocalTestFlagRecorderProcessCreator() *localTestFlagRecorderProcessCreator {
+	return &localTestFlagRecorderProcessCreator{
+		configs: make(map[string]node.Config),
+		flags:   make(map[string][]string),
+	}
+}
+
+func (lt *localTestFlagRecorderProcessCreator) NewNodeProcess(config node.Config, _ *os.File, _ *os.File, flags ...string) (NodeProcess, error) {
+	lt.lock.Lock()
+	lt.configs[config.Name] = config
+	lt.flags[config.Name] = flags
+	lt.lock.Unlock()
+	return newMockProcessSuccessful(config, flags...)
+}
+
+// TestRestartNode checks that a restarted node keeps its
+// identity, directories and ports, and gets its new config
+func TestRestartNode(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	creator := newLocalTestFlagRecorderProcessCreator()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, creator, "")
+	assert.NoError(err)
+
+	// The beacon shouldn't bootstrap from itself
+	beaconName := networkConfig.NodeConfigs[0].Name
+	beacon, err := net.GetNode(beaconName)
+	assert.NoError(err)
+	assert.Contains(creator.flags[beaconName], fmt.Sprintf("--%s=", config.BootstrapIPsKey))
+
+	nodeName := networkConfig.NodeConfigs[1].Name
+	oldNode, err := net.GetNode(nodeName)
+	assert.NoError(err)
+	oldFlags := creator.flags[nodeName]
+	assert.Contains(oldFlags, fmt.Sprintf("--%s=127.0.0.1:%d", config.BootstrapIPsKey, beacon.GetP2PPort()))
+
+	// Restart with the same config
+	assert.NoError(net.RestartNode(context.Background(), nodeName, nil))
+	assert.EqualValues(oldFlags, creator.flags[nodeName])
+
+	// Restart with new flags and binary
+	newConfig := &node.Config{
+		ImplSpecificConfig: utils.NewLocalNodeConfigJsonRaw("new-binary"),
+		Flags: map[string]interface{}{
+			"new-flag": "value",
+		},
+	}
+	assert.NoError(net.RestartNode(context.Background(), nodeName, newConfig))
+	newNode, err := net.GetNode(nodeName)
+	assert.NoError(err)
+	assert.EqualValues(oldNode.GetNodeID(), newNode.GetNodeID())
+	assert.EqualValues(oldNode.GetAPIPort(), newNode.GetAPIPort())
+	assert.EqualValues(oldNode.GetP2PPort(), newNode.GetP2PPort())
+	assert.EqualValues(newConfig.ImplSpecificConfig, creator.configs[nodeName].ImplSpecificConfig)
+	assert.EqualValues(networkConfig.NodeConfigs[1].StakingKey, creator.configs[nodeName].StakingKey)
+	assert.Contains(creator.flags[nodeName], "--new-flag=value")
+	for _, flag := range oldFlags {
+		if strings.HasPrefix(flag, fmt.Sprintf("--%s=", config.DBPathKey)) {
+			assert.Contains(creator.flags[nodeName], flag)
+		}
+	}
+
+	// Can't change a node's identity
+	_, key, err := staking.NewCertAndKeyBytes()
+	assert.NoError(err)
+	assert.Error(net.RestartNode(context.Background(), nodeName, &node.Config{StakingKey: string(key)}))
+	assert.Error(net.RestartNode(context.Background(), nodeName, &node.Config{Name: "other"}))
+	// Unknown node
+	assert.Error(net.RestartNode(context.Background(), "not a node", nil))
+
+	assert.NoError(net.Stop(context.Background()))
+	assert.EqualValues(network.ErrStopped, net.RestartNode(context.Background(), nodeName, nil))
+}
+
+// Returns a func that creates API clients like newMockAPISuccessful,
+// whose Info API reports a version that changes with each client
+func newMockAPIVersionedF() api.NewAPIClientF {
+	var numClients int32
+	return func(ipAddr string, port uint16) api.Client {
+		client := newMockAPISuccessful(ipAddr, port).(*apimocks.Client)
+		infoClient := &apimocks.InfoClient{}
+		infoClient.On("GetNodeVersion", mock.Anything).Return(&info.GetNodeVersionReply{
+			Version: fmt.Sprintf("avalanche/1.7.%d", atomic.AddInt32(&numClients, 1)),
+		}, nil)
+		client.On("InfoAPI").Return(infoClient)
+		return client
+	}
+}
+
+// TestUpgradeNode checks that an upgraded node is restarted with
+// the new binary, and that its versions before and after are returned
+func TestUpgradeNode(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	creator := newLocalTestFlagRecorderProcessCreator()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPIVersionedF(), creator, "")
+	assert.NoError(err)
+	nodeName := networkConfig.NodeConfigs[1].Name
+	oldNode, err := net.GetNode(nodeName)
+	assert.NoError(err)
+	oldFlags := creator.flags[nodeName]
+
+	newBinaryPath := filepath.Join(t.TempDir(), "avalanchego")
+	assert.NoError(os.WriteFile(newBinaryPath, nil, 0o755))
+	upgrade, err := net.UpgradeNode(context.Background(), nodeName, newBinaryPath)
+	assert.NoError(err)
+	assert.Equal(nodeName, upgrade.NodeName)
+	assert.NotEqual(upgrade.OldVersion.Version, upgrade.NewVersion.Version)
+	var localNodeConfig NodeConfig
+	assert.NoError(json.Unmarshal(creator.configs[nodeName].ImplSpecificConfig, &localNodeConfig))
+	assert.Equal(newBinaryPath, localNodeConfig.BinaryPath)
+	// Same identity, database and ports
+	newNode, err := net.GetNode(nodeName)
+	assert.NoError(err)
+	assert.EqualValues(oldNode.GetNodeID(), newNode.GetNodeID())
+	assert.EqualValues(oldNode.GetP2PPort(), newNode.GetP2PPort())
+	assert.EqualValues(oldFlags, creator.flags[nodeName])
+
+	// A node isn't stopped if its new binary doesn't exist
+	_, err = net.UpgradeNode(context.Background(), nodeName, filepath.Join(t.TempDir(), "missing"))
+	assert.Error(err)
+	_, err = net.GetNode(nodeName)
+	assert.NoError(err)
+	_, err = net.UpgradeNode(context.Background(), "not a node", newBinaryPath)
+	assert.Error(err)
+
+	assert.NoError(net.Stop(context.Background()))
+	_, err = net.UpgradeNode(context.Background(), nodeName, newBinaryPath)
+	assert.EqualValues(network.ErrStopped, err)
+}
+
+// Creates processes that exit as soon as they start if they
+// run node [crashingNodeName] with the binary at [crashingBinaryPath]
+type localTestCrashingBinaryProcessCreator struct {
+	crashingNodeName   string
+	crashingBinaryPath string
+	lock               sync.Mutex
+	// The API port of the crashing node while it runs
+	// the crashing binary. Empty otherwise.
+	crashingAPIPort string
+}
+
+func (lt *localTestCrashingBinaryProcessCreator) NewNodeProcess(nodeConfig node.Config, _ *os.File, _ *os.File, flags ...string) (NodeProcess, error) {
+	var localNodeConfig NodeConfig
+	if err := json.Unmarshal(nodeConfig.ImplSpecificConfig, &localNodeConfig); err != nil {
+		return nil, err
+	}
+	if nodeConfig.Name != lt.crashingNodeName || localNodeConfig.BinaryPath != lt.crashingBinaryPath {
+		if nodeConfig.Name == lt.crashingNodeName {
+			lt.lock.Lock()
+			lt.crashingAPIPort = ""
+			lt.lock.Unlock()
+		}
+		return newMockProcessSuccessful(nodeConfig, flags...)
+	}
+	lt.lock.Lock()
+	lt.crashingAPIPort = flagValue(flags, config.HTTPPortKey)
+	lt.lock.Unlock()
+	process := &mocks.NodeProcess{}
+	process.On("Start").Return(nil)
+	process.On("Wait").Return(errors.New("exit status 1"))
+	process.On("PID").Return(int(atomic.AddInt32(&nextMockPID, 1)))
+	return process, nil
+}
+
+// Returns a func that creates API clients like those [newAPIClientF]
+// creates, except that the crashing node doesn't report healthy, and
+// doesn't report its version, while it runs the crashing binary
+func (lt *localTestCrashingBinaryProcessCreator) newAPIClientF(newAPIClientF api.NewAPIClientF) api.NewAPIClientF {
+	return func(ipAddr string, port uint16) api.Client {
+		base := newAPIClientF(ipAddr, port)
+		crashing := func() bool {
+			lt.lock.Lock()
+			defer lt.lock.Unlock()
+			return strconv.Itoa(int(port)) == lt.crashingAPIPort
+		}
+		healthClient := &apimocks.HealthClient{}
+		healthClient.On("Health", mock.Anything).Return(func(context.Context) *health.APIHealthReply {
+			return &health.APIHealthReply{Healthy: !crashing()}
+		}, nil)
+		infoClient := &apimocks.InfoClient{}
+		infoClient.On("GetNodeVersion", mock.Anything).Return(
+			func(ctx context.Context) *info.GetNodeVersionReply {
+				if crashing() {
+					return nil
+				}
+				reply, _ := base.InfoAPI().GetNodeVersion(ctx)
+				return reply
+			},
+			func(context.Context) error {
+				if crashing() {
+					return errors.New("connection refused")
+				}
+				return nil
+			},
+		)
+		client := &apimocks.Client{}
+		client.On("HealthAPI").Return(healthClient)
+		client.On("InfoAPI").Return(infoClient)
+		client.On("CChainEthAPI").Return(base.CChainEthAPI())
+		return client
+	}
+}
+
+// TestRollingUpgrade checks that nodes are upgraded in batches,
+// and downgraded again if an upgraded node isn't healthy
+func TestRollingUpgrade(t *testing.T) {
+	assert := assert.New(t)
+	binaryPaths := map[string]string{}
+	for _, name := range []string{"old", "new"} {
+		binaryPaths[name] = filepath.Join(t.TempDir(), name)
+		assert.NoError(os.WriteFile(binaryPaths[name], nil, 0o755))
+	}
+	networkConfig := testNetworkConfig(t)
+	for i := range networkConfig.NodeConfigs {
+		networkConfig.NodeConfigs[i].ImplSpecificConfig = utils.NewLocalNodeConfigJsonRaw(binaryPaths["old"])
+	}
+	binaryPathOf := func(net Network, nodeName string) string {
+		info, err := net.Describe()
+		assert.NoError(err)
+		return info.Nodes[nodeName].BinaryPath
+	}
+
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPIVersionedF(), &localTestSuccessfulNodeProcessCreator{}, "")
+	assert.NoError(err)
+	upgrades, err := net.RollingUpgrade(context.Background(), binaryPaths["new"], network.RollingUpgradeOptions{BatchSize: 2})
+	assert.NoError(err)
+	assert.Len(upgrades, len(networkConfig.NodeConfigs))
+	for _, upgrade := range upgrades {
+		assert.Equal(binaryPaths["old"], upgrade.PreviousBinaryOrTag)
+		assert.NotNil(upgrade.NewVersion)
+		assert.Equal(binaryPaths["new"], binaryPathOf(net, upgrade.NodeName))
+	}
+	assert.NoError(net.Stop(context.Background()))
+
+	// The second node crashes after being upgraded
+	name0, name1, name2 := networkConfig.NodeConfigs[0].Name, networkConfig.NodeConfigs[1].Name, networkConfig.NodeConfigs[2].Name
+	creator := &localTestCrashingBinaryProcessCreator{
+		crashingNodeName:   name1,
+		crashingBinaryPath: binaryPaths["new"],
+	}
+	net, err = newNetwork(logging.NoLog{}, networkConfig, creator.newAPIClientF(newMockAPIVersionedF()), creator, "")
+	assert.NoError(err)
+	upgrades, err = net.RollingUpgrade(context.Background(), binaryPaths["new"], network.RollingUpgradeOptions{
+		NodeNames:     []string{name0, name1, name2},
+		HealthTimeout: defaultHealthyTimeout,
+		Rollback:      true,
+	})
+	assert.Error(err)
+	assert.Len(upgrades, 2)
+	for _, nodeName := range []string{name0, name1, name2} {
+		assert.Equal(binaryPaths["old"], binaryPathOf(net, nodeName))
+	}
+	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestPauseResumeNode checks that paused nodes are
+// reported as such, and resumed before being stopped
+func TestPauseResumeNode(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
+	assert.NoError(err)
+	nodeName := networkConfig.NodeConfigs[0].Name
+	process := net.(*localNetwork).nodes[nodeName].process.(*mocks.NodeProcess)
+
+	assert.NoError(net.PauseNode(nodeName))
+	process.AssertCalled(t, "Pause")
+	// Can't pause a paused node
+	assert.ErrorIs(net.PauseNode(nodeName), ErrNodePaused)
+	// A paused node is unhealthy
+	err = awaitNetworkHealthy(net, defaultHealthyTimeout)
+	assert.ErrorIs(err, ErrNodePaused)
+	assert.Contains(err.Error(), nodeName)
+
+	assert.NoError(net.ResumeNode(nodeName))
+	process.AssertCalled(t, "Resume")
+	// Can't resume a node that isn't paused
+	assert.Error(net.ResumeNode(nodeName))
+	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
+
+	// A paused node is resumed before it's stopped
+	assert.NoError(net.PauseNode(nodeName))
+	assert.NoError(net.RemoveNode(nodeName))
+	process.AssertNumberOfCalls(t, "Resume", 2)
+	process.AssertCalled(t, "Stop")
+
+	assert.Error(net.PauseNode("not a node"))
+	assert.Error(net.ResumeNode("not a node"))
+	assert.NoError(net.Stop(context.Background()))
+	assert.EqualValues(network.ErrStopped, net.PauseNode(networkConfig.NodeConfigs[1].Name))
+	assert.EqualValues(network.ErrStopped, net.ResumeNode(networkConfig.NodeConfigs[1].Name))
+}
+
+// TestSnapshot checks that a network restored from a
+// snapshot has the saved nodes, databases and configs
+func TestSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	// This node's database is outside of its root directory
+	customDBDir := t.TempDir()
+	networkConfig.NodeConfigs[2].ConfigFile = fmt.Sprintf(`{"%s":"%s"}`, config.DBPathKey, customDBDir)
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
+	assert.NoError(err)
+	ln := net.(*localNetwork)
+	ln.snapshotsDir = t.TempDir()
+
+	// Fake databases and logs
+	for _, node := range ln.nodes {
+		assert.NoError(createFileAndWrite(filepath.Join(node.dbDir, "network-1337", "db"), []byte(node.name)))
+		assert.NoError(createFileAndWrite(filepath.Join(node.logsDir, "main.log"), []byte(node.name)))
+	}
+
+	_, err = net.SaveSnapshot(context.Background(), "../snapshot")
+	assert.Error(err)
+	snapshotDir, err := net.SaveSnapshot(context.Background(), "snapshot")
+	assert.NoError(err)
+	assert.EqualValues(filepath.Join(ln.snapshotsDir, "snapshot"), snapshotDir)
+	// Saving a snapshot stops the network
+	_, err = net.GetNodeNames()
+	assert.EqualValues(network.ErrStopped, err)
+	_, err = net.SaveSnapshot(context.Background(), "snapshot2")
+	assert.EqualValues(network.ErrStopped, err)
+
+	restored, err := loadSnapshot(logging.NoLog{}, snapshotDir, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{})
+	assert.NoError(err)
+	restoredNodes, err := restored.GetAllNodes()
+	assert.NoError(err)
+	assert.Len(restoredNodes, len(networkConfig.NodeConfigs))
+	for _, nodeConfig := range networkConfig.NodeConfigs {
+		restoredNode := restored.(*localNetwork).nodes[nodeConfig.Name]
+		if !assert.NotNil(restoredNode) {
+			continue
+		}
+		assert.EqualValues(nodeConfig.StakingKey, restoredNode.config.StakingKey)
+		assert.EqualValues(nodeConfig.IsBeacon, restoredNode.config.IsBeacon)
+		// Databases are restored into the nodes' root directories
+		assert.EqualValues(restoredNode.rootDir, restoredNode.dbDir)
+		db, err := os.ReadFile(filepath.Join(restoredNode.dbDir, "network-1337", "db"))
+		assert.NoError(err)
+		assert.EqualValues(nodeConfig.Name, string(db))
+		// Logs aren't part of the snapshot
+		_, err = os.Stat(filepath.Join(restoredNode.logsDir, "main.log"))
+		assert.ErrorIs(err, fs.ErrNotExist)
+	}
+	assert.NoError(restored.Stop(context.Background()))
+
+	_, err = loadSnapshot(logging.NoLog{}, t.TempDir(), newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{})
+	assert.Error(err)
+}
+
+// Returns the next event on [events], or fails the test if
+// there isn't one within a second
+func nextEvent(t *testing.T, events <-chan network.Event) network.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		assert.FailNow(t, "didn't get event")
+		return network.Event{}
+	}
+}
+
+// TestEvents checks that the network sends events
+// for node lifecycle changes
+func TestEvents(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	nodeConfigs := networkConfig.NodeConfigs
+	networkConfig.NodeConfigs = nodeConfigs[:1]
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
+	assert.NoError(err)
+	events, unsubscribe := net.Subscribe()
+	defer unsubscribe()
+
+	_, err = net.AddNode(nodeConfigs[1])
+	assert.NoError(err)
+	event := nextEvent(t, events)
+	assert.EqualValues(network.NodeAdded, event.Type)
+	assert.EqualValues(nodeConfigs[1].Name, event.NodeName)
+
+	assert.NoError(net.RemoveNode(nodeConfigs[1].Name))
+	event = nextEvent(t, events)
+	assert.EqualValues(network.NodeExited, event.Type)
+	assert.EqualValues(nodeConfigs[1].Name, event.NodeName)
+	assert.EqualValues(0, event.ExitCode)
+	event = nextEvent(t, events)
+	assert.EqualValues(network.NodeRemoved, event.Type)
+	assert.EqualValues(nodeConfigs[1].Name, event.NodeName)
+
+	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
+	event = nextEvent(t, events)
+	assert.EqualValues(network.NodeHealthy, event.Type)
+	assert.EqualValues(nodeConfigs[0].Name, event.NodeName)
+
+	assert.NoError(net.Stop(context.Background()))
+	assert.EqualValues(network.NodeExited, nextEvent(t, events).Type)
+	assert.EqualValues(network.NodeRemoved, nextEvent(t, events).Type)
+	assert.EqualValues(network.NetworkStopped, nextEvent(t, events).Type)
+}
+
+// TestExitCode checks that exit codes are read from process errors
+func TestExitCode(t *testing.T) {
+	assert := assert.New(t)
+	assert.EqualValues(0, exitCode(nil))
+	assert.EqualValues(-1, exitCode(errors.New("not an exit error")))
+	err := exec.Command("sh", "-c", "exit 3").Run()
+	assert.EqualValues(3, exitCode(err))
+	assert.EqualValues(3, exitCode(fmt.Errorf("wrapped: %w", err)))
+}
+
+// localTestCrashingProcessCreator creates processes that always succeed,
+// except that the process of node [crashingNodeName] exits with
+// [exitErr] when [crashCh] is closed
+type localTestCrashingProcessCreator struct {
+	crashingNodeName string
+	crashCh          chan time.Time
+	exitErr          error
+}
+
+func (lt *localTestCrashingProcessCreator) NewNodeProcess(config node.Config, _ *os.File, _ *os.File, flags ...string) (NodeProcess, error) {
+	if config.Name != lt.crashingNodeName {
+		return newMockProcessSuccessful(config, flags...)
+	}
+	process := &mocks.NodeProcess{}
+	process.On("Start").Return(nil)
+	process.On("Wait").WaitUntil(lt.crashCh).Return(lt.exitErr)
+	process.On("PID").Return(int(atomic.AddInt32(&nextMockPID, 1)))
+	return process, nil
+}
+
+// TestNodeExitedUnexpectedly checks that a node whose
+// process exits on its own is reported as such
+func TestNodeExitedUnexpectedly(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	crashingNodeName := networkConfig.NodeConfigs[1].Name
+	creator := &localTestCrashingProcessCreator{
+		crashingNodeName: crashingNodeName,
+		crashCh:          make(chan time.Time),
+		exitErr:          exec.Command("sh", "-c", "exit 2").Run(),
+	}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, creator, "")
+	assert.NoError(err)
+	events, unsubscribe := net.Subscribe()
+	defer unsubscribe()
+
+	_, err = net.GetNode(crashingNodeName)
+	assert.NoError(err)
+	close(creator.crashCh)
+	event := nextEvent(t, events)
+	assert.EqualValues(network.NodeExited, event.Type)
+	assert.EqualValues(crashingNodeName, event.NodeName)
+	assert.EqualValues(2, event.ExitCode)
+
+	_, err = net.GetNode(crashingNodeName)
+	assert.Error(err)
+	assert.Contains(err.Error(), crashingNodeName)
+	assert.Contains(err.Error(), "exit code 2")
+	err = awaitNetworkHealthy(net, defaultHealthyTimeout)
+	assert.Error(err)
+	assert.Contains(err.Error(), crashingNodeName)
+	assert.Contains(err.Error(), "exit code 2")
+
+	// The other nodes are fine
+	_, err = net.GetNode(networkConfig.NodeConfigs[0].Name)
+	assert.NoError(err)
+	// The crashed node can be removed
+	assert.NoError(net.RemoveNode(crashingNodeName))
+	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestNodeProcessStderrTail checks that the error returned when
+// a node process fails includes the end of its stderr
+func TestNodeProcessStderrTail(t *testing.T) {
+	assert := assert.New(t)
+	npc := &nodeProcessCreator{
+		stdout:      &bytes.Buffer{},
+		stderr:      &bytes.Buffer{},
+		colorPicker: utils.NewColorPicker(),
+	}
+	script := "for i in $(seq 1 50); do echo line$i >&2; done; exit 3"
+	for _, redirect := range []bool{false, true} {
+		testConfig := node.Config{
+			ImplSpecificConfig: json.RawMessage(fmt.Sprintf(`{"binaryPath":"sh","redirectStderr":%t}`, redirect)),
+			Name:               "failing-node",
+		}
+		stderr, _, err := openLogFile(filepath.Join(t.TempDir(), stderrLogFileName))
+		assert.NoError(err)
+		// Lines an earlier process wrote aren't in the error
+		_, err = stderr.WriteString("line0\n")
+		assert.NoError(err)
+		proc, err := npc.NewNodeProcess(testConfig, nil, stderr, "-c", script)
+		assert.NoError(err)
+		assert.NoError(proc.Start())
+		err = proc.Wait()
+		assert.Error(err)
+		assert.EqualValues(3, exitCode(err))
+		assert.Contains(err.Error(), "line50")
+		assert.Contains(err.Error(), fmt.Sprintf("line%d", 50-stderrTailLines+1))
+		assert.NotContains(err.Error(), fmt.Sprintf("line%d\n", 50-stderrTailLines))
+		assert.NoError(stderr.Close())
+	}
+}
+
+// TestNodeProcessResourceLimits checks that a node process with
+// resource limits is moved into a cgroup with those limits, and
+// that the cgroup is removed once the process exits
+func TestNodeProcessResourceLimits(t *testing.T) {
+	assert := assert.New(t)
+	// A directory standing in for the cgroup v2 hierarchy
+	cgroupRoot := t.TempDir()
+	assert.NoError(os.WriteFile(filepath.Join(cgroupRoot, "cgroup.controllers"), []byte("cpu io memory"), 0o644))
+	npc := &nodeProcessCreator{
+		stdout:      &bytes.Buffer{},
+		stderr:      &bytes.Buffer{},
+		colorPicker: utils.NewColorPicker(),
+		cgroupRoot:  cgroupRoot,
+	}
+	testConfig := node.Config{
+		ImplSpecificConfig: json.RawMessage(`{"binaryPath":"sh","cpuQuota":0.5,"memoryMax":1073741824,"ioWeight":50}`),
+		Name:               "limited-node",
+	}
+	proc, err := npc.NewNodeProcess(testConfig, nil, nil, "-c", "exit 0")
+	assert.NoError(err)
+	assert.NoError(proc.Start())
+	parent := filepath.Join(cgroupRoot, cgroupParentName)
+	cgroupDir := nodeCgroupPath(cgroupRoot, testConfig.Name, proc.PID())
+	for path, want := range map[string]string{
+		filepath.Join(parent, "cgroup.subtree_control"): "+cpu +memory +io",
+		filepath.Join(cgroupDir, "cpu.max"):             "50000 100000",
+		filepath.Join(cgroupDir, "memory.max"):          "1073741824",
+		filepath.Join(cgroupDir, "io.weight"):           "default 50",
+		filepath.Join(cgroupDir, "cgroup.procs"):        strconv.Itoa(proc.PID()),
+	} {
+		got, err := os.ReadFile(path)
+		assert.NoError(err)
+		assert.Equal(want, string(got))
+	}
+	assert.NoError(proc.Wait())
+	assert.NoDirExists(cgroupDir)
+	assert.DirExists(parent)
+
+	// The binary only runs once its process is in the cgroup, and doesn't
+	// get the pipe it was held back with
+	stdout, err := os.Create(filepath.Join(t.TempDir(), stdoutLogFileName))
+	assert.NoError(err)
+	defer stdout.Close()
+	script := fmt.Sprintf(`cat %s/limited-node-$$/cgroup.procs; [ -e /proc/$$/fd/3 ] && echo leaked; exit 0`, parent)
+	proc, err = npc.NewNodeProcess(testConfig, stdout, nil, "-c", script)
+	assert.NoError(err)
+	assert.NoError(proc.Start())
+	assert.NoError(proc.Wait())
+	got, err := os.ReadFile(stdout.Name())
+	assert.NoError(err)
+	assert.Equal(strconv.Itoa(proc.PID()), string(got))
+
+	// Only the controllers of the limits that are set are enabled
+	testConfig.ImplSpecificConfig = json.RawMessage(`{"binaryPath":"sh","memoryMax":1073741824}`)
+	proc, err = npc.NewNodeProcess(testConfig, nil, nil, "-c", "exit 0")
+	assert.NoError(err)
+	assert.NoError(proc.Start())
+	got, err = os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	assert.NoError(err)
+	assert.Equal("+memory", string(got))
+	assert.NoFileExists(filepath.Join(nodeCgroupPath(cgroupRoot, testConfig.Name, proc.PID()), "cpu.max"))
+	assert.NoError(proc.Wait())
+
+	// Invalid limits
+	for _, limits := range []string{`"cpuQuota":-1`, `"cpuQuota":0.000001`, `"ioWeight":10001`} {
+		testConfig.ImplSpecificConfig = json.RawMessage(fmt.Sprintf(`{"binaryPath":"sh",%s}`, limits))
+		_, err = npc.NewNodeProcess(testConfig, nil, nil, "-c", "exit 0")
+		assert.Error(err)
+	}
+
+	// Without cgroup v2, the process is killed rather than left running without its limits
+	npc.cgroupRoot = t.TempDir()
+	testConfig.ImplSpecificConfig = json.RawMessage(`{"binaryPath":"sh","cpuQuota":1}`)
+	proc, err = npc.NewNodeProcess(testConfig, nil, nil, "-c", "sleep 60")
+	assert.NoError(err)
+	err = proc.Start()
+	assert.Error(err)
+	assert.Contains(err.Error(), "cgroup v2")
+	assert.False(processExists(proc.PID()))
+}
+
+// TestGetNodeLogs checks that a node's output is kept in memory
+// and written to log files in its root directory
+func TestGetNodeLogs(t *testing.T) {
+	assert := assert.New(t)
+	binaryPath := filepath.Join(t.TempDir(), "avalanchego")
+	script := "#!/bin/sh\n" +
+		"trap 'kill $!; exit 0' TERM\n" +
+		"echo 'INFO [01-02|15:04:05] <P Chain> bootstrapping finished'\n" +
+		"echo 'WARN [01-02|15:04:05] <C Chain> peer benched' >&2\n" +
+		"printf 'no newline'\n" +
+		"sleep 60 &\n" +
+		"wait\n"
+	assert.NoError(os.WriteFile(binaryPath, []byte(script), 0o755))
+	networkConfig := testNetworkConfig(t)
+	networkConfig.NodeConfigs = networkConfig.NodeConfigs[:1]
+	networkConfig.NodeConfigs[0].ImplSpecificConfig = utils.NewLocalNodeConfigJsonRaw(binaryPath)
+	nodeName := networkConfig.NodeConfigs[0].Name
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &nodeProcessCreator{
+		stdout:      &bytes.Buffer{},
+		stderr:      &bytes.Buffer{},
+		colorPicker: utils.NewColorPicker(),
+	}, "")
+	assert.NoError(err)
+
+	var lines []network.LogLine
+	assert.Eventually(func() bool {
+		lines, err = net.GetNodeLogs(context.Background(), nodeName, network.LogQuery{})
+		assert.NoError(err)
+		return len(lines) == 2
+	}, 10*time.Second, 10*time.Millisecond)
+	streams := map[string]network.LogLine{}
+	for _, line := range lines {
+		streams[line.Stream] = line
+	}
+	assert.Equal("INFO", streams[network.StdoutStream].Level)
+	assert.Equal("WARN", streams[network.StderrStream].Level)
+	assert.Equal("WARN [01-02|15:04:05] <C Chain> peer benched", streams[network.StderrStream].Text)
+
+	lines, err = net.GetNodeLogs(context.Background(), nodeName, network.LogQuery{Level: "WARN"})
+	assert.NoError(err)
+	assert.Len(lines, 1)
+	lines, err = net.GetNodeLogs(context.Background(), nodeName, network.LogQuery{Since: time.Now().Add(time.Minute)})
+	assert.NoError(err)
+	assert.Len(lines, 0)
+	_, err = net.GetNodeLogs(context.Background(), nodeName, network.LogQuery{Tail: -1})
+	assert.Error(err)
+	_, err = net.GetNodeLogs(context.Background(), "not a node", network.LogQuery{})
+	assert.Error(err)
+
+	// The unterminated last line is kept once the node exits,
+	// and the node's output is also in its root directory
+	info, err := net.Describe()
+	assert.NoError(err)
+	rootDir := info.Nodes[nodeName].RootDir
+	assert.NoError(net.RestartNode(context.Background(), nodeName, nil))
+	lines, err = net.GetNodeLogs(context.Background(), nodeName, network.LogQuery{})
+	assert.NoError(err)
+	assert.GreaterOrEqual(len(lines), 3)
+	assert.Equal("no newline", lines[2].Text)
+	stdout, err := os.ReadFile(filepath.Join(rootDir, stdoutLogFileName))
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(string(stdout), "INFO [01-02|15:04:05] <P Chain> bootstrapping finished\nno newline"))
+	stderr, err := os.ReadFile(filepath.Join(rootDir, stderrLogFileName))
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(string(stderr), "WARN [01-02|15:04:05] <C Chain> peer benched\n"))
+
+	// Let the restarted node handle SIGTERM
+	assert.Eventually(func() bool {
+		lines, err = net.GetNodeLogs(context.Background(), nodeName, network.LogQuery{})
+		assert.NoError(err)
+		return len(lines) == 5
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.NoError(net.Stop(context.Background()))
+	_, err = net.GetNodeLogs(context.Background(), nodeName, network.LogQuery{})
+	assert.ErrorIs(err, network.ErrStopped)
+}
+
+// TestOutputPrinter checks that redirected lines are filtered by level
+// and chain, and printed as colored text or as JSON
+func TestOutputPrinter(t *testing.T) {
+	assert := assert.New(t)
+	output := "INFO [01-02|15:04:05] <C Chain> vms/vm.go#1: block accepted\n" +
+		"WARN [01-02|15:04:05] <P Chain> vms/vm.go#2: peer benched\n" +
+		"ERROR[01-02|15:04:05] <C Chain> vms/vm.go#3: bad block\n" +
+		"panic: oops\n" +
+		"no newline"
+	config := NodeConfig{RedirectLevel: "WARN", RedirectChains: []string{"C Chain"}}
+	buf := &bytes.Buffer{}
+	printAll := func(printer *outputPrinter) {
+		printer.start()
+		for _, text := range strings.Split(output, "\n") {
+			printer.printLine(time.Now(), text)
+		}
+		assert.NoError(printer.Close())
+		<-printer.doneCh
+	}
+	printAll(newOutputPrinter("node0", network.StdoutStream, logging.Red, config, buf))
+	assert.Equal(
+		logging.Red.Wrap("[node0] ERROR[01-02|15:04:05] <C Chain> vms/vm.go#3: bad block\n")+
+			logging.Red.Wrap("[node0] panic: oops\n")+
+			logging.Red.Wrap("[node0] no newline\n"),
+		buf.String(),
+	)
+
+	config.RedirectJSON = true
+	buf.Reset()
+	printAll(newOutputPrinter("node0", network.StderrStream, logging.Red, config, buf))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(lines, 3)
+	parsed := map[string]interface{}{}
+	assert.NoError(json.Unmarshal([]byte(lines[0]), &parsed))
+	assert.Equal("node0", parsed["node"])
+	assert.Equal(network.StderrStream, parsed["stream"])
+	assert.Equal("ERROR", parsed["level"])
+	assert.Equal("C Chain", parsed["chain"])
+	assert.Equal("vms/vm.go#3", parsed["module"])
+	assert.Equal("bad block", parsed["message"])
+	assert.Contains(parsed, "time")
+	assert.Contains(parsed, "readAt")
+	parsed = map[string]interface{}{}
+	assert.NoError(json.Unmarshal([]byte(lines[1]), &parsed))
+	assert.Equal("panic: oops", parsed["text"])
+	assert.NotContains(parsed, "level")
+	assert.NotContains(parsed, "time")
+
+	// Invalid redirect level
+	npc := &nodeProcessCreator{
+		stdout:      &bytes.Buffer{},
+		stderr:      &bytes.Buffer{},
+		colorPicker: utils.NewColorPicker(),
+	}
+	_, err := npc.NewNodeProcess(node.Config{
+		ImplSpecificConfig: json.RawMessage(`{"binaryPath":"sh","redirectStdout":true,"redirectLevel":"LOUD"}`),
+		Name:               "node0",
+	}, nil, nil)
+	assert.Error(err)
+
+	// Lines are dropped rather than waiting for a slow writer
+	writer := &blockingWriter{unblockCh: make(chan struct{})}
+	printer := newOutputPrinter("node0", network.StdoutStream, logging.Red, NodeConfig{}, writer)
+	printer.start()
+	numLines := 2 * outputQueueLines
+	for i := 0; i < numLines; i++ {
+		printer.printLine(time.Now(), strconv.Itoa(i))
+	}
+	close(writer.unblockCh)
+	assert.Eventually(func() bool { return len(printer.queue) == 0 }, 5*time.Second, time.Millisecond)
+	// The number of lines dropped is printed before the next line
+	printer.printLine(time.Now(), "last")
+	assert.NoError(printer.Close())
+	<-printer.doneCh
+	printed := writer.String()
+	// The writer may have taken a line off the queue before it blocked
+	assert.Regexp(regexp.MustCompile(`\[node0\] \(\d+ lines dropped because they were printed too slowly\)\n`), printed)
+	assert.Contains(printed, "[node0] last\n")
+	assert.Less(strings.Count(printed, "\n"), numLines)
+}
+
+// blockingWriter is an io.Writer whose
+// writes block until [unblockCh] is closed
+type blockingWriter struct {
+	bytes.Buffer
+	unblockCh chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.unblockCh
+	return w.Buffer.Write(p)
+}
+
+// TestNodeLogsRingBuffer checks that only the last
+// [nodeLogBufferLines] lines of a node are kept
+func TestNodeLogsRingBuffer(t *testing.T) {
+	assert := assert.New(t)
+	logs := newNodeLogs()
+	for i := 0; i < nodeLogBufferLines+10; i++ {
+		logs.add(network.LogLine{Text: strconv.Itoa(i)}, time.Time{})
+	}
+	lines := logs.get(network.LogQuery{})
+	assert.Len(lines, nodeLogBufferLines)
+	assert.Equal("10", lines[0].Text)
+	assert.Equal(strconv.Itoa(nodeLogBufferLines+9), lines[len(lines)-1].Text)
+	lines = logs.get(network.LogQuery{Tail: 2})
+	assert.Equal([]string{strconv.Itoa(nodeLogBufferLines + 8), strconv.Itoa(nodeLogBufferLines + 9)}, []string{lines[0].Text, lines[1].Text})
+
+	// Lines added before the ones seen aren't returned again
+	lines, numAdded, _ := logs.linesAfter(0)
+	assert.Len(lines, nodeLogBufferLines)
+	assert.EqualValues(nodeLogBufferLines+10, numAdded)
+	lines, _, addedCh := logs.linesAfter(numAdded - 1)
+	assert.Len(lines, 1)
+	assert.Equal(strconv.Itoa(nodeLogBufferLines+9), lines[0].Text)
+	logs.add(network.LogLine{Text: "new"}, time.Time{})
+	select {
+	case <-addedCh:
+	default:
+		assert.Fail("channel wasn't closed when a line was added")
+	}
+	lines, _, _ = logs.linesAfter(numAdded)
+	assert.Len(lines, 1)
+	assert.Equal("new", lines[0].Text)
+}
+
+// TestWaitForLog checks that waiting for a line returns lines that the
+// node's current process wrote before and after the wait started
+func TestWaitForLog(t *testing.T) {
+	assert := assert.New(t)
+	binaryPath := filepath.Join(t.TempDir(), "avalanchego")
+	script := "#!/bin/sh\n" +
+		"trap 'kill $!; exit 0' TERM\n" +
+		"echo 'INFO [01-02|15:04:05] bootstrapping finished'\n" +
+		"sleep 0.5\n" +
+		"echo \"INFO [01-02|15:04:05] <C Chain> block accepted by $$\" >&2\n" +
+		"sleep 60 &\n" +
+		"wait\n"
+	assert.NoError(os.WriteFile(binaryPath, []byte(script), 0o755))
+	networkConfig := testNetworkConfig(t)
+	networkConfig.NodeConfigs = networkConfig.NodeConfigs[:2]
+	for i := range networkConfig.NodeConfigs {
+		networkConfig.NodeConfigs[i].ImplSpecificConfig = utils.NewLocalNodeConfigJsonRaw(binaryPath)
+	}
+	nodeName := networkConfig.NodeConfigs[0].Name
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &nodeProcessCreator{
+		stdout:      &bytes.Buffer{},
+		stderr:      &bytes.Buffer{},
+		colorPicker: utils.NewColorPicker(),
+	}, "")
+	assert.NoError(err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	line, err := net.WaitForLog(ctx, nodeName, regexp.MustCompile("block accepted by [0-9]+$"))
+	assert.NoError(err)
+	assert.Equal(network.StderrStream, line.Stream)
+	assert.Equal("C Chain", line.Chain)
+	previousText := line.Text
+	// Written before the wait started
+	line, err = net.WaitForLog(ctx, nodeName, regexp.MustCompile("bootstrapping"))
+	assert.NoError(err)
+	assert.Equal("INFO [01-02|15:04:05] bootstrapping finished", line.Text)
+
+	lines, err := net.WaitForNetworkLog(ctx, regexp.MustCompile("block accepted"), true)
+	assert.NoError(err)
+	assert.Len(lines, 2)
+	lines, err = net.WaitForNetworkLog(ctx, regexp.MustCompile("block accepted"), false)
+	assert.NoError(err)
+	assert.Len(lines, 1)
+
+	// Lines of the node's previous process don't match
+	assert.NoError(net.RestartNode(ctx, nodeName, nil))
+	line, err = net.WaitForLog(ctx, nodeName, regexp.MustCompile("block accepted"))
+	assert.NoError(err)
+	assert.NotEqual(previousText, line.Text)
+
+	shortCtx, shortCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer shortCancel()
+	_, err = net.WaitForLog(shortCtx, nodeName, regexp.MustCompile("never written"))
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	_, err = net.WaitForNetworkLog(shortCtx, regexp.MustCompile("never written"), false)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	_, err = net.WaitForLog(ctx, "not a node", regexp.MustCompile("."))
+	assert.Error(err)
+
+	// Waiting stops when the network does
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := net.WaitForLog(ctx, nodeName, regexp.MustCompile("never written"))
+		errCh <- err
+	}()
+	assert.NoError(net.Stop(ctx))
+	assert.ErrorIs(<-errCh, network.ErrStopped)
+	_, err = net.WaitForLog(ctx, nodeName, regexp.MustCompile("."))
+	assert.ErrorIs(err, network.ErrStopped)
+}
+
+// TestPortAllocator checks that a port is only given out if it's
+// free and isn't assigned, until it's released
+func TestPortAllocator(t *testing.T) {
+	assert := assert.New(t)
+	ports := newPortAllocator(network.PortRange{})
+	assigned := map[uint16]struct{}{}
+	for i := 0; i < 10; i++ {
+		port, err := ports.allocate()
+		assert.NoError(err)
+		assert.GreaterOrEqual(port, uint16(defaultMinPort))
+		assert.NotContains(assigned, port)
+		assigned[port] = struct{}{}
+		assert.Error(ports.reserve(port))
+	}
+
+	// A range of one port that another process is bound to
+	l, err := gonet.Listen("tcp", ":0")
+	assert.NoError(err)
+	port := uint16(l.Addr().(*gonet.TCPAddr).Port)
+	ports = newPortAllocator(network.PortRange{Min: port, Max: port})
+	_, err = ports.allocate()
+	assert.Error(err)
+	assert.NoError(l.Close())
+	allocated, err := ports.allocate()
+	assert.NoError(err)
+	assert.Equal(port, allocated)
+	_, err = ports.allocate()
+	assert.Error(err)
+	assert.False(ports.isAvailable(port))
+	ports.release(port)
+	assert.True(ports.isAvailable(port))
+	allocated, err = ports.allocate()
+	assert.NoError(err)
+	assert.Equal(port, allocated)
+}
+
+// TestPortRange checks that nodes are given distinct ports from the
+// network's port range, and that a removed node's ports are released
+func TestPortRange(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	networkConfig.Ports = network.PortRange{Min: 20000, Max: 30000}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
+	assert.NoError(err)
+	nodes, err := net.GetAllNodes()
+	assert.NoError(err)
+	ports := map[uint16]string{}
+	for name, node := range nodes {
+		for _, port := range []uint16{node.GetAPIPort(), node.GetP2PPort()} {
+			assert.GreaterOrEqual(port, uint16(20000))
+			assert.LessOrEqual(port, uint16(30000))
+			assert.NotContains(ports, port)
+			ports[port] = name
+		}
+	}
+
+	// A node can't be given a port another node has
+	removed := networkConfig.NodeConfigs[0]
+	nodeConfig := networkConfig.NodeConfigs[1]
+	nodeConfig.Name = "new-node"
+	nodeConfig.Flags = map[string]interface{}{config.HTTPPortKey: int(nodes[removed.Name].GetAPIPort())}
+	_, err = net.AddNode(nodeConfig)
+	assert.Error(err)
+	assert.NoError(net.RemoveNode(removed.Name))
+	_, err = net.AddNode(nodeConfig)
+	assert.NoError(err)
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestRetryWithNewPorts checks that a node whose process fails to bind
+// its ports is started again with new ones, at most [maxPortRetries] times
+func TestRetryWithNewPorts(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	binaryPath := filepath.Join(dir, "avalanchego")
+	attemptsPath := filepath.Join(dir, "attempts")
+	// Fails to bind its ports the first [failures] times it's run
+	script := "#!/bin/sh\n" +
+		"trap 'kill $!; exit 0' TERM\n" +
+		"echo \"$@\" >> " + attemptsPath + "\n" +
+		"if [ $(wc -l < " + attemptsPath + ") -le $(cat " + filepath.Join(dir, "failures") + ") ]; then\n" +
+		"  echo 'FATAL[01-02|15:04:05] listen tcp 127.0.0.1:9651: bind: address already in use'\n" +
+		"  exit 1\n" +
+		"fi\n" +
+		"sleep 60 &\n" +
+		"wait\n"
+	assert.NoError(os.WriteFile(binaryPath, []byte(script), 0o755))
+	// The API port of each attempt to run the node
+	readAttemptAPIPorts := func() ([]string, error) {
+		attempts, err := os.ReadFile(attemptsPath)
+		if err != nil {
+			return nil, err
+		}
+		apiPorts := []string{}
+		for _, match := range regexp.MustCompile(`--http-port=(\d+)`).FindAllStringSubmatch(string(attempts), -1) {
+			apiPorts = append(apiPorts, match[1])
+		}
+		return apiPorts, nil
+	}
+	attemptAPIPorts := func() []string {
+		apiPorts, err := readAttemptAPIPorts()
+		assert.NoError(err)
+		return apiPorts
+	}
+	// A node is only healthy once it's run with ports it doesn't fail to bind
+	newAPIClientF := func(failures int) api.NewAPIClientF {
+		return func(ipAddr string, port uint16) api.Client {
+			healthClient := &apimocks.HealthClient{}
+			healthClient.On("Health", mock.Anything).Return(func(context.Context) *health.APIHealthReply {
+				apiPorts, _ := readAttemptAPIPorts()
+				healthy := len(apiPorts) > failures && apiPorts[len(apiPorts)-1] == strconv.Itoa(int(port))
+				return &health.APIHealthReply{Healthy: healthy}
+			}, nil)
+			ethClient := &apimocks.EthClient{}
+			ethClient.On("Close").Return()
+			client := &apimocks.Client{}
+			client.On("HealthAPI").Return(healthClient)
+			client.On("CChainEthAPI").Return(ethClient)
+			return client
+		}
+	}
+	newTestNetwork := func(failures int) Network {
+		assert.NoError(os.WriteFile(filepath.Join(dir, "failures"), []byte(strconv.Itoa(failures)), 0o644))
+		assert.NoError(os.RemoveAll(attemptsPath))
+		networkConfig := testNetworkConfig(t)
+		networkConfig.NodeConfigs = networkConfig.NodeConfigs[:1]
+		networkConfig.NodeConfigs[0].ImplSpecificConfig = utils.NewLocalNodeConfigJsonRaw(binaryPath)
+		net, err := newNetwork(logging.NoLog{}, networkConfig, newAPIClientF(failures), &nodeProcessCreator{
+			stdout:      &bytes.Buffer{},
+			stderr:      &bytes.Buffer{},
+			colorPicker: utils.NewColorPicker(),
+		}, "")
+		assert.NoError(err)
+		net.(*localNetwork).healthCheckFreq = testHealthCheckFreq
+		return net
+	}
+
+	net := newTestNetwork(2)
+	// Healthy waits for the node to be started again
+	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
+	apiPorts := attemptAPIPorts()
+	assert.Len(apiPorts, 3)
+	assert.NotEqual(apiPorts[0], apiPorts[1])
+	assert.NotEqual(apiPorts[1], apiPorts[2])
+	nodes, err := net.GetAllNodes()
+	assert.NoError(err)
+	for _, node := range nodes {
+		assert.Equal(apiPorts[2], strconv.Itoa(int(node.GetAPIPort())))
+	}
+	assert.NoError(net.Stop(context.Background()))
+
+	net = newTestNetwork(maxPortRetries + 1)
+	err = awaitNetworkHealthy(net, defaultHealthyTimeout)
+	assert.Error(err)
+	assert.Contains(err.Error(), "exited unexpectedly")
+	assert.Len(attemptAPIPorts(), maxPortRetries+1)
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestHealthReport checks that the health report has
+// every node's health, including paused nodes
+func TestHealthReport(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
+	assert.NoError(err)
+	pausedNodeName := networkConfig.NodeConfigs[0].Name
+	assert.NoError(net.PauseNode(pausedNodeName))
+
+	report, err := net.HealthReport(context.Background())
+	assert.NoError(err)
+	assert.Len(report, len(networkConfig.NodeConfigs))
+	for name, nodeHealth := range report {
+		if name == pausedNodeName {
+			assert.False(nodeHealth.Healthy)
+			assert.Nil(nodeHealth.Reply)
+			assert.Contains(nodeHealth.LastError, ErrNodePaused.Error())
+			continue
+		}
+		assert.True(nodeHealth.Healthy)
+		assert.NotNil(nodeHealth.Reply)
+		assert.Empty(nodeHealth.LastError)
+	}
+
+	assert.NoError(net.Stop(context.Background()))
+	_, err = net.HealthReport(context.Background())
+	assert.EqualValues(network.ErrStopped, err)
+}
+
+// TestDescribe checks that the network's description
+// has every node and marshals to JSON
+func TestDescribe(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	networkConfig.Flags = map[string]interface{}{
+		"network-flag": "value",
+	}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
+	assert.NoError(err)
+	pausedName := networkConfig.NodeConfigs[1].Name
+	assert.NoError(net.PauseNode(pausedName))
+
+	info, err := net.Describe()
+	assert.NoError(err)
+	networkID, err := utils.NetworkIDFromGenesis([]byte(networkConfig.Genesis))
+	assert.NoError(err)
+	assert.EqualValues(networkID, info.NetworkID)
+	assert.EqualValues(network.GenesisHash([]byte(networkConfig.Genesis)), info.GenesisHash)
+	assert.Len(info.Nodes, len(networkConfig.NodeConfigs))
+	beaconName := networkConfig.NodeConfigs[0].Name
+	beacon, err := net.GetNode(beaconName)
+	assert.NoError(err)
+	assert.EqualValues([]network.BeaconInfo{{
+		NodeName: beaconName,
+		NodeID:   beacon.GetNodeID().PrefixedString(constants.NodeIDPrefix),
+		IP:       fmt.Sprintf("127.0.0.1:%d", beacon.GetP2PPort()),
+	}}, info.Beacons)
+	for name, nodeInfo := range info.Nodes {
+		node, err := net.GetNode(name)
+		assert.NoError(err)
+		assert.EqualValues(name, nodeInfo.Name)
+		assert.EqualValues(node.GetNodeID().PrefixedString(constants.NodeIDPrefix), nodeInfo.NodeID)
+		assert.EqualValues(fmt.Sprintf("http://localhost:%d", node.GetAPIPort()), nodeInfo.URI)
+		assert.EqualValues("pepito", nodeInfo.BinaryPath)
+		assert.NotZero(nodeInfo.PID)
+		assert.DirExists(nodeInfo.RootDir)
+		assert.EqualValues("value", nodeInfo.Flags["network-flag"])
+		assert.EqualValues(fmt.Sprint(node.GetAPIPort()), nodeInfo.Flags[config.HTTPPortKey])
+		assert.EqualValues(nodeInfo.DBDir, nodeInfo.Flags[config.DBPathKey])
+		if name == pausedName {
+			assert.EqualValues(network.NodeStatusPaused, nodeInfo.Status)
+		} else {
+			assert.EqualValues(network.NodeStatusRunning, nodeInfo.Status)
+		}
+	}
+	_, err = json.Marshal(info)
+	assert.NoError(err)
+
+	assert.NoError(net.Stop(context.Background()))
+	_, err = net.Describe()
+	assert.EqualValues(network.ErrStopped, err)
+}
+
+// TestAttachNetwork checks that a network can be rebuilt from
+// the state file written to its root directory
+func TestAttachNetwork(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	rootDir := t.TempDir()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.NoError(err)
+	statePath := filepath.Join(rootDir, stateFileName)
+	assert.FileExists(statePath)
+	pausedName := networkConfig.NodeConfigs[1].Name
+	assert.NoError(net.PauseNode(pausedName))
+	info, err := net.Describe()
+	assert.NoError(err)
+
+	// The process of this node is gone
+	exitedName := networkConfig.NodeConfigs[2].Name
+	exitedPID := info.Nodes[exitedName].PID
+	attachProcess := func(pid int, _ uint64) (NodeProcess, error) {
+		if pid == exitedPID {
+			return nil, fmt.Errorf("process %d isn't running", pid)
+		}
+		return newMockProcessSuccessful(node.Config{})
+	}
+	attached, err := attachNetwork(logging.NoLog{}, rootDir, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, attachProcess)
+	assert.NoError(err)
+	attachedInfo, err := attached.Describe()
+	assert.NoError(err)
+	assert.EqualValues(info.NetworkID, attachedInfo.NetworkID)
+	assert.EqualValues(info.GenesisHash, attachedInfo.GenesisHash)
+	assert.EqualValues(info.Beacons, attachedInfo.Beacons)
+	assert.Len(attachedInfo.Nodes, len(networkConfig.NodeConfigs)-1)
+	assert.NotContains(attachedInfo.Nodes, exitedName)
+	for name, nodeInfo := range attachedInfo.Nodes {
+		originalInfo := info.Nodes[name]
+		assert.EqualValues(originalInfo.NodeID, nodeInfo.NodeID)
+		assert.EqualValues(originalInfo.APIPort, nodeInfo.APIPort)
+		assert.EqualValues(originalInfo.P2PPort, nodeInfo.P2PPort)
+		assert.EqualValues(originalInfo.DBDir, nodeInfo.DBDir)
+		assert.EqualValues(originalInfo.Flags, nodeInfo.Flags)
+	}
+	assert.EqualValues(network.NodeStatusPaused, attachedInfo.Nodes[pausedName].Status)
+	assert.NoError(attached.ResumeNode(pausedName))
+	assert.NoError(awaitNetworkHealthy(attached, defaultHealthyTimeout))
+
+	// The attached network bootstraps new nodes from the same beacons
+	// and doesn't reuse generated names
+	_, err = attached.AddNode(node.Config{
+		ImplSpecificConfig: utils.NewLocalNodeConfigJsonRaw("pepito"),
+		StakingKey:         networkConfig.NodeConfigs[2].StakingKey,
+		StakingCert:        networkConfig.NodeConfigs[2].StakingCert,
+	})
+	assert.NoError(err)
+	names, err := attached.GetNodeNames()
+	assert.NoError(err)
+	assert.Len(names, len(networkConfig.NodeConfigs))
+	assert.NoError(attached.RemoveNode(pausedName))
+
+	// The state file has the attached network's changes
+	var state networkState
+	stateBytes, err := os.ReadFile(statePath)
+	assert.NoError(err)
+	assert.NoError(json.Unmarshal(stateBytes, &state))
+	assert.Len(state.Nodes, len(networkConfig.NodeConfigs)-1)
+	for _, nodeState := range state.Nodes {
+		assert.NotEqualValues(pausedName, nodeState.Name)
+	}
+
+	// A stopped network can't be attached to
+	assert.NoError(attached.Stop(context.Background()))
+	state, err = readState(rootDir)
+	assert.NoError(err)
+	assert.True(state.Stopped)
+	_, err = attachNetwork(logging.NoLog{}, rootDir, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, attachProcess)
+	assert.Error(err)
+}
+
+// TestStartNetwork checks that a stopped network can be started again,
+// in the same process or by creating it again in the same directory
+func TestStartNetwork(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	rootDir := t.TempDir()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.NoError(err)
+	assert.ErrorIs(net.Start(context.Background()), ErrNotStopped)
+	info, err := net.Describe()
+	assert.NoError(err)
+	events, unsubscribe := net.Subscribe()
+	defer unsubscribe()
+
+	assert.NoError(net.Stop(context.Background()))
+	_, err = net.GetNodeNames()
+	assert.ErrorIs(err, network.ErrStopped)
+	state, err := readState(rootDir)
+	assert.NoError(err)
+	assert.True(state.Stopped)
+	assert.Len(state.Nodes, len(networkConfig.NodeConfigs))
+
+	// The nodes keep their ports and directories
+	assert.NoError(net.Start(context.Background()))
+	startedInfo, err := net.Describe()
+	assert.NoError(err)
+	assert.EqualValues(info.Beacons, startedInfo.Beacons)
+	assert.Len(startedInfo.Nodes, len(info.Nodes))
+	for name, nodeInfo := range info.Nodes {
+		startedNodeInfo := startedInfo.Nodes[name]
+		assert.EqualValues(nodeInfo.APIPort, startedNodeInfo.APIPort)
+		assert.EqualValues(nodeInfo.P2PPort, startedNodeInfo.P2PPort)
+		assert.EqualValues(nodeInfo.DBDir, startedNodeInfo.DBDir)
+	}
+	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
+	state, err = readState(rootDir)
+	assert.NoError(err)
+	assert.False(state.Stopped)
+	for {
+		event := nextEvent(t, events)
+		if event.Type == network.NetworkStarted {
+			break
+		}
+	}
+	assert.NoError(net.Stop(context.Background()))
+
+	// Creating the network again in its directory resumes its nodes
+	resumed, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.NoError(err)
+	resumedInfo, err := resumed.Describe()
+	assert.NoError(err)
+	for name, nodeInfo := range info.Nodes {
+		resumedNodeInfo := resumedInfo.Nodes[name]
+		assert.EqualValues(nodeInfo.APIPort, resumedNodeInfo.APIPort)
+		assert.EqualValues(nodeInfo.P2PPort, resumedNodeInfo.P2PPort)
+		assert.EqualValues(nodeInfo.DBDir, resumedNodeInfo.DBDir)
+	}
+	assert.NoError(resumed.Stop(context.Background()))
+
+	// A directory can't be reused with another genesis
+	otherConfig, err := emptyNetworkConfig()
+	assert.NoError(err)
+	otherConfig.NodeConfigs = networkConfig.NodeConfigs
+	_, err = newNetwork(logging.NoLog{}, otherConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.Error(err)
+
+	// Nor while the network in it is running
+	running, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestRunningProcessCreator{}, rootDir)
+	assert.NoError(err)
+	_, err = newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.Error(err)
+	assert.NoError(running.Stop(context.Background()))
+}
+
+// Creates processes whose PID is that of the test,
+// so that they look like they're running
+type localTestRunningProcessCreator struct{}
+
+func (*localTestRunningProcessCreator) NewNodeProcess(config node.Config, _ *os.File, _ *os.File, flags ...string) (NodeProcess, error) {
+	return newMockProcess(os.Getpid(), func() error { return nil }), nil
+}
+
+// TestCleanup checks that a network's directories are removed
+// according to its cleanup policy, except those given in config files
+func TestCleanup(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	// Directories are kept by default
+	networkConfig := testNetworkConfig(t)
+	rootDir := t.TempDir()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.NoError(err)
+	assert.ErrorIs(net.Cleanup(), ErrNotStopped)
+	assert.NoError(net.Stop(ctx))
+	assert.DirExists(filepath.Join(rootDir, networkConfig.NodeConfigs[0].Name))
+
+	// Directories given in config files are left, even in [rootDir]
+	userDBDir := filepath.Join(rootDir, "user-db")
+	userLogsDir := filepath.Join(t.TempDir(), "user-logs")
+	assert.NoError(createFileAndWrite(filepath.Join(userDBDir, "db"), []byte("db")))
+	assert.NoError(createFileAndWrite(filepath.Join(userLogsDir, "log"), []byte("log")))
+	networkConfig.NodeConfigs[1].ConfigFile = fmt.Sprintf(`{"%s":"%s","%s":"%s"}`, config.DBPathKey, userDBDir, config.LogsDirKey, userLogsDir)
+	net, err = newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.NoError(err)
+	assert.NoError(net.Stop(ctx))
+	assert.NoError(net.Cleanup())
+	entries, err := os.ReadDir(rootDir)
+	assert.NoError(err)
+	assert.Len(entries, 1)
+	assert.FileExists(filepath.Join(userDBDir, "db"))
+	assert.FileExists(filepath.Join(userLogsDir, "log"))
+	// The nodes' databases are gone
+	assert.ErrorIs(net.Start(ctx), ErrCleanedUp)
+
+	// Directories are removed on Stop
+	networkConfig = testNetworkConfig(t)
+	networkConfig.CleanupPolicy = network.CleanupAlways
+	rootDir = filepath.Join(t.TempDir(), "network")
+	net, err = newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.NoError(err)
+	assert.NoError(net.Stop(ctx))
+	assert.NoDirExists(rootDir)
+
+	// Directories are removed on Stop unless a node was unhealthy
+	networkConfig.CleanupPolicy = network.CleanupOnSuccess
+	net, err = newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.NoError(err)
+	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
+	assert.NoError(net.Stop(ctx))
+	assert.NoDirExists(rootDir)
+	net, err = newNetwork(logging.NoLog{}, networkConfig, newMockAPIUnhealthy, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.NoError(err)
+	assert.Error(awaitNetworkHealthy(net, 100*time.Millisecond))
+	assert.NoError(net.Stop(ctx))
+	assert.DirExists(rootDir)
+}
+
+// TestStopKillsHungNodes checks that nodes that don't exit after
+// a SIGTERM are killed once their grace period ends or the
+// context is done, without holding up the other nodes
+func TestStopKillsHungNodes(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	hungNodeNames := []string{networkConfig.NodeConfigs[1].Name, networkConfig.NodeConfigs[2].Name}
+	creator := &localTestHungProcessCreator{
+		hungNodeNames: map[string]struct{}{hungNodeNames[0]: {}, hungNodeNames[1]: {}},
+		processes:     make(map[string]*mocks.NodeProcess),
+	}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, creator, "")
+	assert.NoError(err)
+	gracePeriod := 500 * time.Millisecond
+	net.(*localNetwork).stopGracePeriod = gracePeriod
+
+	// The hung nodes are stopped in parallel
+	start := time.Now()
+	err = net.Stop(context.Background())
+	assert.Less(int64(time.Since(start)), int64(2*gracePeriod))
+	var killedErr *NodesKilledError
+	assert.True(errors.As(err, &killedErr))
+	assert.EqualValues(hungNodeNames, killedErr.NodeNames)
+	for nodeName, process := range creator.processes {
+		if _, ok := creator.hungNodeNames[nodeName]; ok {
+			process.AssertCalled(t, "Kill")
+		} else {
+			process.AssertNotCalled(t, "Kill")
+		}
+	}
+
+	// Hung nodes are killed once the context is done,
+	// even if their grace period hasn't ended
+	assert.NoError(net.Start(context.Background()))
+	net.(*localNetwork).stopGracePeriod = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+	err = net.Stop(ctx)
+	assert.True(errors.As(err, &killedErr))
+	assert.EqualValues(hungNodeNames, killedErr.NodeNames)
+}
+
+// Creates processes whose Start blocks until all [numNonBeacons]
+// non-beacons are starting, and fails for [failingNodeName]
+type localTestParallelProcessCreator struct {
+	numNonBeacons   int
+	failingNodeName string
+	lock            sync.Mutex
+	numStarting     int
+	allStartingCh   chan struct{}
+	// Node name --> Its process
+	processes map[string]*mocks.NodeProcess
+}
+
+func (lt *localTestParallelProcessCreator) NewNodeProcess(config node.Config, _ *os.File, _ *os.File, flags ...string) (NodeProcess, error) {
+	start := func() error { return nil }
+	if !config.IsBeacon {
+		start = func() error {
+			lt.lock.Lock()
+			lt.numStarting++
+			if lt.numStarting == lt.numNonBeacons {
+				close(lt.allStartingCh)
+			}
+			lt.lock.Unlock()
+			select {
+			case <-lt.allStartingCh:
+			case <-time.After(5 * time.Second):
+				return errors.New("non-beacons weren't started in parallel")
+			}
+			if config.Name == lt.failingNodeName {
+				return errors.New("Start failed")
+			}
+			return nil
+		}
+	}
+	process := newMockProcess(int(atomic.AddInt32(&nextMockPID, 1)), start)
+	lt.lock.Lock()
+	lt.processes[config.Name] = process
+	lt.lock.Unlock()
+	return process, nil
+}
+
+// TestParallelStartup checks that non-beacons are started in parallel,
+// and that the nodes that started are stopped if another one fails
+func TestParallelStartup(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	newCreator := func(failingNodeName string) *localTestParallelProcessCreator {
+		return &localTestParallelProcessCreator{
+			numNonBeacons:   len(networkConfig.NodeConfigs) - 1,
+			failingNodeName: failingNodeName,
+			allStartingCh:   make(chan struct{}),
+			processes:       make(map[string]*mocks.NodeProcess),
+		}
+	}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, newCreator(""), "")
+	assert.NoError(err)
+	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
+	assert.NoError(net.Stop(context.Background()))
+
+	failingNodeName := networkConfig.NodeConfigs[2].Name
+	creator := newCreator(failingNodeName)
+	_, err = newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, creator, "")
+	assert.Error(err)
+	for nodeName, process := range creator.processes {
+		if nodeName == failingNodeName {
+			process.AssertNotCalled(t, "Stop")
+		} else {
+			process.AssertCalled(t, "Stop")
+		}
+	}
+}
+
+// Returns the value of the last flag named [flagName] in [flags]
+func flagValue(flags []string, flagName string) string {
+	value := ""
+	for _, flag := range flags {
+		if strings.HasPrefix(flag, fmt.Sprintf("--%s=", flagName)) {
+			value = strings.TrimPrefix(flag, fmt.Sprintf("--%s=", flagName))
+		}
+	}
+	return value
+}
+
+// TestPartition checks that with fault injection enabled, nodes connect
+// to each other through proxies, and that traffic between nodes in
+// different groups is held until the partition is healed
+func TestPartition(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	networkConfig.FaultInjection = true
+	creator := newLocalTestFlagRecorderProcessCreator()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, creator, "")
+	assert.NoError(err)
+	localNet := net.(*localNetwork)
+
+	// Each node bootstraps from the nodes started before it
+	name0, name1, name2 := networkConfig.NodeConfigs[0].Name, networkConfig.NodeConfigs[1].Name, networkConfig.NodeConfigs[2].Name
+	node0 := localNet.nodes[name0]
+	assert.Empty(flagValue(creator.flags[name0], config.BootstrapIPsKey))
+	assert.Len(strings.Split(flagValue(creator.flags[name2], config.BootstrapIPsKey), ","), 2)
+	assert.Equal(proxiedPublicIP, flagValue(creator.flags[name2], config.PublicIPKey))
+	assert.Equal(proxiedPingTimeout.String(), flagValue(creator.flags[name2], config.NetworkPingTimeoutKey))
+	proxyAddr := flagValue(creator.flags[name1], config.BootstrapIPsKey)
+	assert.NotEqual(fmt.Sprintf("127.0.0.1:%d", node0.p2pPort), proxyAddr)
+	assert.Equal(node0.nodeID.PrefixedString(constants.NodeIDPrefix), flagValue(creator.flags[name1], config.BootstrapIDsKey))
+
+	// Pretend to be node0 and connect to it through node1's proxy
+	listener, err := gonet.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", node0.p2pPort))
+	assert.NoError(err)
+	defer listener.Close()
+	conn1, err := gonet.Dial("tcp", proxyAddr)
+	assert.NoError(err)
+	defer conn1.Close()
+	conn0, err := listener.Accept()
+	assert.NoError(err)
+	defer conn0.Close()
+	buf := make([]byte, 1)
+	_, err = conn1.Write([]byte("a"))
+	assert.NoError(err)
+	_, err = io.ReadFull(conn0, buf)
+	assert.NoError(err)
+	assert.Equal("a", string(buf))
+
+	// node1 and node2 are in the same group
+	assert.NoError(net.Partition([]string{name0}))
+	_, err = conn1.Write([]byte("b"))
+	assert.NoError(err)
+	assert.NoError(conn0.SetReadDeadline(time.Now().Add(200 * time.Millisecond)))
+	_, err = conn0.Read(buf)
+	assert.True(errors.Is(err, os.ErrDeadlineExceeded))
+
+	// The held traffic is delivered once the partition is healed
+	assert.NoError(net.Heal())
+	assert.NoError(conn0.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	_, err = io.ReadFull(conn0, buf)
+	assert.NoError(err)
+	assert.Equal("b", string(buf))
+
+	assert.Error(net.Partition([]string{name0}, []string{name0, name1}))
+	assert.Error(net.Partition([]string{"not a node"}))
+	// Links within a group aren't partitioned
+	assert.NoError(net.Partition([]string{name0, name1}, []string{name2}))
+	_, err = conn0.Write([]byte("c"))
+	assert.NoError(err)
+	assert.NoError(conn1.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	_, err = io.ReadFull(conn1, buf)
+	assert.NoError(err)
+	assert.Equal("c", string(buf))
+
+	// Stopping the network closes the proxies and their connections
+	assert.NoError(net.Stop(context.Background()))
+	_, err = io.ReadFull(conn1, buf)
+	assert.Error(err)
+	assert.EqualValues(network.ErrStopped, net.Partition([]string{name0}))
+	assert.EqualValues(network.ErrStopped, net.Heal())
+
+	// Fault injection isn't enabled by default
+	networkConfig.FaultInjection = false
+	net, err = newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, newLocalTestFlagRecorderProcessCreator(), "")
+	assert.NoError(err)
+	assert.EqualValues(ErrNoFaultInjection, net.Partition([]string{name0}))
+	assert.EqualValues(ErrNoFaultInjection, net.Heal())
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestLinkConditions checks that traffic between nodes
+// is shaped by the conditions of the link it's sent over
+func TestLinkConditions(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	networkConfig.FaultInjection = true
+	creator := newLocalTestFlagRecorderProcessCreator()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, creator, "")
+	assert.NoError(err)
+	name0, name1 := networkConfig.NodeConfigs[0].Name, networkConfig.NodeConfigs[1].Name
+	node0 := net.(*localNetwork).nodes[name0]
+
+	// Pretend to be node0 and connect to it through node1's proxy
+	listener, err := gonet.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", node0.p2pPort))
+	assert.NoError(err)
+	defer listener.Close()
+	conn1, err := gonet.Dial("tcp", flagValue(creator.flags[name1], config.BootstrapIPsKey))
+	assert.NoError(err)
+	defer conn1.Close()
+	conn0, err := listener.Accept()
+	assert.NoError(err)
+	defer conn0.Close()
+	// Returns how long it takes to send [numBytes] over [from] to [to]
+	timeSend := func(from gonet.Conn, to gonet.Conn, numBytes int) time.Duration {
+		start := time.Now()
+		go func() {
+			_, _ = from.Write(make([]byte, numBytes))
+		}()
+		_, err := io.ReadFull(to, make([]byte, numBytes))
+		assert.NoError(err)
+		return time.Since(start)
+	}
+
+	latency := 300 * time.Millisecond
+	assert.NoError(net.SetLinkConditions(name1, name0, LinkConditions{Latency: latency}))
+	assert.GreaterOrEqual(timeSend(conn1, conn0, 1), latency)
+	assert.NoError(net.SetLinkConditions(name1, name0, LinkConditions{BandwidthBps: 10_000}))
+	assert.GreaterOrEqual(timeSend(conn1, conn0, 5_000), 400*time.Millisecond)
+	// The default conditions apply to the links that have none
+	assert.NoError(net.SetDefaultLinkConditions(LinkConditions{Latency: latency}))
+	assert.GreaterOrEqual(timeSend(conn0, conn1, 1), latency)
+	assert.NoError(net.ClearLinkConditions(name1, name0))
+	assert.GreaterOrEqual(timeSend(conn1, conn0, 1), latency)
+
+	assert.Error(net.SetLinkConditions(name0, name0, LinkConditions{}))
+	assert.Error(net.SetLinkConditions(name0, "not a node", LinkConditions{}))
+	assert.Error(net.SetLinkConditions(name0, name1, LinkConditions{DropRate: 2}))
+	assert.Error(net.SetDefaultLinkConditions(LinkConditions{Latency: -time.Second}))
+	assert.Error(net.ClearLinkConditions(name0, "not a node"))
+
+	assert.NoError(net.Stop(context.Background()))
+	assert.EqualValues(network.ErrStopped, net.SetLinkConditions(name0, name1, LinkConditions{}))
+	assert.EqualValues(network.ErrStopped, net.ClearLinkConditions(name0, name1))
+	assert.EqualValues(network.ErrStopped, net.SetDefaultLinkConditions(LinkConditions{}))
+}
+
+// TestLinkConditionsDelay checks the delay of
+// traffic with jitter and dropped traffic
+func TestLinkConditionsDelay(t *testing.T) {
+	assert := assert.New(t)
+	conditions := LinkConditions{Latency: time.Second, Jitter: 100 * time.Millisecond}
+	for i := 0; i < 100; i++ {
+		delay := conditions.delay()
+		assert.GreaterOrEqual(delay, 900*time.Millisecond)
+		assert.LessOrEqual(delay, 1100*time.Millisecond)
+	}
+	conditions = LinkConditions{Latency: time.Second, DropRate: 1}
+	assert.Equal(time.Second+retransmissionTimeout, conditions.delay())
+	// Jitter doesn't make the delay negative
+	conditions = LinkConditions{Jitter: time.Second}
+	for i := 0; i < 100; i++ {
+		assert.GreaterOrEqual(conditions.delay(), time.Duration(0))
+	}
+	assert.Equal(500*time.Millisecond, LinkConditions{BandwidthBps: 2_000}.transmissionTime(1_000))
+	assert.Zero(LinkConditions{}.transmissionTime(1_000))
+}
+
+// TestWatchHealth checks that WatchHealth reports a node
+// becoming unhealthy and then healthy again
+func TestWatchHealth(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	networkConfig.NodeConfigs = networkConfig.NodeConfigs[:1]
+	nodeName := networkConfig.NodeConfigs[0].Name
+	var healthy int32 = 1
+	newAPIClientF := func(string, uint16) api.Client {
+		healthClient := &apimocks.HealthClient{}
+		healthClient.On("Health", mock.Anything).Return(
+			func(context.Context) *health.APIHealthReply {
+				return &health.APIHealthReply{Healthy: atomic.LoadInt32(&healthy) == 1}
+			},
+			nil,
+		)
+		ethClient := &apimocks.EthClient{}
+		ethClient.On("Close").Return()
+		client := &apimocks.Client{}
+		client.On("HealthAPI").Return(healthClient)
+		client.On("CChainEthAPI").Return(ethClient)
+		return client
+	}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newAPIClientF, &localTestSuccessfulNodeProcessCreator{}, "")
+	assert.NoError(err)
+	events, unsubscribe := net.Subscribe()
+	defer unsubscribe()
+
+	_, err = net.WatchHealth(context.Background(), 0)
+	assert.Error(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	transitions, err := net.WatchHealth(ctx, 10*time.Millisecond)
+	assert.NoError(err)
+
+	nextTransition := func() network.HealthTransition {
+		select {
+		case transition := <-transitions:
+			return transition
+		case <-time.After(time.Second):
+			assert.FailNow("didn't get transition")
+			return network.HealthTransition{}
+		}
+	}
+	// Initial health
+	transition := nextTransition()
+	assert.EqualValues(nodeName, transition.NodeName)
+	assert.True(transition.Healthy)
+	assert.EqualValues(network.NodeHealthy, nextEvent(t, events).Type)
+
+	atomic.StoreInt32(&healthy, 0)
+	transition = nextTransition()
+	assert.False(transition.Healthy)
+	assert.NotEmpty(transition.Health.LastError)
+	event := nextEvent(t, events)
+	assert.EqualValues(network.NodeUnhealthy, event.Type)
+	assert.EqualValues(nodeName, event.NodeName)
+
+	atomic.StoreInt32(&healthy, 1)
+	transition = nextTransition()
+	assert.True(transition.Healthy)
+
+	// The channel is closed when the network stops
+	assert.NoError(net.Stop(context.Background()))
+	for range transitions {
+	}
+	_, err = net.WatchHealth(ctx, time.Second)
+	assert.EqualValues(network.ErrStopped, err)
+}
+
+// TestCreateSubnet checks that the subnet's validators are
+// added and restarted with the subnet whitelisted
+func TestCreateSubnet(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	networkConfig.FundedPrivateKey = "PrivateKey-ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN"
+	subnetID := ids.GenerateTestID()
+	keystoreClient := &apimocks.KeystoreClient{}
+	keystoreClient.On("CreateUser", mock.Anything, mock.Anything).Return(true, nil)
+	keystoreClient.On("DeleteUser", mock.Anything, mock.Anything).Return(true, nil)
+	pChainClient := &apimocks.PChainClient{}
+	pChainClient.On("ImportKey", mock.Anything, mock.Anything, networkConfig.FundedPrivateKey).Return("P-custom1address", nil)
+	pChainClient.On("CreateSubnet", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(subnetID, nil)
+	pChainClient.On("GetTxStatus", mock.Anything, mock.Anything, true).Return(&platformvm.GetTxStatusResponse{Status: status.Committed}, nil)
+	// Every node is a primary network validator
+	pChainClient.On("GetCurrentValidators", mock.Anything, constants.PrimaryNetworkID, mock.Anything).Return(
+		func(_ context.Context, _ ids.ID, nodeIDs []ids.ShortID) []interface{} {
+			validators := make([]interface{}, len(nodeIDs))
+			for i, nodeID := range nodeIDs {
+				validators[i] = map[string]interface{}{
+					"nodeID":  nodeID.PrefixedString(constants.NodeIDPrefix),
+					"endTime": "12345",
+				}
+			}
+			return validators
+		},
+		nil,
+	)
+	pChainClient.On("AddSubnetValidator", mock.Anything, mock.Anything, mock.Anything, mock.Anything, subnetID.String(), mock.Anything, uint64(network.SubnetValidatorWeight), mock.Anything, uint64(12345)).Return(ids.GenerateTestID(), nil)
+	newAPIClientF := func(ipAddr string, port uint16) api.Client {
+		client := newMockAPISuccessful(ipAddr, port).(*apimocks.Client)
+		client.On("KeystoreAPI").Return(keystoreClient)
+		client.On("PChainAPI").Return(pChainClient)
+		return client
+	}
+	creator := newLocalTestFlagRecorderProcessCreator()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newAPIClientF, creator, "")
+	assert.NoError(err)
+
+	validatorName := networkConfig.NodeConfigs[1].Name
+	otherName := networkConfig.NodeConfigs[2].Name
+	validator, err := net.GetNode(validatorName)
+	assert.NoError(err)
+	gotSubnetID, err := net.CreateSubnet(context.Background(), nil, 0, []string{validatorName})
+	assert.NoError(err)
+	assert.EqualValues(subnetID, gotSubnetID)
+	pChainClient.AssertCalled(t, "AddSubnetValidator", mock.Anything, mock.Anything, mock.Anything, mock.Anything, subnetID.String(), validator.GetNodeID().PrefixedString(constants.NodeIDPrefix), uint64(network.SubnetValidatorWeight), mock.Anything, uint64(12345))
+	whitelistFlag := fmt.Sprintf("--%s=%s", config.WhitelistedSubnetsKey, subnetID)
+	assert.Contains(creator.flags[validatorName], whitelistFlag)
+	assert.NotContains(creator.flags[otherName], whitelistFlag)
+	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
+
+	// Unknown validator
+	_, err = net.CreateSubnet(context.Background(), nil, 0, []string{"not a node"})
+	assert.Error(err)
+
+	assert.NoError(net.Stop(context.Background()))
+	_, err = net.CreateSubnet(context.Background(), nil, 0, []string{validatorName})
+	assert.EqualValues(network.ErrStopped, err)
+}
+
+// TestAddValidator checks that nodes can start validating
+// and be delegated to while the network runs
+func TestAddValidator(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	networkConfig.FundedPrivateKey = "PrivateKey-ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN"
+	validatorTxID := ids.GenerateTestID()
+	delegatorTxID := ids.GenerateTestID()
+	keystoreClient := &apimocks.KeystoreClient{}
+	keystoreClient.On("CreateUser", mock.Anything, mock.Anything).Return(true, nil)
+	keystoreClient.On("DeleteUser", mock.Anything, mock.Anything).Return(true, nil)
+	pChainClient := &apimocks.PChainClient{}
+	pChainClient.On("ImportKey", mock.Anything, mock.Anything, networkConfig.FundedPrivateKey).Return("P-custom1address", nil)
+	pChainClient.On("GetTxStatus", mock.Anything, mock.Anything, true).Return(&platformvm.GetTxStatusResponse{Status: status.Committed}, nil)
+	pChainClient.On("GetCurrentValidators", mock.Anything, constants.PrimaryNetworkID, mock.Anything).Return(
+		func(_ context.Context, _ ids.ID, nodeIDs []ids.ShortID) []interface{} {
+			return []interface{}{
+				map[string]interface{}{
+					"txID":   validatorTxID.String(),
+					"nodeID": nodeIDs[0].PrefixedString(constants.NodeIDPrefix),
+					"delegators": []interface{}{
+						map[string]interface{}{"txID": delegatorTxID.String()},
+					},
+				},
+			}
+		},
+		nil,
+	)
+	pChainClient.On("AddValidator", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "P-custom1address", mock.Anything, uint64(2000), mock.Anything, mock.Anything, mock.Anything).Return(validatorTxID, nil)
+	pChainClient.On("AddDelegator", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "P-custom1reward", mock.Anything, uint64(1000), mock.Anything, mock.Anything).Return(delegatorTxID, nil)
+	newAPIClientF := func(ipAddr string, port uint16) api.Client {
+		client := newMockAPISuccessful(ipAddr, port).(*apimocks.Client)
+		client.On("KeystoreAPI").Return(keystoreClient)
+		client.On("PChainAPI").Return(pChainClient)
+		return client
+	}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newAPIClientF, &localTestSuccessfulNodeProcessCreator{}, "")
+	assert.NoError(err)
+
+	// A node added after the network started
+	newNodeConfig := testNetworkConfig(t).NodeConfigs[1]
+	newNodeConfig.Name = "new node"
+	newNode, err := net.AddNode(newNodeConfig)
+	assert.NoError(err)
+	end := time.Now().Add(24 * time.Hour)
+	txID, err := net.AddValidator(context.Background(), newNode.GetName(), 2000, time.Time{}, end, "")
+	assert.NoError(err)
+	assert.EqualValues(validatorTxID, txID)
+	pChainClient.AssertCalled(t, "AddValidator", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "P-custom1address", newNode.GetNodeID().PrefixedString(constants.NodeIDPrefix), uint64(2000), mock.Anything, uint64(end.Unix()), mock.Anything)
+
+	txID, err = net.AddDelegator(context.Background(), newNode.GetName(), 1000, time.Time{}, end, "P-custom1reward")
+	assert.NoError(err)
+	assert.EqualValues(delegatorTxID, txID)
+
+	// Unknown node
+	_, err = net.AddValidator(context.Background(), "not a node", 2000, time.Time{}, end, "")
+	assert.Error(err)
+
+	assert.NoError(net.Stop(context.Background()))
+	_, err = net.AddValidator(context.Background(), newNode.GetName(), 2000, time.Time{}, end, "")
+	assert.EqualValues(network.ErrStopped, err)
+	_, err = net.AddDelegator(context.Background(), newNode.GetName(), 1000, time.Time{}, end, "")
+	assert.EqualValues(network.ErrStopped, err)
+}
+
+// TestCreateBlockchain checks that the VM plugin is installed on the
+// subnet's validators, which are restarted to load it
+func TestCreateBlockchain(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	networkConfig.FundedPrivateKey = "PrivateKey-ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN"
+	buildDir := t.TempDir()
+	assert.NoError(os.Mkdir(filepath.Join(buildDir, pluginsDirName), 0o755))
+	assert.NoError(os.WriteFile(filepath.Join(buildDir, pluginsDirName, "evm"), []byte("evm"), 0o755))
+	networkConfig.Flags = map[string]interface{}{
+		config.BuildDirKey: buildDir,
+	}
+	pluginPath := filepath.Join(t.TempDir(), "plugin")
+	assert.NoError(os.WriteFile(pluginPath, []byte("plugin"), 0o755))
+
+	subnetID := ids.GenerateTestID()
+	vmID := ids.GenerateTestID()
+	chainID := ids.GenerateTestID()
+	genesis := []byte("genesis")
+	// Node ID --> Whether it validates the subnet
+	var validatorIDs sync.Map
+	keystoreClient := &apimocks.KeystoreClient{}
+	keystoreClient.On("CreateUser", mock.Anything, mock.Anything).Return(true, nil)
+	keystoreClient.On("DeleteUser", mock.Anything, mock.Anything).Return(true, nil)
+	pChainClient := &apimocks.PChainClient{}
+	pChainClient.On("ImportKey", mock.Anything, mock.Anything, networkConfig.FundedPrivateKey).Return("P-custom1address", nil)
+	pChainClient.On("GetTxStatus", mock.Anything, mock.Anything, true).Return(&platformvm.GetTxStatusResponse{Status: status.Committed}, nil)
+	pChainClient.On("GetCurrentValidators", mock.Anything, subnetID, mock.Anything).Return(
+		func(context.Context, ids.ID, []ids.ShortID) []interface{} {
+			validators := []interface{}{}
+			validatorIDs.Range(func(nodeID, _ interface{}) bool {
+				validators = append(validators, map[string]interface{}{
+					"nodeID": nodeID.(ids.ShortID).PrefixedString(constants.NodeIDPrefix),
+				})
+				return true
+			})
+			return validators
+		},
+		nil,
+	)
+	pChainClient.On("GetPendingValidators", mock.Anything, subnetID, mock.Anything).Return([]interface{}{}, []interface{}{}, nil)
+	pChainClient.On("CreateBlockchain", mock.Anything, mock.Anything, mock.Anything, mock.Anything, subnetID, vmID.String(), mock.Anything, "chain", genesis).Return(chainID, nil)
+	// API ports of the nodes that never become healthy
+	var unhealthyPorts sync.Map
+	newAPIClientF := func(ipAddr string, port uint16) api.Client {
+		healthReply := &health.APIHealthReply{
+			Healthy: true,
+			Checks: map[string]health.Result{
+				chainID.String(): {},
+			},
+		}
+		healthClient := &apimocks.HealthClient{}
+		healthClient.On("Health", mock.Anything).Return(
+			func(context.Context) *health.APIHealthReply {
+				if _, ok := unhealthyPorts.Load(port); ok {
+					return &health.APIHealthReply{Healthy: false}
+				}
+				return healthReply
+			},
+			nil,
+		)
+		ethClient := &apimocks.EthClient{}
+		ethClient.On("Close").Return()
+		client := &apimocks.Client{}
+		client.On("HealthAPI").Return(healthClient)
+		client.On("CChainEthAPI").Return(ethClient)
+		client.On("KeystoreAPI").Return(keystoreClient)
+		client.On("PChainAPI").Return(pChainClient)
+		return client
+	}
+	creator := newLocalTestFlagRecorderProcessCreator()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newAPIClientF, creator, t.TempDir())
+	assert.NoError(err)
+	ln := net.(*localNetwork)
+
+	// A subnet with no validators in the network
+	_, err = net.CreateBlockchain(context.Background(), subnetID, vmID, "chain", genesis, pluginPath)
+	assert.Error(err)
+
+	validatorNames := []string{networkConfig.NodeConfigs[1].Name, networkConfig.NodeConfigs[2].Name}
+	otherName := networkConfig.NodeConfigs[0].Name
+	processes := make(map[string]NodeProcess)
+	for name, node := range ln.nodes {
+		processes[name] = node.process
+		if name != otherName {
+			validatorIDs.Store(node.nodeID, true)
+		}
+	}
+	// Only the restarted validators are waited for
+	unhealthyPorts.Store(ln.nodes[otherName].apiPort, true)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultHealthyTimeout)
+	defer cancel()
+	blockchain, err := net.CreateBlockchain(ctx, subnetID, vmID, "chain", genesis, pluginPath)
+	assert.NoError(err)
+	assert.EqualValues(chainID, blockchain.ID)
+	assert.EqualValues(subnetID, blockchain.SubnetID)
+	assert.EqualValues(vmID, blockchain.VMID)
+	assert.Len(blockchain.Clients, 2)
+	for _, name := range validatorNames {
+		node, err := net.GetNode(name)
+		assert.NoError(err)
+		assert.Same(node.GetAPIClient(), blockchain.Clients[name])
+		assert.EqualValues(
+			fmt.Sprintf("http://localhost:%d/ext/bc/%s", node.GetAPIPort(), chainID),
+			blockchain.Endpoints[name],
+		)
+		// Restarted to load the plugin, which is installed in its
+		// own build directory along with its binary's plugins
+		assert.NotSame(processes[name], ln.nodes[name].process)
+		nodeBuildDir := filepath.Join(ln.nodes[name].rootDir, nodeBuildDirName)
+		creator.lock.Lock()
+		assert.EqualValues(nodeBuildDir, flagValue(creator.flags[name], config.BuildDirKey))
+		creator.lock.Unlock()
+		plugin, err := os.ReadFile(filepath.Join(nodeBuildDir, pluginsDirName, vmID.String()))
+		assert.NoError(err)
+		assert.EqualValues("plugin", plugin)
+		evm, err := os.ReadFile(filepath.Join(nodeBuildDir, pluginsDirName, "evm"))
+		assert.NoError(err)
+		assert.EqualValues("evm", evm)
+	}
+	assert.Same(processes[otherName], ln.nodes[otherName].process)
+	// The binary's plugins directory is left as it was
+	_, err = os.Stat(filepath.Join(buildDir, pluginsDirName, vmID.String()))
+	assert.ErrorIs(err, fs.ErrNotExist)
+
+	// Nodes that already have the plugin aren't restarted
+	for name, node := range ln.nodes {
+		processes[name] = node.process
+	}
+	_, err = net.CreateBlockchain(context.Background(), subnetID, vmID, "chain", genesis, pluginPath)
+	assert.NoError(err)
+	for _, name := range validatorNames {
+		assert.Same(processes[name], ln.nodes[name].process)
+	}
+
+	// A node's links to its binary's plugins are refreshed when it
+	// starts, and the plugins installed on it are kept
+	assert.NoError(os.WriteFile(filepath.Join(buildDir, pluginsDirName, "other"), []byte("other"), 0o755))
+	assert.NoError(net.RestartNode(context.Background(), validatorNames[0], nil))
+	nodeBuildDir := filepath.Join(ln.nodes[validatorNames[0]].rootDir, nodeBuildDirName)
+	other, err := os.ReadFile(filepath.Join(nodeBuildDir, pluginsDirName, "other"))
+	assert.NoError(err)
+	assert.EqualValues("other", other)
+	plugin, err := os.ReadFile(filepath.Join(nodeBuildDir, pluginsDirName, vmID.String()))
+	assert.NoError(err)
+	assert.EqualValues("plugin", plugin)
+
+	assert.NoError(net.Stop(context.Background()))
+	_, err = net.CreateBlockchain(context.Background(), subnetID, vmID, "chain", genesis, "")
+	assert.EqualValues(network.ErrStopped, err)
+}
+
+// TestNodeOutputFiles checks that a node's process writes its output
+// directly to the log files in its root directory, rather than to pipes
+// that would break if the process that started it exited
+func TestNodeOutputFiles(t *testing.T) {
+	assert := assert.New(t)
+	binaryPath := filepath.Join(t.TempDir(), "avalanchego")
+	script := "#!/bin/sh\n" +
+		"trap 'kill $!; exit 0' TERM\n" +
+		"echo 'INFO [01-02|15:04:05] started'\n" +
+		"sleep 60 &\n" +
+		"wait\n"
+	assert.NoError(os.WriteFile(binaryPath, []byte(script), 0o755))
+	networkConfig := testNetworkConfig(t)
+	networkConfig.NodeConfigs = networkConfig.NodeConfigs[:1]
+	networkConfig.NodeConfigs[0].ImplSpecificConfig = json.RawMessage(
+		fmt.Sprintf(`{"binaryPath":%q,"redirectStdout":true,"redirectStderr":true}`, binaryPath),
+	)
+	nodeName := networkConfig.NodeConfigs[0].Name
+	printed := &lockedBuffer{writtenCh: make(chan struct{})}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &nodeProcessCreator{
+		stdout:      printed,
+		stderr:      &bytes.Buffer{},
+		colorPicker: utils.NewColorPicker(),
+	}, "")
+	assert.NoError(err)
+	_, err = net.WaitForLog(context.Background(), nodeName, regexp.MustCompile("started"))
+	assert.NoError(err)
+
+	info, err := net.Describe()
+	assert.NoError(err)
+	nodeInfo := info.Nodes[nodeName]
+	for fd, fileName := range map[int]string{1: stdoutLogFileName, 2: stderrLogFileName} {
+		target, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", nodeInfo.PID, fd))
+		assert.NoError(err)
+		assert.Equal(filepath.Join(nodeInfo.RootDir, fileName), target)
+	}
+	assert.NoError(net.Stop(context.Background()))
+	// Redirected output is printed from the files
+	<-printed.writtenCh
+	assert.Contains(printed.String(), "started")
+}
+
+// TestFailedToBindPort checks that only a bind error in the last lines
+// written by a node's current process counts as failing to bind a port
+func TestFailedToBindPort(t *testing.T) {
+	assert := assert.New(t)
+	bindErr := "listen tcp 127.0.0.1:9651: bind: address already in use"
+	exitErr := errors.New("exit status 1")
+
+	node := &localNode{logs: newNodeLogs()}
+	assert.False(failedToBindPort(node, nil))
+	assert.False(failedToBindPort(node, exitErr))
+	assert.True(failedToBindPort(node, fmt.Errorf("exit status 1: %s", bindErr)))
+
+	// Written by the previous process
+	node.logs.add(network.LogLine{Time: time.Now(), Text: bindErr}, time.Now())
+	node.logs.setProcessStart(time.Now().Add(time.Millisecond))
+	time.Sleep(2 * time.Millisecond)
+	assert.False(failedToBindPort(node, exitErr))
+
+	// Written by the current process, but long before it exited
+	node.logs.add(network.LogLine{Time: time.Now(), Text: bindErr}, time.Now())
+	for i := 0; i < stderrTailLines; i++ {
+		node.logs.add(network.LogLine{Time: time.Now(), Text: "line" + strconv.Itoa(i)}, time.Now())
+	}
+	assert.False(failedToBindPort(node, exitErr))
+
+	// Written by the current process right before it exited
+	node.logs.add(network.LogLine{Time: time.Now(), Text: bindErr}, time.Now())
+	assert.True(failedToBindPort(node, exitErr))
+	// Other errors aren't bind errors
+	for i := 0; i < stderrTailLines; i++ {
+		node.logs.add(network.LogLine{Time: time.Now(), Text: "line" + strconv.Itoa(i)}, time.Now())
+	}
+	node.logs.add(network.LogLine{Time: time.Now(), Text: "dial tcp 127.0.0.1:9651: connect: address already in use"}, time.Now())
+	assert.False(failedToBindPort(node, exitErr))
+}
+
+// Creates processes like localTestSuccessfulNodeProcessCreator,
+// except that those of the nodes in [failingNodeNames] fail to start
+type localTestFailingRestartProcessCreator struct {
+	lock             sync.Mutex
+	failingNodeNames map[string]struct{}
+}
+
+func (lt *localTestFailingRestartProcessCreator) NewNodeProcess(config node.Config, _ *os.File, _ *os.File, flags ...string) (NodeProcess, error) {
+	lt.lock.Lock()
+	_, failing := lt.failingNodeNames[config.Name]
+	lt.lock.Unlock()
+	if failing {
+		return newMockProcess(int(atomic.AddInt32(&nextMockPID, 1)), func() error { return errors.New("Start failed") }), nil
+	}
+	return newMockProcessSuccessful(config, flags...)
+}
+
+func (lt *localTestFailingRestartProcessCreator) setFailing(nodeName string, failing bool) {
+	lt.lock.Lock()
+	defer lt.lock.Unlock()
+
+	if failing {
+		lt.failingNodeNames[nodeName] = struct{}{}
+	} else {
+		delete(lt.failingNodeNames, nodeName)
+	}
+}
+
+// TestRestartNodeFailure checks that a node that isn't started again
+// when it's restarted is kept, with its ports, as a node that exited
+func TestRestartNodeFailure(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	creator := &localTestFailingRestartProcessCreator{failingNodeNames: map[string]struct{}{}}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, creator, "")
+	assert.NoError(err)
+	nodeName := networkConfig.NodeConfigs[1].Name
+	oldNode, err := net.GetNode(nodeName)
+	assert.NoError(err)
+
+	creator.setFailing(nodeName, true)
+	err = net.RestartNode(context.Background(), nodeName, nil)
+	assert.Error(err)
+	assert.Contains(err.Error(), "Start failed")
+	// The node is reported as a node that exited
+	names, err := net.GetNodeNames()
+	assert.NoError(err)
+	assert.Contains(names, nodeName)
+	_, err = net.GetNode(nodeName)
+	assert.Error(err)
+	assert.Contains(err.Error(), "Start failed")
+	report, err := net.HealthReport(context.Background())
+	assert.NoError(err)
+	assert.False(report[nodeName].Healthy)
+	assert.Contains(report[nodeName].LastError, "Start failed")
+	err = awaitNetworkHealthy(net, 5*time.Second)
+	assert.Error(err)
+	assert.Contains(err.Error(), "Start failed")
+
+	// A restart that's cancelled keeps the node too
+	creator.setFailing(nodeName, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(net.RestartNode(ctx, nodeName, nil), context.Canceled)
+	names, err = net.GetNodeNames()
+	assert.NoError(err)
+	assert.Contains(names, nodeName)
+
+	// The node can be restarted later, with the same ports
+	assert.NoError(net.RestartNode(context.Background(), nodeName, nil))
+	node, err := net.GetNode(nodeName)
+	assert.NoError(err)
+	assert.EqualValues(oldNode.GetAPIPort(), node.GetAPIPort())
+	assert.EqualValues(oldNode.GetP2PPort(), node.GetP2PPort())
+	assert.NoError(awaitNetworkHealthy(net, 5*time.Second))
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestHealthyFailure checks that when a node fails while the network
+// is waited on, only that node is reported unhealthy, and that the
+// network's failure is forgotten once it's started again
+func TestHealthyFailure(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	crashingNodeName := networkConfig.NodeConfigs[1].Name
+	creator := &localTestCrashingProcessCreator{
+		crashingNodeName: crashingNodeName,
+		crashCh:          make(chan time.Time),
+		exitErr:          errors.New("exit status 1"),
+	}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPIUnhealthy, creator, "")
+	assert.NoError(err)
+	net.(*localNetwork).healthCheckFreq = testHealthCheckFreq
+	events, unsubscribe := net.Subscribe()
+	defer unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultHealthyTimeout)
+	defer cancel()
+	healthyCh := net.Healthy(ctx)
+	close(creator.crashCh)
+	err = <-healthyCh
+	assert.Error(err)
+	assert.Contains(err.Error(), crashingNodeName)
+	assert.EqualValues(network.NodeExited, nextEvent(t, events).Type)
+	event := nextEvent(t, events)
+	assert.EqualValues(network.NodeUnhealthy, event.Type)
+	assert.EqualValues(crashingNodeName, event.NodeName)
+	// The other nodes stopped being waited on, but didn't fail
+	select {
+	case event := <-events:
+		assert.Fail("unexpected event", "%+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.True(net.(*localNetwork).failed.GetValue())
+
+	assert.NoError(net.Stop(context.Background()))
+	creator.crashingNodeName = ""
+	assert.NoError(net.Start(context.Background()))
+	assert.False(net.(*localNetwork).failed.GetValue())
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestAddNodesReleasesPorts checks that when nodes can't be added,
+// the ports given to the nodes that weren't started are released
+func TestAddNodesReleasesPorts(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	nodeConfigs := networkConfig.NodeConfigs
+	networkConfig.NodeConfigs = nil
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, "")
+	assert.NoError(err)
+	ln := net.(*localNetwork)
+	l, err := gonet.Listen("tcp", ":0")
+	assert.NoError(err)
+	port := l.Addr().(*gonet.TCPAddr).Port
+	assert.NoError(l.Close())
+
+	for _, repeated := range []string{"name", "port"} {
+		first, second := nodeConfigs[1], nodeConfigs[2]
+		first.IsBeacon, second.IsBeacon = false, false
+		switch repeated {
+		case "name":
+			second.Name = first.Name
+		case "port":
+			first.Flags = map[string]interface{}{config.HTTPPortKey: port}
+			second.Flags = map[string]interface{}{config.HTTPPortKey: port}
+		}
+		ln.lock.Lock()
+		assert.Error(ln.addNodes([]node.Config{first, second}))
+		assert.Empty(ln.ports.assigned)
+		assert.Empty(ln.nodes)
+		ln.lock.Unlock()
+	}
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestUpgradeExitedNode checks that a node whose version can't be
+// queried, because its process exited, can still be upgraded
+func TestUpgradeExitedNode(t *testing.T) {
+	assert := assert.New(t)
+	binaryPaths := map[string]string{}
+	for _, name := range []string{"old", "new"} {
+		binaryPaths[name] = filepath.Join(t.TempDir(), name)
+		assert.NoError(os.WriteFile(binaryPaths[name], nil, 0o755))
+	}
+	networkConfig := testNetworkConfig(t)
+	for i := range networkConfig.NodeConfigs {
+		networkConfig.NodeConfigs[i].ImplSpecificConfig = utils.NewLocalNodeConfigJsonRaw(binaryPaths["old"])
+	}
+	// The node's process exits as soon as it's started with the old binary
+	nodeName := networkConfig.NodeConfigs[1].Name
+	creator := &localTestCrashingBinaryProcessCreator{
+		crashingNodeName:   nodeName,
+		crashingBinaryPath: binaryPaths["old"],
+	}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, creator.newAPIClientF(newMockAPIVersionedF()), creator, "")
+	assert.NoError(err)
+	assert.Eventually(func() bool {
+		_, err := net.GetNode(nodeName)
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	upgrade, err := net.UpgradeNode(context.Background(), nodeName, binaryPaths["new"])
+	assert.NoError(err)
+	assert.Nil(upgrade.OldVersion)
+	assert.NotNil(upgrade.NewVersion)
+	assert.Equal(binaryPaths["old"], upgrade.PreviousBinaryOrTag)
+	_, err = net.GetNode(nodeName)
+	assert.NoError(err)
+	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestRollbackExitedNode checks that a node whose new binary exits as
+// soon as it's started is downgraded, and running again, after a
+// rolling upgrade with rollback fails
+func TestRollbackExitedNode(t *testing.T) {
+	assert := assert.New(t)
+	binaryPaths := map[string]string{}
+	for _, name := range []string{"old", "new"} {
+		binaryPaths[name] = filepath.Join(t.TempDir(), name)
+		assert.NoError(os.WriteFile(binaryPaths[name], nil, 0o755))
+	}
+	networkConfig := testNetworkConfig(t)
+	for i := range networkConfig.NodeConfigs {
+		networkConfig.NodeConfigs[i].ImplSpecificConfig = utils.NewLocalNodeConfigJsonRaw(binaryPaths["old"])
+	}
+	nodeName := networkConfig.NodeConfigs[1].Name
+	creator := &localTestCrashingBinaryProcessCreator{
+		crashingNodeName:   nodeName,
+		crashingBinaryPath: binaryPaths["new"],
+	}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, creator.newAPIClientF(newMockAPIVersionedF()), creator, "")
+	assert.NoError(err)
+
+	upgrades, err := net.RollingUpgrade(context.Background(), binaryPaths["new"], network.RollingUpgradeOptions{
+		NodeNames:     []string{nodeName},
+		HealthTimeout: defaultHealthyTimeout,
+		Rollback:      true,
+	})
+	assert.Error(err)
+	assert.Contains(err.Error(), "exited unexpectedly")
+	assert.Contains(err.Error(), "rolled back upgraded nodes")
+	assert.Len(upgrades, 1)
+	assert.Equal(binaryPaths["old"], upgrades[0].PreviousBinaryOrTag)
+	assert.Nil(upgrades[0].NewVersion)
+	// The node runs the old binary again
+	_, err = net.GetNode(nodeName)
+	assert.NoError(err)
+	netInfo, err := net.Describe()
+	assert.NoError(err)
+	assert.Equal(binaryPaths["old"], netInfo.Nodes[nodeName].BinaryPath)
+	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestCreateSubnetParallelRestart checks that the subnet's validators
+// are restarted in parallel, and that the keystore user that paid for
+// the transactions is deleted
+func TestCreateSubnetParallelRestart(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	networkConfig.FundedPrivateKey = "PrivateKey-ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN"
+	subnetID := ids.GenerateTestID()
+	keystoreClient := &apimocks.KeystoreClient{}
+	keystoreClient.On("CreateUser", mock.Anything, mock.Anything).Return(true, nil)
+	keystoreClient.On("DeleteUser", mock.Anything, mock.Anything).Return(true, nil)
+	pChainClient := &apimocks.PChainClient{}
+	pChainClient.On("ImportKey", mock.Anything, mock.Anything, networkConfig.FundedPrivateKey).Return("P-custom1address", nil)
+	pChainClient.On("CreateSubnet", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(subnetID, nil)
+	pChainClient.On("GetTxStatus", mock.Anything, mock.Anything, true).Return(&platformvm.GetTxStatusResponse{Status: status.Committed}, nil)
+	pChainClient.On("GetCurrentValidators", mock.Anything, constants.PrimaryNetworkID, mock.Anything).Return(
+		func(_ context.Context, _ ids.ID, nodeIDs []ids.ShortID) []interface{} {
+			return []interface{}{
+				map[string]interface{}{
+					"nodeID":  nodeIDs[0].PrefixedString(constants.NodeIDPrefix),
+					"endTime": "12345",
+				},
+			}
+		},
+		nil,
+	)
+	pChainClient.On("AddSubnetValidator", mock.Anything, mock.Anything, mock.Anything, mock.Anything, subnetID.String(), mock.Anything, uint64(network.SubnetValidatorWeight), mock.Anything, uint64(12345)).Return(ids.GenerateTestID(), nil)
+	newAPIClientF := func(ipAddr string, port uint16) api.Client {
+		client := newMockAPISuccessful(ipAddr, port).(*apimocks.Client)
+		client.On("KeystoreAPI").Return(keystoreClient)
+		client.On("PChainAPI").Return(pChainClient)
+		return client
+	}
+	// The non-beacons are the validators
+	validatorNames := []string{networkConfig.NodeConfigs[1].Name, networkConfig.NodeConfigs[2].Name}
+	creator := &localTestParallelProcessCreator{
+		numNonBeacons: len(validatorNames),
+		allStartingCh: make(chan struct{}),
+		processes:     make(map[string]*mocks.NodeProcess),
+	}
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newAPIClientF, creator, "")
+	assert.NoError(err)
+	assert.NoError(awaitNetworkHealthy(net, defaultHealthyTimeout))
+
+	// The restarted validators' processes only start once they're all starting
+	creator.lock.Lock()
+	creator.numStarting = 0
+	creator.allStartingCh = make(chan struct{})
+	creator.lock.Unlock()
+	gotSubnetID, err := net.CreateSubnet(context.Background(), nil, 0, validatorNames)
+	assert.NoError(err)
+	assert.EqualValues(subnetID, gotSubnetID)
+	keystoreClient.AssertNumberOfCalls(t, "DeleteUser", 1)
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestAttachedZombieProcess checks that an attached process that exited
+// but wasn't reaped by the process that started it is seen to exit
+func TestAttachedZombieProcess(t *testing.T) {
+	assert := assert.New(t)
+	cmd := exec.Command("sh", "-c", "sleep 0.2")
+	assert.NoError(cmd.Start())
+	pid := cmd.Process.Pid
+	proc, err := newAttachedProcess(pid, processStartTime(pid))
+	assert.NoError(err)
+	assert.ErrorIs(proc.Wait(), errExitStatusUnknown)
+	// The process isn't reaped until cmd.Wait is called
+	assert.True(isZombie(pid))
+	assert.False(processExists(pid))
+	_, err = newAttachedProcess(pid, 0)
+	assert.Error(err)
+	assert.NoError(cmd.Wait())
+	assert.False(isZombie(pid))
+	assert.False(processExists(pid))
+}
+
+// TestStateFilePerms checks that only the owner of the network's
+// state file can read it, since it has secrets
+func TestStateFilePerms(t *testing.T) {
+	assert := assert.New(t)
+	rootDir := t.TempDir()
+	statePath := filepath.Join(rootDir, stateFileName)
+	// Left over from an earlier version that didn't restrict the file
+	assert.NoError(os.WriteFile(statePath+".tmp", nil, 0o644))
+	networkConfig := testNetworkConfig(t)
+	networkConfig.FundedPrivateKey = "PrivateKey-ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN"
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.NoError(err)
+	info, err := os.Stat(statePath)
+	assert.NoError(err)
+	assert.EqualValues(os.FileMode(0o600), info.Mode().Perm())
+	state, err := readState(rootDir)
+	assert.NoError(err)
+	assert.EqualValues(networkConfig.FundedPrivateKey, state.FundedPrivateKey)
+	assert.NoError(net.Stop(context.Background()))
+	info, err = os.Stat(statePath)
+	assert.NoError(err)
+	assert.EqualValues(os.FileMode(0o600), info.Mode().Perm())
+}
+
+// TestPartitionAfterGossip checks that nodes can't reach each other at
+// the addresses they advertise, so that a node that learns a peer's
+// address through gossip, e.g. after the peer is restarted, can't send
+// it traffic that bypasses the proxies while they're partitioned
+func TestPartitionAfterGossip(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	networkConfig.FaultInjection = true
+	creator := newLocalTestFlagRecorderProcessCreator()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, creator, t.TempDir())
+	assert.NoError(err)
+	name0, name1 := networkConfig.NodeConfigs[0].Name, networkConfig.NodeConfigs[1].Name
+	assert.NoError(net.Partition([]string{name0}, []string{name1}))
+	assert.NoError(net.RestartNode(context.Background(), name0, nil))
+
+	// Pretend to be node0, which listens on its staking port on all
+	// interfaces, so any address of this host with that port reaches it
+	creator.lock.Lock()
+	flags0 := creator.flags[name0]
+	creator.lock.Unlock()
+	p2pPort := flagValue(flags0, config.StakingPortKey)
+	listener, err := gonet.Listen("tcp", ":"+p2pPort)
+	assert.NoError(err)
+	defer listener.Close()
+	accepted := make(chan gonet.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	// Connect to the address node0 advertises, as node1 would after
+	// learning it through gossip, and send it traffic
+	advertisedAddr := gonet.JoinHostPort(flagValue(flags0, config.PublicIPKey), p2pPort)
+	conn, err := gonet.DialTimeout("tcp", advertisedAddr, time.Second)
+	if err == nil {
+		_, _ = conn.Write([]byte("a"))
+		_ = conn.Close()
+	}
+	select {
+	case conn := <-accepted:
+		_ = conn.Close()
+		assert.Fail("traffic to the address node0 advertises reached it without going through a proxy")
+	case <-time.After(200 * time.Millisecond):
+	}
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestSnapshotCleanup checks that the nodes' output isn't part of a
+// snapshot, and that directories aren't left behind when saving or
+// loading a snapshot fails
+func TestSnapshotCleanup(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, t.TempDir())
+	assert.NoError(err)
+	ln := net.(*localNetwork)
+	ln.snapshotsDir = t.TempDir()
+	snapshotDir, err := net.SaveSnapshot(context.Background(), "snapshot")
+	assert.NoError(err)
+	for _, nodeConfig := range networkConfig.NodeConfigs {
+		assert.FileExists(filepath.Join(ln.stoppedNodes[nodeConfig.Name].rootDir, stdoutLogFileName))
+		assert.NoFileExists(filepath.Join(snapshotDir, nodeConfig.Name, stdoutLogFileName))
+		assert.NoFileExists(filepath.Join(snapshotDir, nodeConfig.Name, stderrLogFileName))
+	}
+
+	// The network's directory is removed if loading the snapshot fails
+	tmpDir := t.TempDir()
+	t.Setenv("TMPDIR", tmpDir)
+	_, err = loadSnapshot(logging.NoLog{}, snapshotDir, newMockAPISuccessful, &localTestFailedStartProcessCreator{})
+	assert.Error(err)
+	entries, err := os.ReadDir(tmpDir)
+	assert.NoError(err)
+	assert.Empty(entries)
+	assert.NoError(os.RemoveAll(filepath.Join(snapshotDir, networkConfig.NodeConfigs[1].Name)))
+	_, err = loadSnapshot(logging.NoLog{}, snapshotDir, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{})
+	assert.Error(err)
+	entries, err = os.ReadDir(tmpDir)
+	assert.NoError(err)
+	assert.Empty(entries)
+
+	// The network is cleaned up even if stopping it fails
+	networkConfig.CleanupPolicy = network.CleanupAlways
+	rootDir := filepath.Join(t.TempDir(), "network")
+	creator := &localTestHungProcessCreator{
+		hungNodeNames: map[string]struct{}{networkConfig.NodeConfigs[1].Name: {}},
+		processes:     make(map[string]*mocks.NodeProcess),
+	}
+	net, err = newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, creator, rootDir)
+	assert.NoError(err)
+	ln = net.(*localNetwork)
+	ln.snapshotsDir = t.TempDir()
+	ln.stopGracePeriod = 10 * time.Millisecond
+	_, err = net.SaveSnapshot(context.Background(), "snapshot")
+	var killedErr *NodesKilledError
+	assert.True(errors.As(err, &killedErr))
+	assert.NoDirExists(rootDir)
+}
+
+// TestRestartNodePorts checks that a node can't be restarted
+// with flags or a config file that give it other ports
+func TestRestartNodePorts(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	creator := newLocalTestFlagRecorderProcessCreator()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, creator, "")
+	assert.NoError(err)
+	defer func() {
+		assert.NoError(net.Stop(context.Background()))
+	}()
+
+	nodeName := networkConfig.NodeConfigs[1].Name
+	node1, err := net.GetNode(nodeName)
+	assert.NoError(err)
+	apiPort, p2pPort := node1.GetAPIPort(), node1.GetP2PPort()
+	oldFlags := creator.flags[nodeName]
+
+	err = net.RestartNode(context.Background(), nodeName, &node.Config{
+		Flags: map[string]interface{}{config.HTTPPortKey: int(apiPort) + 1},
+	})
+	assert.Error(err)
+	err = net.RestartNode(context.Background(), nodeName, &node.Config{
+		ConfigFile: fmt.Sprintf(`{%q:%d}`, config.StakingPortKey, p2pPort+1),
+	})
+	assert.Error(err)
+	assert.EqualValues(oldFlags, creator.flags[nodeName])
+
+	// The node's own ports can be given
+	err = net.RestartNode(context.Background(), nodeName, &node.Config{
+		Flags:      map[string]interface{}{config.HTTPPortKey: int(apiPort)},
+		ConfigFile: fmt.Sprintf(`{%q:%d}`, config.StakingPortKey, p2pPort),
+	})
+	assert.NoError(err)
+	node1, err = net.GetNode(nodeName)
+	assert.NoError(err)
+	assert.EqualValues(apiPort, node1.GetAPIPort())
+	assert.EqualValues(p2pPort, node1.GetP2PPort())
+}
+
+// TestAttachReusedPID checks that a process that has the PID of a node's
+// process, but started at another time, isn't taken to be the node's
+func TestAttachReusedPID(t *testing.T) {
+	assert := assert.New(t)
+	cmd := exec.Command("sleep", "10")
+	assert.NoError(cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	pid := cmd.Process.Pid
+	startTime := processStartTime(pid)
+	assert.NotZero(startTime)
+	_, err := newAttachedProcess(pid, startTime)
+	assert.NoError(err)
+	_, err = newAttachedProcess(pid, startTime-1)
+	assert.Error(err)
+
+	// The network's nodes exited, and their PIDs were reused
+	networkConfig := testNetworkConfig(t)
+	rootDir := t.TempDir()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.NoError(err)
+	assert.NoError(net.Stop(context.Background()))
+	state, err := readState(rootDir)
+	assert.NoError(err)
+	state.Stopped = false
+	for i := range state.Nodes {
+		state.Nodes[i].PID = pid
+		state.Nodes[i].StartTime = startTime - 1
+	}
+	stateBytes, err := json.Marshal(state)
+	assert.NoError(err)
+	assert.NoError(writePrivateFile(filepath.Join(rootDir, stateFileName), stateBytes))
+	_, err = attachNetwork(logging.NoLog{}, rootDir, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, newAttachedProcess)
+	assert.Error(err)
+	// The network isn't running, so it can be started again
+	net, err = newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, &localTestSuccessfulNodeProcessCreator{}, rootDir)
+	assert.NoError(err)
+	assert.NoError(net.Stop(context.Background()))
+}
+
+// TestLinkConditionsExempt checks that a link given the zero
+// conditions isn't shaped by the default conditions
+func TestLinkConditionsExempt(t *testing.T) {
+	assert := assert.New(t)
+	networkConfig := testNetworkConfig(t)
+	networkConfig.FaultInjection = true
+	creator := newLocalTestFlagRecorderProcessCreator()
+	net, err := newNetwork(logging.NoLog{}, networkConfig, newMockAPISuccessful, creator, "")
+	assert.NoError(err)
+	defer func() {
+		assert.NoError(net.Stop(context.Background()))
+	}()
+	name0, name1 := networkConfig.NodeConfigs[0].Name, networkConfig.NodeConfigs[1].Name
+	ln := net.(*localNetwork)
+	toNode0 := link{src: name1, dst: name0}
+	fromNode0 := link{src: name0, dst: name1}
+
+	defaultConditions := LinkConditions{Latency: time.Second}
+	assert.NoError(net.SetDefaultLinkConditions(defaultConditions))
+	assert.NoError(net.SetLinkConditions(name1, name0, LinkConditions{}))
+	assert.Equal(LinkConditions{}, ln.proxies.conditionsOf(toNode0))
+	assert.Equal(defaultConditions, ln.proxies.conditionsOf(fromNode0))
+
+	// Traffic over the exempt link isn't delayed
+	node0 := ln.nodes[name0]
+	listener, err := gonet.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", node0.p2pPort))
+	assert.NoError(err)
+	defer listener.Close()
+	conn1, err := gonet.Dial("tcp", flagValue(creator.flags[name1], config.BootstrapIPsKey))
+	assert.NoError(err)
+	defer conn1.Close()
+	conn0, err := listener.Accept()
+	assert.NoError(err)
+	defer conn0.Close()
+	start := time.Now()
+	_, err = conn1.Write([]byte{1})
+	assert.NoError(err)
+	_, err = io.ReadFull(conn0, make([]byte, 1))
+	assert.NoError(err)
+	assert.Less(time.Since(start), defaultConditions.Latency)
+
+	assert.NoError(net.ClearLinkConditions(name1, name0))
+	assert.Equal(defaultConditions, ln.proxies.conditionsOf(toNode0))
+}
+
+// TestNodeLogsTime checks that the time of an avalanchego log line is
+// when it was logged, rather than when it was read, so that lines read
+// long after they're written aren't returned for a later Since
+func TestNodeLogsTime(t *testing.T) {
+	assert := assert.New(t)
+	node := &localNode{rootDir: t.TempDir(), logs: newNodeLogs()}
+	loggedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	output := fmt.Sprintf("INFO [%s] bootstrapping finished\nnot a log line\n", loggedAt.Format("01-02|15:04:05"))
+	assert.NoError(os.WriteFile(filepath.Join(node.rootDir, stdoutLogFileName), []byte(output), 0o644))
+	assert.NoError(os.WriteFile(filepath.Join(node.rootDir, stderrLogFileName), nil, 0o644))
+
+	since := time.Now()
+	closeOnExit, err := tailNodeLogs(node, 0, 0)
+	assert.NoError(err)
+	closeAll(closeOnExit)
+	lines := node.logs.get(network.LogQuery{})
+	assert.Len(lines, 2)
+	assert.True(loggedAt.Equal(lines[0].Time))
+	// Lines that aren't log lines have the time they were read
+	assert.False(lines[1].Time.Before(since))
+	lines = node.logs.get(network.LogQuery{Since: since})
+	assert.Len(lines, 1)
+	assert.Equal("not a log line", lines[0].Text)
+}
@@ -1,7 +1,11 @@
 package local
 
 import (
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"strings"
 	"syscall"
 
 	"github.com/ava-labs/avalanche-network-runner/api"
@@ -24,6 +28,29 @@ type NodeConfig struct {
 	RedirectStdout bool `json:"redirectStdout"`
 	// If non-nil, direct this node's Stderr to os.Stderr
 	RedirectStderr bool `json:"redirectStderr"`
+	// If non-empty, only the redirected avalanchego log lines logged at
+	// this level or a more severe one are printed, e.g. "WARN". Other
+	// lines, like the stack trace of a panic, are always printed.
+	RedirectLevel string `json:"redirectLevel,omitempty"`
+	// If non-empty, only the redirected avalanchego log lines logged
+	// about these chains are printed, e.g. "C Chain".
+	RedirectChains []string `json:"redirectChains,omitempty"`
+	// If true, redirected lines are printed as JSON objects, one per
+	// line, with the parts of avalanchego log lines, instead of as text.
+	RedirectJSON bool `json:"redirectJSON,omitempty"`
+	// The resource limits below need cgroup v2. If any of them is set,
+	// the node's process and the VM plugins it starts run in their own
+	// cgroup, which is removed when the node stops. The binary only
+	// starts running once its process has been moved into the cgroup.
+	// Number of CPUs' worth of time the node can use, e.g. 0.5 or 2.
+	// If 0, CPU usage isn't limited.
+	CPUQuota float64 `json:"cpuQuota,omitempty"`
+	// Bytes of memory the node can use before it's reclaimed from
+	// or the node is OOM killed. If 0, memory usage isn't limited.
+	MemoryMax uint64 `json:"memoryMax,omitempty"`
+	// Share of IO the node gets, from 1 to 10000, relative to
+	// other cgroups. If 0, the default weight of 100 applies.
+	IOWeight uint16 `json:"ioWeight,omitempty"`
 }
 
 // NodeProcess as an interface so we can mock running
@@ -33,26 +60,158 @@ type NodeProcess interface {
 	Start() error
 	// Send a SIGTERM to this process
 	Stop() error
-	// Returns when the process finishes exiting
+	// Send a SIGKILL to this process' process group,
+	// which includes the VM plugins it started
+	Kill() error
+	// Returns when the process finishes exiting.
+	// Must be called only once.
 	Wait() error
+	// Send a SIGSTOP to this process
+	Pause() error
+	// Send a SIGCONT to this process
+	Resume() error
+	// Returns the ID of this process, or 0 if it wasn't started
+	PID() int
 }
 
 type nodeProcessImpl struct {
 	cmd *exec.Cmd
+	// The files the process writes its stdout and stderr to.
+	// If nil, the output is discarded.
+	stdout, stderr *os.File
+	// Where the process' output starts in [stdout] and [stderr]
+	stdoutOffset, stderrOffset int64
+	// Print the process' stdout and stderr once it's
+	// started. Nil if that output isn't redirected.
+	stdoutPrinter, stderrPrinter *outputPrinter
+	// Closed after the process exits so that
+	// its redirected output is fully printed
+	closeOnExit []io.Closer
+	// The name of the node this process runs
+	nodeName string
+	// Where the cgroup v2 hierarchy is mounted
+	cgroupRoot string
+	// If it has resource limits, the process is moved
+	// into a cgroup with them once it's started
+	limits NodeConfig
+	// The cgroup the process runs in. Nil if it has no resource limits.
+	cgroup *nodeCgroup
 }
 
 func (p *nodeProcessImpl) Start() error {
-	return p.cmd.Start()
+	p.stdoutOffset = fileSize(p.stdout)
+	p.stderrOffset = fileSize(p.stderr)
+	if p.limits.hasResourceLimits() {
+		if err := p.startInCgroup(); err != nil {
+			return err
+		}
+	} else if err := p.cmd.Start(); err != nil {
+		return err
+	}
+	p.startPrinter(p.stdout, p.stdoutOffset, p.stdoutPrinter)
+	p.startPrinter(p.stderr, p.stderrOffset, p.stderrPrinter)
+	return nil
 }
 
+// Starts the process in a new cgroup with its resource limits.
+// So that no process it starts, e.g. a VM plugin, is left outside of
+// the cgroup, it's started as a shell that waits until it's been moved
+// into the cgroup, and then replaces itself with the node's binary,
+// which keeps the shell's PID.
+func (p *nodeProcessImpl) startInCgroup() error {
+	shPath, err := exec.LookPath("sh")
+	if err != nil {
+		return fmt.Errorf("couldn't limit the resources of node %q: %w", p.nodeName, err)
+	}
+	// The shell reads from [startReader] until [startWriter] is closed
+	startReader, startWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer startWriter.Close()
+	fd := 3 + len(p.cmd.ExtraFiles)
+	p.cmd.ExtraFiles = append(p.cmd.ExtraFiles, startReader)
+	// "$0" is the binary and "$@" its arguments. The binary doesn't get the pipe.
+	script := fmt.Sprintf(`read _ <&%d; exec "$0" "$@" %d<&-`, fd, fd)
+	p.cmd.Args = append([]string{"sh", "-c", script, p.cmd.Path}, p.cmd.Args[1:]...)
+	p.cmd.Path = shPath
+	err = p.cmd.Start()
+	_ = startReader.Close()
+	if err != nil {
+		return err
+	}
+	cgroup, err := newNodeCgroup(p.cgroupRoot, p.nodeName, p.cmd.Process.Pid, p.limits)
+	if err != nil {
+		// Don't let the binary run without the limits it was given
+		_ = p.Kill()
+		_ = p.cmd.Wait()
+		return fmt.Errorf("couldn't limit the resources of node %q: %w", p.nodeName, err)
+	}
+	p.cgroup = cgroup
+	return nil
+}
+
+// Prints with [printer] what the process writes to [file] after
+// [offset], unless either is nil. Failing to do so doesn't stop the
+// process, whose output is still in [file], so errors are ignored.
+func (p *nodeProcessImpl) startPrinter(file *os.File, offset int64, printer *outputPrinter) {
+	if file == nil || printer == nil {
+		return
+	}
+	tailer, err := tailFile(file.Name(), offset, printer.printLine)
+	if err != nil {
+		return
+	}
+	printer.start()
+	// The tailer queues the rest of the output before the printer is closed
+	p.closeOnExit = append(p.closeOnExit, tailer, printer)
+}
+
+// If the process exits with an error, the returned
+// error includes the last lines it wrote to stderr
 func (p *nodeProcessImpl) Wait() error {
-	return p.cmd.Wait()
+	err := p.cmd.Wait()
+	for _, closer := range p.closeOnExit {
+		_ = closer.Close()
+	}
+	if p.cgroup != nil {
+		if removeErr := p.cgroup.remove(); removeErr != nil && err == nil {
+			return removeErr
+		}
+	}
+	if err == nil || p.stderr == nil {
+		return err
+	}
+	if lines := lastLines(p.stderr.Name(), p.stderrOffset, stderrTailLines); len(lines) > 0 {
+		return fmt.Errorf("%w; last lines of stderr:\n%s", err, strings.Join(lines, "\n"))
+	}
+	return err
 }
 
 func (p *nodeProcessImpl) Stop() error {
 	return p.cmd.Process.Signal(syscall.SIGTERM)
 }
 
+func (p *nodeProcessImpl) Kill() error {
+	// The process leads its own process group
+	return syscall.Kill(-p.cmd.Process.Pid, syscall.SIGKILL)
+}
+
+func (p *nodeProcessImpl) Pause() error {
+	return p.cmd.Process.Signal(syscall.SIGSTOP)
+}
+
+func (p *nodeProcessImpl) Resume() error {
+	return p.cmd.Process.Signal(syscall.SIGCONT)
+}
+
+func (p *nodeProcessImpl) PID() int {
+	if p.cmd.Process == nil {
+		return 0
+	}
+	return p.cmd.Process.Pid
+}
+
 // Gives access to basic nodes info, and to most avalanchego apis
 type localNode struct {
 	// Must be unique across all nodes in this network.
@@ -60,14 +219,35 @@ type localNode struct {
 	// [nodeID] is this node's Avalannche Node ID.
 	// Set in network.AddNode
 	nodeID ids.ShortID
+	// The config this node was last started with
+	config node.Config
 	// Allows user to make API calls to this node.
 	client api.Client
 	// The process running this node.
 	process NodeProcess
+	// When [process] started, in clock ticks since boot. 0 if unknown.
+	startTime uint64
+	// The flags [process] was started with
+	flags []string
+	// True if [process] was sent a SIGSTOP and hasn't been resumed
+	paused bool
+	// How [process] exited. Replaced when a new process is started.
+	exit *processExit
+	// Where this node's config files, staking key/cert and genesis are written
+	rootDir string
+	// This node's database directory
+	dbDir string
+	// This node's logs directory
+	logsDir string
 	// The API port
 	apiPort uint16
 	// The P2P (staking) port
 	p2pPort uint16
+	// The last lines this node's processes wrote to their stdout and stderr
+	logs *nodeLogs
+	// Number of times this node was started again with
+	// new ports because its process failed to bind its ports
+	portRetries int
 }
 
 // See node.Node
@@ -99,3 +279,50 @@ func (node *localNode) GetP2PPort() uint16 {
 func (node *localNode) GetAPIPort() uint16 {
 	return node.apiPort
 }
+
+// processExit holds how a node's process exited
+type processExit struct {
+	// Closed when the process has exited.
+	// [err] and [exitCode] must not be read before then.
+	closedOnExitCh chan struct{}
+	// The error returned by the process' Wait method
+	err error
+	// The process' exit code, or -1 if unknown
+	exitCode int
+	// Non-nil if the process failed to bind its ports, in which case
+	// it's closed once the node was started again with new ports or
+	// won't be. Set before [closedOnExitCh] is closed.
+	closedOnPortRetryCh chan struct{}
+}
+
+func newProcessExit() *processExit {
+	return &processExit{closedOnExitCh: make(chan struct{})}
+}
+
+// Returns the exit of a process that wasn't started because of [err]
+func newFailedStartExit(err error) *processExit {
+	exit := newProcessExit()
+	exit.err = err
+	exit.exitCode = -1
+	close(exit.closedOnExitCh)
+	return exit
+}
+
+// Returns true if the process has exited
+func (e *processExit) exited() bool {
+	select {
+	case <-e.closedOnExitCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns an error naming node [nodeName] and describing the exit.
+// Should only be called after the process has exited.
+func (e *processExit) unexpectedExitErr(nodeName string) error {
+	if e.err == nil {
+		return fmt.Errorf("node %q exited unexpectedly with exit code %d", nodeName, e.exitCode)
+	}
+	return fmt.Errorf("node %q exited unexpectedly with exit code %d: %w", nodeName, e.exitCode, e.err)
+}
@@ -0,0 +1,150 @@
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ava-labs/avalanche-network-runner/network"
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+// Number of lines of a node's output that can wait to be printed
+// before more lines are dropped
+const outputQueueLines = 1000
+
+// A line of a node's output, as printed when NodeConfig.RedirectJSON is set
+type jsonOutputLine struct {
+	Node   string `json:"node"`
+	Stream string `json:"stream"`
+	// The parts of the line, if it's an avalanchego log line
+	*network.LogRecord
+	// When the line was read from the node
+	ReadAt time.Time `json:"readAt"`
+	Text   string    `json:"text"`
+}
+
+// Prints the lines a node writes to one of its streams
+type outputPrinter struct {
+	nodeName string
+	// network.StdoutStream or network.StderrStream
+	stream string
+	// The color of the lines, unless they're printed as JSON
+	color logging.Color
+	// Only avalanchego log lines that match [filter] are printed.
+	// Other lines, like the stack trace of a panic, are always printed.
+	filter network.LogQuery
+	// If true, lines are printed as JSON objects rather than colored text
+	json bool
+	// Where the lines are printed
+	writer io.Writer
+	// The formatted lines waiting to be printed
+	queue chan string
+	// Number of lines dropped since the last line queued
+	numDropped int
+	// Closed once the lines queued are printed after Close is called
+	doneCh chan struct{}
+}
+
+// Returns the printer of the lines node [nodeName] writes
+// to [stream], which prints them to [writer] as [config] says
+func newOutputPrinter(nodeName string, stream string, color logging.Color, config NodeConfig, writer io.Writer) *outputPrinter {
+	return &outputPrinter{
+		nodeName: nodeName,
+		stream:   stream,
+		color:    color,
+		filter: network.LogQuery{
+			Level:  config.RedirectLevel,
+			Chains: config.RedirectChains,
+		},
+		json:   config.RedirectJSON,
+		writer: writer,
+		queue:  make(chan string, outputQueueLines),
+		doneCh: make(chan struct{}),
+	}
+}
+
+// Starts printing the lines passed to printLine
+func (p *outputPrinter) start() {
+	go func() {
+		defer close(p.doneCh)
+		for formatted := range p.queue {
+			_, _ = p.writer.Write([]byte(formatted))
+		}
+	}()
+}
+
+// Queues the line [text], read at [readAt], to be printed, unless it's
+// filtered out. If [p.writer] is too slow for the queue to have room,
+// the line is dropped, and how many lines were dropped is printed
+// before the next line, so that printing the node's output never holds
+// up reading it. Must not be called concurrently or after Close.
+func (p *outputPrinter) printLine(readAt time.Time, text string) {
+	formatted, ok := p.format(readAt, text)
+	if !ok {
+		return
+	}
+	if p.numDropped > 0 {
+		dropped, _ := p.format(readAt, fmt.Sprintf("(%d lines dropped because they were printed too slowly)", p.numDropped))
+		if !p.enqueue(dropped) {
+			p.numDropped++
+			return
+		}
+		p.numDropped = 0
+	}
+	if !p.enqueue(formatted) {
+		p.numDropped++
+	}
+}
+
+// Returns false if there's no room in [p.queue] for [formatted]
+func (p *outputPrinter) enqueue(formatted string) bool {
+	select {
+	case p.queue <- formatted:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stops queueing lines. The lines already queued are still printed,
+// without waiting for them, so that a slow [p.writer] doesn't hold up
+// the node's exit; [p.doneCh] is closed once they are.
+func (p *outputPrinter) Close() error {
+	close(p.queue)
+	return nil
+}
+
+// Returns how the line [text], read at [readAt], is printed,
+// or false if it's filtered out
+func (p *outputPrinter) format(readAt time.Time, text string) (string, bool) {
+	record, isLogLine := network.ParseLogLine(text, readAt)
+	if isLogLine {
+		line := network.LogLine{
+			Time:   readAt,
+			Stream: p.stream,
+			Level:  record.Level,
+			Chain:  record.Chain,
+			Text:   text,
+		}
+		if !p.filter.Matches(line) {
+			return "", false
+		}
+	}
+	if !p.json {
+		return p.color.Wrap(fmt.Sprintf("[%s] %s\n", p.nodeName, text)), true
+	}
+	jsonLine := jsonOutputLine{
+		Node:   p.nodeName,
+		Stream: p.stream,
+		ReadAt: readAt,
+		Text:   text,
+	}
+	if isLogLine {
+		jsonLine.LogRecord = &record
+	}
+	// Can't fail, since every field can be marshalled
+	formatted, _ := json.Marshal(jsonLine)
+	return string(formatted) + "\n", true
+}
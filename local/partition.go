@@ -0,0 +1,412 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanche-network-runner/network"
+	"github.com/ava-labs/avalanchego/config"
+	avalancheconstants "github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+const (
+	// IP that nodes advertise to their peers when fault injection is
+	// enabled. AvalancheGo listens on its staking port on all interfaces,
+	// and advertises that port, so any address of this host with that
+	// port would reach the node without going through a proxy. This IP
+	// is reserved for documentation (RFC 5737), so it isn't this host's,
+	// and peers that learn it through gossip can't reach the node with it.
+	// Nodes then only connect to each other through the proxies.
+	proxiedPublicIP = "192.0.2.1"
+	// Ping timeout given to nodes when fault injection is enabled, unless
+	// their flags give one. AvalancheGo only dials a peer again at the
+	// address the peer advertises, which can't be reached, so nodes whose
+	// connection is dropped don't reconnect. A partition holds traffic
+	// rather than dropping it, so it mustn't make nodes time out their
+	// connections.
+	proxiedPingTimeout = 24 * time.Hour
+	proxyDialTimeout   = 5 * time.Second
+	proxyBufferSize    = 32 * 1024
+	// Number of chunks of traffic a proxy holds for each direction
+	// of a connection before it stops reading from the sender
+	proxyQueueSize = 1024
+)
+
+// Returned by Partition and Heal if the network
+// wasn't created with fault injection enabled
+var ErrNoFaultInjection = errors.New("network wasn't created with fault injection enabled")
+
+// A direction in which a node connects, or sends traffic, to another node
+type link struct {
+	// Name of the node that connects or sends
+	src string
+	// Name of the node it connects or sends to
+	dst string
+}
+
+// A chunk of traffic relayed by a proxy
+type proxyChunk struct {
+	data []byte
+	// When to deliver [data]
+	deliverAt time.Time
+}
+
+// Relays the TCP connections from a node to another node
+type linkProxy struct {
+	listener net.Listener
+	// Address of the destination node's staking port
+	dstAddr string
+	// Connections accepted by [listener] and the
+	// connections to [dstAddr] they're relayed to
+	conns map[net.Conn]struct{}
+	// True if this proxy was closed
+	closed bool
+}
+
+// The proxies that the nodes of a network with fault injection
+// enabled connect to each other through, one for each link.
+// Traffic on a link between nodes in different groups of the
+// current partition is held by its proxy until the partition
+// is healed. Connections are never closed because of a partition,
+// since nodes don't reconnect through the proxies. Traffic is also
+// shaped by the conditions of the link it's sent over.
+type linkProxies struct {
+	log  logging.Logger
+	lock sync.Mutex
+	// Broadcast when the partition changes or a proxy is closed
+	cond    *sync.Cond
+	proxies map[link]*linkProxy
+	// Node name --> Index of its group in the current partition.
+	// Nil if the network isn't partitioned.
+	groups map[string]int
+	// Conditions of the links whose traffic isn't
+	// shaped by [defaultConditions]
+	conditions        map[link]LinkConditions
+	defaultConditions LinkConditions
+}
+
+func newLinkProxies(log logging.Logger) *linkProxies {
+	p := &linkProxies{
+		log:        log,
+		proxies:    map[link]*linkProxy{},
+		conditions: map[link]LinkConditions{},
+	}
+	p.cond = sync.NewCond(&p.lock)
+	return p
+}
+
+// Returns the address that the node named [src] connects to in order
+// to reach the node named [dst], whose staking port is [dstPort].
+// Starts a proxy for the link if there isn't one.
+func (p *linkProxies) addr(src string, dst string, dstPort uint16) (string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	l := link{src: src, dst: dst}
+	if proxy, ok := p.proxies[l]; ok {
+		return proxy.listener.Addr().String(), nil
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("couldn't start proxy from node %q to node %q: %w", src, dst, err)
+	}
+	proxy := &linkProxy{
+		listener: listener,
+		dstAddr:  fmt.Sprintf("127.0.0.1:%d", dstPort),
+		conns:    map[net.Conn]struct{}{},
+	}
+	p.proxies[l] = proxy
+	p.log.Debug("proxying connections from node %q to node %q through %s", src, dst, listener.Addr())
+	go p.accept(l, proxy)
+	return listener.Addr().String(), nil
+}
+
+// Accepts connections to [proxy] until it's closed
+func (p *linkProxies) accept(l link, proxy *linkProxy) {
+	for {
+		conn, err := proxy.listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.relayConn(l, proxy, conn)
+	}
+}
+
+// Connects to the destination of [proxy] and relays
+// the traffic between it and [conn] in both directions
+func (p *linkProxies) relayConn(l link, proxy *linkProxy, conn net.Conn) {
+	dstConn, err := net.DialTimeout("tcp", proxy.dstAddr, proxyDialTimeout)
+	if err != nil {
+		p.log.Debug("proxy from node %q to node %q couldn't connect to %s: %s", l.src, l.dst, proxy.dstAddr, err)
+		_ = conn.Close()
+		return
+	}
+	p.lock.Lock()
+	if proxy.closed {
+		p.lock.Unlock()
+		_ = conn.Close()
+		_ = dstConn.Close()
+		return
+	}
+	proxy.conns[conn] = struct{}{}
+	proxy.conns[dstConn] = struct{}{}
+	p.lock.Unlock()
+
+	closeConns := func() {
+		p.lock.Lock()
+		delete(proxy.conns, conn)
+		delete(proxy.conns, dstConn)
+		p.lock.Unlock()
+		_ = conn.Close()
+		_ = dstConn.Close()
+	}
+	go func() {
+		p.relay(l, proxy, dstConn, conn)
+		closeConns()
+	}()
+	p.relay(link{src: l.dst, dst: l.src}, proxy, conn, dstConn)
+	closeConns()
+}
+
+// Copies the traffic sent over [dir] from [src] to [dst] until either
+// is closed, shaping it by the conditions of [dir] and holding it while
+// the nodes of [dir] are partitioned
+func (p *linkProxies) relay(dir link, proxy *linkProxy, dst net.Conn, src net.Conn) {
+	chunks := make(chan proxyChunk, proxyQueueSize)
+	// Closed when [dst] can't be written to anymore
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for chunk := range chunks {
+			time.Sleep(time.Until(chunk.deliverAt))
+			if !p.waitUntilConnected(dir, proxy) {
+				return
+			}
+			if _, err := dst.Write(chunk.data); err != nil {
+				// Stop reading from [src]
+				_ = src.Close()
+				return
+			}
+		}
+	}()
+
+	// When the last chunk read is transmitted and delivered
+	var transmittedAt, deliverAt time.Time
+	buf := make([]byte, proxyBufferSize)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			conditions := p.conditionsOf(dir)
+			now := time.Now()
+			if transmittedAt.Before(now) {
+				transmittedAt = now
+			}
+			transmittedAt = transmittedAt.Add(conditions.transmissionTime(n))
+			// The sender can't send more until this chunk is transmitted
+			time.Sleep(time.Until(transmittedAt))
+			// Traffic is delivered in order
+			if chunkDeliverAt := transmittedAt.Add(conditions.delay()); chunkDeliverAt.After(deliverAt) {
+				deliverAt = chunkDeliverAt
+			}
+			data := make([]byte, n)
+			copy(data, buf[:n])
+			select {
+			case chunks <- proxyChunk{data: data, deliverAt: deliverAt}:
+			case <-doneCh:
+				return
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	// Deliver what was read before [src] was closed
+	close(chunks)
+	<-doneCh
+}
+
+// Waits until the nodes of [l] aren't partitioned.
+// Returns false if [proxy] was closed first.
+func (p *linkProxies) waitUntilConnected(l link, proxy *linkProxy) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for !proxy.closed && p.isPartitioned(l) {
+		p.cond.Wait()
+	}
+	return !proxy.closed
+}
+
+// Returns true if the nodes of [l] are in different groups.
+// Nodes that aren't in any group are in the same group.
+// Assumes [p.lock] is held.
+func (p *linkProxies) isPartitioned(l link) bool {
+	if p.groups == nil {
+		return false
+	}
+	srcGroup, ok := p.groups[l.src]
+	if !ok {
+		srcGroup = -1
+	}
+	dstGroup, ok := p.groups[l.dst]
+	if !ok {
+		dstGroup = -1
+	}
+	return srcGroup != dstGroup
+}
+
+// Returns the conditions of [dir]
+func (p *linkProxies) conditionsOf(dir link) LinkConditions {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if conditions, ok := p.conditions[dir]; ok {
+		return conditions
+	}
+	return p.defaultConditions
+}
+
+// Sets the conditions of [dir]
+func (p *linkProxies) setConditions(dir link, conditions LinkConditions) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.conditions[dir] = conditions
+}
+
+// Removes the conditions of [dir], so it has the default conditions again
+func (p *linkProxies) clearConditions(dir link) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.conditions, dir)
+}
+
+// Sets the conditions of the links that have no conditions of their own
+func (p *linkProxies) setDefaultConditions(conditions LinkConditions) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.defaultConditions = conditions
+}
+
+// Partitions the network into [groups], replacing the current partition.
+// If [groups] is nil, heals the network.
+func (p *linkProxies) partition(groups map[string]int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.groups = groups
+	p.cond.Broadcast()
+}
+
+// Closes the proxies of the links to and from the node named [nodeName],
+// along with their connections. Does nothing if [p] is nil.
+func (p *linkProxies) closeNode(nodeName string) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for l, proxy := range p.proxies {
+		if l.src != nodeName && l.dst != nodeName {
+			continue
+		}
+		proxy.closed = true
+		_ = proxy.listener.Close()
+		for conn := range proxy.conns {
+			_ = conn.Close()
+		}
+		delete(p.proxies, l)
+	}
+	p.cond.Broadcast()
+}
+
+// Returns the bootstrap IPs and IDs flags to give [node] when fault
+// injection is enabled. A node bootstraps from, and so connects to,
+// every other node in this network through the proxy of that link.
+// Since nodes are started one at a time, this connects every pair
+// of nodes. Also returns the flags that keep nodes from connecting
+// to each other in any other way.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) proxiedFlags(node *localNode) ([]string, error) {
+	peerNames := make([]string, 0, len(ln.nodes))
+	for peerName := range ln.nodes {
+		if peerName != node.name {
+			peerNames = append(peerNames, peerName)
+		}
+	}
+	sort.Strings(peerNames)
+	// The IPs and IDs must be in the same order
+	bootstrapIPs := make([]string, 0, len(peerNames))
+	bootstrapIDs := make([]string, 0, len(peerNames))
+	for _, peerName := range peerNames {
+		peer := ln.nodes[peerName]
+		addr, err := ln.proxies.addr(node.name, peerName, peer.p2pPort)
+		if err != nil {
+			return nil, err
+		}
+		bootstrapIPs = append(bootstrapIPs, addr)
+		bootstrapIDs = append(bootstrapIDs, peer.nodeID.PrefixedString(avalancheconstants.NodeIDPrefix))
+	}
+	flags := []string{
+		fmt.Sprintf("--%s=%s", config.BootstrapIPsKey, strings.Join(bootstrapIPs, ",")),
+		fmt.Sprintf("--%s=%s", config.BootstrapIDsKey, strings.Join(bootstrapIDs, ",")),
+		fmt.Sprintf("--%s=%s", config.PublicIPKey, proxiedPublicIP),
+	}
+	if _, ok := node.config.Flags[config.NetworkPingTimeoutKey]; !ok {
+		flags = append(flags, fmt.Sprintf("--%s=%s", config.NetworkPingTimeoutKey, proxiedPingTimeout))
+	}
+	return flags, nil
+}
+
+// See Network
+func (ln *localNetwork) Partition(groups ...[]string) error {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	if ln.proxies == nil {
+		return ErrNoFaultInjection
+	}
+	if ln.isStopped() {
+		return network.ErrStopped
+	}
+	nodeGroups := map[string]int{}
+	for i, group := range groups {
+		for _, nodeName := range group {
+			if _, ok := ln.nodes[nodeName]; !ok {
+				return fmt.Errorf("node %q not found", nodeName)
+			}
+			if _, ok := nodeGroups[nodeName]; ok {
+				return fmt.Errorf("node %q is in more than one group", nodeName)
+			}
+			nodeGroups[nodeName] = i
+		}
+	}
+	ln.log.Info("partitioning network into %v", groups)
+	ln.proxies.partition(nodeGroups)
+	return nil
+}
+
+// See Network
+func (ln *localNetwork) Heal() error {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	if ln.proxies == nil {
+		return ErrNoFaultInjection
+	}
+	if ln.isStopped() {
+		return network.ErrStopped
+	}
+	ln.log.Info("healing network partition")
+	ln.proxies.partition(nil)
+	return nil
+}
@@ -0,0 +1,159 @@
+package local
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/ava-labs/avalanche-network-runner/network"
+)
+
+const (
+	defaultMinPort = 10000
+	defaultMaxPort = math.MaxUint16
+	// Number of times a node is started again with new ports
+	// after its process fails to bind the ports it was given
+	maxPortRetries = 3
+)
+
+// Gives the nodes of a network API and P2P ports from a range, never
+// giving out a port that's assigned to another node of the network.
+// A port is only given out if it can be bound to, but another process
+// can still take it before the node binds it, in which case the node
+// is started again with new ports.
+type portAllocator struct {
+	lock sync.Mutex
+	// The range this allocator was created with
+	portRange network.PortRange
+	min, max  uint16
+	// Ports assigned to this network's nodes
+	assigned map[uint16]struct{}
+	// The next port to try to give out
+	next uint16
+}
+
+// Returns an allocator of the ports in [portRange]. If the range is the
+// default one, ports are given out starting at a random one, so that
+// networks in different processes are unlikely to try the same ports.
+// Otherwise they're given out starting at the range's min port.
+// Assumes [portRange] is valid.
+func newPortAllocator(portRange network.PortRange) *portAllocator {
+	a := &portAllocator{
+		portRange: portRange,
+		min:       portRange.Min,
+		max:       portRange.Max,
+		assigned:  map[uint16]struct{}{},
+	}
+	if a.min == 0 {
+		a.min = defaultMinPort
+	}
+	if a.max == 0 {
+		a.max = defaultMaxPort
+	}
+	a.next = a.min
+	if portRange == (network.PortRange{}) {
+		a.next = uint16(rand.Intn(int(a.max)-int(a.min)+1) + int(a.min))
+	}
+	return a
+}
+
+// Returns a free port in the range that isn't assigned,
+// and assigns it
+func (a *portAllocator) allocate() (uint16, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	numPorts := int(a.max) - int(a.min) + 1
+	for i := 0; i < numPorts; i++ {
+		port := a.next
+		if a.next == a.max {
+			a.next = a.min
+		} else {
+			a.next++
+		}
+		if _, ok := a.assigned[port]; ok {
+			continue
+		}
+		if !isFreePort(port) {
+			// Couldn't bind to this port. Try another.
+			continue
+		}
+		a.assigned[port] = struct{}{}
+		return port, nil
+	}
+	return 0, fmt.Errorf("no free port in [%d, %d]", a.min, a.max)
+}
+
+// Assigns [port], which may be outside the range, e.g. because it was
+// given in a node's config. Returns an error if it's already assigned.
+func (a *portAllocator) reserve(port uint16) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if _, ok := a.assigned[port]; ok {
+		return fmt.Errorf("port %d is already used by another node", port)
+	}
+	a.assigned[port] = struct{}{}
+	return nil
+}
+
+// Returns true if [port] isn't assigned and is free
+func (a *portAllocator) isAvailable(port uint16) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	_, ok := a.assigned[port]
+	return !ok && isFreePort(port)
+}
+
+// Unassigns [ports] so that they can be given out again
+func (a *portAllocator) release(ports ...uint16) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	for _, port := range ports {
+		delete(a.assigned, port)
+	}
+}
+
+// Returns true if [port] is free, which it is if it can be bound to.
+// It's bound on all interfaces (":[port]"), so a port that's in use on
+// any single address, e.g. 127.0.0.2, isn't considered free.
+func isFreePort(port uint16) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	_ = l.Close()
+	return true
+}
+
+// Returns true if [node]'s current process, which exited with
+// [exitErr], did so because a port it was given was already in use.
+// AvalancheGo logs the error to its stdout and stderr right before it
+// exits, and only the last lines of stderr are in [exitErr]. So only the
+// last [stderrTailLines] lines the current process wrote are checked,
+// and not earlier lines or lines written by the node's previous processes.
+func failedToBindPort(node *localNode, exitErr error) bool {
+	if exitErr == nil {
+		return false
+	}
+	bindErr := "bind: " + syscall.EADDRINUSE.Error()
+	if strings.Contains(exitErr.Error(), bindErr) {
+		return true
+	}
+	lines, _, _ := node.logs.linesAfter(0)
+	if len(lines) > stderrTailLines {
+		lines = lines[len(lines)-stderrTailLines:]
+	}
+	for _, line := range lines {
+		if strings.Contains(line.Text, bindErr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,281 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ava-labs/avalanche-network-runner/api"
+	"github.com/ava-labs/avalanche-network-runner/network"
+	"github.com/ava-labs/avalanche-network-runner/network/node"
+	"github.com/ava-labs/avalanche-network-runner/utils"
+	"github.com/ava-labs/avalanchego/config"
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+const (
+	// Written to the root of each snapshot directory.
+	// Holds the network.Config of the snapshotted network.
+	snapshotManifestFileName = "network.json"
+	snapshotsDirName         = "snapshots"
+	runnerDirName            = ".avalanche-network-runner"
+)
+
+// Returns the directory under which snapshots are saved by default:
+// ~/.avalanche-network-runner/snapshots
+func defaultSnapshotsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("couldn't get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, runnerDirName, snapshotsDirName), nil
+}
+
+// See Network
+func (ln *localNetwork) SaveSnapshot(ctx context.Context, snapshotName string) (string, error) {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	if ln.isStopped() {
+		return "", network.ErrStopped
+	}
+	if len(snapshotName) == 0 || snapshotName != filepath.Base(snapshotName) {
+		return "", fmt.Errorf("invalid snapshot name %q", snapshotName)
+	}
+	snapshotsDir := ln.snapshotsDir
+	if snapshotsDir == "" {
+		var err error
+		snapshotsDir, err = defaultSnapshotsDir()
+		if err != nil {
+			return "", err
+		}
+	}
+	snapshotDir := filepath.Join(snapshotsDir, snapshotName)
+	if _, err := os.Stat(snapshotDir); err == nil {
+		return "", fmt.Errorf("snapshot %q already exists at %s", snapshotName, snapshotDir)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("couldn't check snapshot directory %s: %w", snapshotDir, err)
+	}
+
+	// [stop] forgets the nodes, so remember them first
+	nodes := make([]*localNode, 0, len(ln.nodes))
+	for _, node := range ln.nodes {
+		nodes = append(nodes, node)
+	}
+	// Beacons first so they start first on restore
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].config.IsBeacon && !nodes[j].config.IsBeacon
+	})
+	networkConfig := network.Config{
+		Genesis:     string(ln.genesis),
+		NodeConfigs: make([]node.Config, 0, len(nodes)),
+		Backend:     network.Local,
+		Flags:       ln.flags,
+
+		FundedPrivateKey: ln.fundedPrivateKey,
+		CleanupPolicy:    ln.cleanupPolicy,
+		FaultInjection:   ln.proxies != nil,
+		Ports:            ln.ports.portRange,
+	}
+	for _, node := range nodes {
+		nodeConfig, err := snapshotNodeConfig(node.config)
+		if err != nil {
+			return "", fmt.Errorf("couldn't snapshot config of node %q: %w", node.name, err)
+		}
+		networkConfig.NodeConfigs = append(networkConfig.NodeConfigs, nodeConfig)
+	}
+	manifest, err := json.MarshalIndent(networkConfig, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("couldn't marshal network config: %w", err)
+	}
+
+	// The nodes' databases must not change while we copy them
+	ln.log.Info("stopping network to save snapshot %q", snapshotName)
+	stopErr := ln.stop(ctx)
+	// The network's directories may only be removed once they're copied,
+	// or once stopping the network failed, as they would be by Stop
+	defer func() {
+		if err := ln.cleanupOnStop(stopErr); err != nil {
+			ln.log.Error("couldn't clean up network: %s", err)
+		}
+	}()
+	if stopErr != nil {
+		return "", fmt.Errorf("couldn't stop network: %w", stopErr)
+	}
+
+	ln.log.Info("saving snapshot %q to %s", snapshotName, snapshotDir)
+	for _, node := range nodes {
+		nodeSnapshotDir := filepath.Join(snapshotDir, node.name)
+		// Logs aren't part of the snapshot
+		logPaths := []string{
+			node.logsDir,
+			filepath.Join(node.rootDir, stdoutLogFileName),
+			filepath.Join(node.rootDir, stderrLogFileName),
+		}
+		// Staking key/cert, config files, genesis and, by default, the database
+		if err := copyDir(ctx, node.rootDir, nodeSnapshotDir, append(logPaths, node.dbDir)...); err != nil {
+			_ = os.RemoveAll(snapshotDir)
+			return "", fmt.Errorf("couldn't copy directory of node %q: %w", node.name, err)
+		}
+		// The database was given in the node's config file.
+		// Put it where the restored node will look for it.
+		if filepath.Clean(node.dbDir) != filepath.Clean(node.rootDir) {
+			if err := copyDir(ctx, node.dbDir, nodeSnapshotDir, logPaths...); err != nil {
+				_ = os.RemoveAll(snapshotDir)
+				return "", fmt.Errorf("couldn't copy database of node %q: %w", node.name, err)
+			}
+		}
+	}
+	if err := createFileAndWrite(filepath.Join(snapshotDir, snapshotManifestFileName), manifest); err != nil {
+		_ = os.RemoveAll(snapshotDir)
+		return "", fmt.Errorf("couldn't write snapshot manifest: %w", err)
+	}
+	return snapshotDir, nil
+}
+
+// Returns a copy of [nodeConfig] whose config file doesn't give a
+// database or logs directory, so that a node restored from a snapshot
+// keeps its database and logs in its own root directory.
+func snapshotNodeConfig(nodeConfig node.Config) (node.Config, error) {
+	if len(nodeConfig.ConfigFile) == 0 {
+		return nodeConfig, nil
+	}
+	var configFile map[string]interface{}
+	if err := json.Unmarshal([]byte(nodeConfig.ConfigFile), &configFile); err != nil {
+		return node.Config{}, fmt.Errorf("couldn't unmarshal config file: %w", err)
+	}
+	_, hasDBPath := configFile[config.DBPathKey]
+	_, hasLogsDir := configFile[config.LogsDirKey]
+	if !hasDBPath && !hasLogsDir {
+		return nodeConfig, nil
+	}
+	delete(configFile, config.DBPathKey)
+	delete(configFile, config.LogsDirKey)
+	configFileBytes, err := json.Marshal(configFile)
+	if err != nil {
+		return node.Config{}, fmt.Errorf("couldn't marshal config file: %w", err)
+	}
+	nodeConfig.ConfigFile = string(configFileBytes)
+	return nodeConfig, nil
+}
+
+// LoadSnapshot starts a new network from the snapshot in [snapshotDir],
+// which was written by SaveSnapshot. The snapshot itself isn't modified;
+// each node runs on a copy of its snapshotted directory, in a new
+// network root directory.
+func LoadSnapshot(log logging.Logger, snapshotDir string) (Network, error) {
+	return loadSnapshot(log, snapshotDir, api.NewAPIClient, &nodeProcessCreator{
+		colorPicker: utils.NewColorPicker(),
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+	})
+}
+
+func loadSnapshot(
+	log logging.Logger,
+	snapshotDir string,
+	newAPIClientF api.NewAPIClientF,
+	nodeProcessCreator NodeProcessCreator,
+) (Network, error) {
+	manifest, err := os.ReadFile(filepath.Join(snapshotDir, snapshotManifestFileName))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read snapshot manifest: %w", err)
+	}
+	var networkConfig network.Config
+	if err := json.Unmarshal(manifest, &networkConfig); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal snapshot manifest: %w", err)
+	}
+
+	rootDir, err := os.MkdirTemp("", "avalanche-network-runner-*")
+	if err != nil {
+		return nil, err
+	}
+	log.Info("loading snapshot %s into %s", snapshotDir, rootDir)
+	for _, nodeConfig := range networkConfig.NodeConfigs {
+		if err := copyDir(
+			context.Background(),
+			filepath.Join(snapshotDir, nodeConfig.Name),
+			filepath.Join(rootDir, nodeConfig.Name),
+		); err != nil {
+			_ = os.RemoveAll(rootDir)
+			return nil, fmt.Errorf("couldn't copy snapshot of node %q: %w", nodeConfig.Name, err)
+		}
+	}
+	net, err := newNetwork(log, networkConfig, newAPIClientF, nodeProcessCreator, rootDir)
+	if err != nil {
+		// The network's nodes were stopped, and no one else knows of [rootDir]
+		_ = os.RemoveAll(rootDir)
+		return nil, err
+	}
+	return net, nil
+}
+
+// Recursively copies the contents of [srcDir] into [dstDir],
+// creating [dstDir] if needed. Files and directories in [skipPaths]
+// other than [srcDir] itself aren't copied. Only regular files and
+// directories are copied.
+func copyDir(ctx context.Context, srcDir string, dstDir string, skipPaths ...string) error {
+	skip := make(map[string]struct{}, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[filepath.Clean(path)] = struct{}{}
+	}
+	delete(skip, filepath.Clean(srcDir))
+	return filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if _, ok := skip[filepath.Clean(path)]; ok {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(dstDir, relPath)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(dstPath, 0o755)
+		case d.Type().IsRegular():
+			return copyFile(path, dstPath)
+		default:
+			return nil
+		}
+	})
+}
+
+// Copies the regular file at [srcPath] to [dstPath],
+// keeping its permissions.
+func copyFile(srcPath string, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,492 @@
+package local
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ava-labs/avalanche-network-runner/api"
+	"github.com/ava-labs/avalanche-network-runner/network"
+	"github.com/ava-labs/avalanche-network-runner/network/node"
+	"github.com/ava-labs/avalanche-network-runner/utils"
+	"github.com/ava-labs/avalanchego/ids"
+	avalancheconstants "github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+const (
+	// Written to the root directory of each network.
+	// Holds the network's networkState.
+	stateFileName = "network-state.json"
+	// The state has secrets, so only its owner can read it
+	stateFilePerms = 0o600
+	// Time between checks of whether an attached process exited
+	attachedProcessCheckFreq = 500 * time.Millisecond
+)
+
+// Returned by the Wait method of processes that aren't children
+// of this process, whose exit status can't be known
+var errExitStatusUnknown = errors.New("exit status of a process started by another process is unknown")
+
+var _ NodeProcess = (*attachedProcess)(nil)
+
+// The state of a local network. Written to [stateFileName] in the
+// network's root directory whenever it changes, so that other
+// processes can attach to the network, and so that a stopped
+// network can be resumed from its root directory.
+// It has secrets: the funded private key and the nodes' staking keys.
+type networkState struct {
+	// True if the network was stopped, in which case
+	// its nodes are the ones that were running then
+	Stopped          bool                   `json:"stopped"`
+	NetworkID        uint32                 `json:"networkID"`
+	Genesis          string                 `json:"genesis"`
+	Flags            map[string]interface{} `json:"flags,omitempty"`
+	FundedPrivateKey string                 `json:"fundedPrivateKey,omitempty"`
+	CleanupPolicy    network.CleanupPolicy  `json:"cleanupPolicy,omitempty"`
+	FaultInjection   bool                   `json:"faultInjection,omitempty"`
+	Ports            network.PortRange      `json:"ports,omitempty"`
+	// The IPs and node IDs of the beacons
+	BootstrapIPs   []string    `json:"bootstrapIPs"`
+	BootstrapIDs   []string    `json:"bootstrapIDs"`
+	NextNodeSuffix uint64      `json:"nextNodeSuffix"`
+	Nodes          []nodeState `json:"nodes"`
+}
+
+// The state of a node in a local network
+type nodeState struct {
+	Name   string      `json:"name"`
+	NodeID string      `json:"nodeID"`
+	Config node.Config `json:"config"`
+	// ID of the node's process.
+	// 0 if the network is stopped.
+	PID int `json:"pid,omitempty"`
+	// When the node's process started, in clock ticks since boot.
+	// 0 if the network is stopped or it's unknown.
+	StartTime uint64 `json:"startTime,omitempty"`
+	Paused    bool   `json:"paused"`
+	APIPort   uint16 `json:"apiPort"`
+	P2PPort   uint16 `json:"p2pPort"`
+	RootDir   string `json:"rootDir"`
+	DBDir     string `json:"dbDir"`
+	LogsDir   string `json:"logsDir"`
+	// The flags the node's process was started with
+	Flags []string `json:"flags"`
+}
+
+// Writes this network's state to [stateFileName] in [ln.rootDir].
+// Failing to do so only means other processes can't attach to the
+// network or resume its nodes' ports, so errors are logged rather
+// than returned.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) writeState() {
+	stopped := ln.isStopped()
+	nodes := ln.nodes
+	if stopped {
+		nodes = ln.stoppedNodes
+	}
+	state := networkState{
+		Stopped:          stopped,
+		NetworkID:        ln.networkID,
+		Genesis:          string(ln.genesis),
+		Flags:            ln.flags,
+		FundedPrivateKey: ln.fundedPrivateKey,
+		CleanupPolicy:    ln.cleanupPolicy,
+		FaultInjection:   ln.proxies != nil,
+		Ports:            ln.ports.portRange,
+		BootstrapIPs:     make([]string, 0, len(ln.bootstrapIPs)),
+		BootstrapIDs:     make([]string, 0, len(ln.bootstrapIDs)),
+		NextNodeSuffix:   ln.nextNodeSuffix,
+		Nodes:            make([]nodeState, 0, len(nodes)),
+	}
+	for ip := range ln.bootstrapIPs {
+		state.BootstrapIPs = append(state.BootstrapIPs, ip)
+	}
+	for id := range ln.bootstrapIDs {
+		state.BootstrapIDs = append(state.BootstrapIDs, id)
+	}
+	sort.Strings(state.BootstrapIPs)
+	sort.Strings(state.BootstrapIDs)
+	for _, node := range nodes {
+		nodeState := nodeState{
+			Name:    node.name,
+			NodeID:  node.nodeID.PrefixedString(avalancheconstants.NodeIDPrefix),
+			Config:  node.config,
+			Paused:  node.paused,
+			APIPort: node.apiPort,
+			P2PPort: node.p2pPort,
+			RootDir: node.rootDir,
+			DBDir:   node.dbDir,
+			LogsDir: node.logsDir,
+			Flags:   node.flags,
+		}
+		if !stopped {
+			nodeState.PID = node.process.PID()
+			nodeState.StartTime = node.startTime
+		}
+		state.Nodes = append(state.Nodes, nodeState)
+	}
+	sort.Slice(state.Nodes, func(i, j int) bool {
+		return state.Nodes[i].Name < state.Nodes[j].Name
+	})
+	stateBytes, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		ln.log.Error("couldn't marshal network state: %s", err)
+		return
+	}
+	// Write to a temporary file first so that readers
+	// never see a partially written state
+	statePath := filepath.Join(ln.rootDir, stateFileName)
+	tmpPath := statePath + ".tmp"
+	if err := writePrivateFile(tmpPath, stateBytes); err != nil {
+		ln.log.Error("couldn't write network state: %s", err)
+		return
+	}
+	if err := os.Rename(tmpPath, statePath); err != nil {
+		ln.log.Error("couldn't write network state: %s", err)
+	}
+}
+
+// Writes [contents] to a file at [path] that only its owner can read,
+// even if the file already exists
+func writePrivateFile(path string, contents []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, stateFilePerms)
+	if err != nil {
+		return err
+	}
+	// The file's permissions aren't changed by OpenFile if it existed
+	if err := file.Chmod(stateFilePerms); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if _, err := file.Write(contents); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Returns the network state in [rootDir]
+func readState(rootDir string) (networkState, error) {
+	var state networkState
+	stateBytes, err := os.ReadFile(filepath.Join(rootDir, stateFileName))
+	if err != nil {
+		return state, fmt.Errorf("couldn't read network state: %w", err)
+	}
+	if err := json.Unmarshal(stateBytes, &state); err != nil {
+		return state, fmt.Errorf("couldn't unmarshal network state: %w", err)
+	}
+	return state, nil
+}
+
+// If [ln.rootDir] has the state of a network that was stopped, sets
+// [ln.previousNodes] so that the nodes resumed from their directories
+// keep their ports. Returns an error if that network is still running
+// or has a different genesis, since the nodes' databases are only valid
+// for that genesis.
+// Assumes [ln.lock] is held.
+func (ln *localNetwork) readPreviousState() error {
+	state, err := readState(ln.rootDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// A network that wasn't stopped may have had its process killed
+	if !state.Stopped {
+		for _, nodeState := range state.Nodes {
+			if nodeState.PID > 0 && processExists(nodeState.PID) && startedAt(nodeState.PID, nodeState.StartTime) {
+				return fmt.Errorf("the network in %s is running; use AttachNetwork to attach to it", ln.rootDir)
+			}
+		}
+	}
+	if state.Genesis != string(ln.genesis) {
+		return fmt.Errorf("the network previously in %s has a different genesis", ln.rootDir)
+	}
+	ln.previousNodes = make(map[string]nodeState, len(state.Nodes))
+	for _, nodeState := range state.Nodes {
+		ln.previousNodes[nodeState.Name] = nodeState
+	}
+	return nil
+}
+
+// AttachNetwork returns a network made of the nodes that are still
+// running in the network whose root directory is [rootDir], which was
+// started by this or another process. The returned network can add,
+// remove, restart and stop nodes like the original one. Only one
+// process should change the network at a time. A stopped network
+// can't be attached to; use NewNetworkWithDir to resume it instead.
+// Neither can a network with fault injection enabled, since its nodes
+// connect to each other through proxies run by the original process.
+// The network's state is read from network-state.json in [rootDir], which
+// only the user who started the network can read, since it has the
+// funded private key and the nodes' staking keys.
+// Since the nodes aren't children of this process, their exit codes
+// are unknown. Their output is still appended to the log files in their
+// root directories, and the lines they write from now on are kept in
+// memory, but it's only printed by the process that started them.
+func AttachNetwork(log logging.Logger, rootDir string) (Network, error) {
+	return attachNetwork(
+		log,
+		rootDir,
+		api.NewAPIClient,
+		&nodeProcessCreator{
+			colorPicker: utils.NewColorPicker(),
+			stdout:      os.Stdout,
+			stderr:      os.Stderr,
+		},
+		newAttachedProcess,
+	)
+}
+
+func attachNetwork(
+	log logging.Logger,
+	rootDir string,
+	newAPIClientF api.NewAPIClientF,
+	nodeProcessCreator NodeProcessCreator,
+	attachProcess func(pid int, startTime uint64) (NodeProcess, error),
+) (Network, error) {
+	state, err := readState(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if state.Stopped {
+		return nil, fmt.Errorf("the network in %s is stopped", rootDir)
+	}
+	if state.FaultInjection {
+		return nil, fmt.Errorf("the network in %s has fault injection enabled", rootDir)
+	}
+
+	ln := &localNetwork{
+		networkID:          state.NetworkID,
+		genesis:            []byte(state.Genesis),
+		nodes:              map[string]*localNode{},
+		closedOnStopCh:     make(chan struct{}),
+		log:                log,
+		bootstrapIPs:       make(beaconList, len(state.BootstrapIPs)),
+		bootstrapIDs:       make(beaconList, len(state.BootstrapIDs)),
+		newAPIClientF:      newAPIClientF,
+		nodeProcessCreator: nodeProcessCreator,
+		nextNodeSuffix:     state.NextNodeSuffix,
+		rootDir:            rootDir,
+		flags:              state.Flags,
+		events:             network.NewEventBroker(),
+		fundedPrivateKey:   state.FundedPrivateKey,
+		cleanupPolicy:      state.CleanupPolicy,
+		stopGracePeriod:    defaultStopGracePeriod,
+		healthCheckFreq:    defaultHealthCheckFreq,
+		ports:              newPortAllocator(state.Ports),
+	}
+	for _, ip := range state.BootstrapIPs {
+		ln.bootstrapIPs[ip] = struct{}{}
+	}
+	for _, id := range state.BootstrapIDs {
+		ln.bootstrapIDs[id] = struct{}{}
+	}
+
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	for _, nodeState := range state.Nodes {
+		nodeID, err := ids.ShortFromPrefixedString(nodeState.NodeID, avalancheconstants.NodeIDPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse ID of node %q: %w", nodeState.Name, err)
+		}
+		process, err := attachProcess(nodeState.PID, nodeState.StartTime)
+		if err != nil {
+			log.Warn("not attaching to node %q: %s", nodeState.Name, err)
+			continue
+		}
+		node := &localNode{
+			name:      nodeState.Name,
+			nodeID:    nodeID,
+			config:    nodeState.Config,
+			client:    newAPIClientF("localhost", nodeState.APIPort),
+			process:   process,
+			startTime: nodeState.StartTime,
+			flags:     nodeState.Flags,
+			paused:    nodeState.Paused,
+			exit:      newProcessExit(),
+			rootDir:   nodeState.RootDir,
+			dbDir:     nodeState.DBDir,
+			logsDir:   nodeState.LogsDir,
+			apiPort:   nodeState.APIPort,
+			p2pPort:   nodeState.P2PPort,
+			logs:      newNodeLogs(),
+		}
+		// Keep the lines the node writes from now on
+		closeOnExit, err := tailNodeLogs(
+			node,
+			fileSizeAt(filepath.Join(node.rootDir, stdoutLogFileName)),
+			fileSizeAt(filepath.Join(node.rootDir, stderrLogFileName)),
+		)
+		if err != nil {
+			log.Warn("not keeping the output of node %q: %s", node.name, err)
+		}
+		go ln.superviseNode(node, process, node.exit, closeOnExit)
+		// Nodes' ports are distinct, so this can't fail
+		_ = ln.ports.reserve(node.apiPort)
+		_ = ln.ports.reserve(node.p2pPort)
+		ln.nodes[node.name] = node
+		ln.keepUserPaths(node)
+		log.Info("attached to node %q with PID %d", node.name, nodeState.PID)
+	}
+	if len(ln.nodes) == 0 {
+		return nil, fmt.Errorf("no node of the network at %s is running", rootDir)
+	}
+	// Forget the nodes that aren't running
+	ln.writeState()
+	return ln, nil
+}
+
+// A node process that was started by another process.
+// Since it isn't a child of this process, it can't be waited
+// for, so its Wait method polls until it no longer exists
+// or is a zombie.
+type attachedProcess struct {
+	process *os.Process
+	// The cgroup the process was put in because it has
+	// resource limits. Nil if it has none.
+	cgroup *nodeCgroup
+}
+
+// Returns the running process with ID [pid], which
+// started at [startTime] if it's non-zero
+func newAttachedProcess(pid int, startTime uint64) (NodeProcess, error) {
+	if pid <= 0 {
+		return nil, fmt.Errorf("invalid PID %d", pid)
+	}
+	if !processExists(pid) {
+		return nil, fmt.Errorf("process %d isn't running", pid)
+	}
+	if !startedAt(pid, startTime) {
+		return nil, fmt.Errorf("process %d isn't the node's; its PID was reused", pid)
+	}
+	// Never fails on Unix
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return nil, err
+	}
+	return &attachedProcess{
+		process: process,
+		cgroup:  nodeCgroupOf(defaultCgroupRoot, pid),
+	}, nil
+}
+
+// Returns true if a process with ID [pid] exists and hasn't exited
+func processExists(pid int) bool {
+	// Signal 0 only checks whether the process can be signalled
+	err := syscall.Kill(pid, 0)
+	if err != nil && !errors.Is(err, syscall.EPERM) {
+		return false
+	}
+	// A process that exited but wasn't reaped by its parent
+	// can still be signalled until it is
+	return !isZombie(pid)
+}
+
+// Returns true if the process with ID [pid] exited but wasn't reaped.
+// Returns false if its state can't be read, e.g. because /proc isn't
+// mounted, since the process is then assumed to be running.
+func isZombie(pid int) bool {
+	fields := procStatFields(pid)
+	if len(fields) == 0 {
+		return false
+	}
+	return fields[0] == "Z" || fields[0] == "X"
+}
+
+// Returns true if the process with ID [pid] started at [startTime], so
+// that a process that got the PID of a node's process after it exited,
+// e.g. after a reboot, isn't taken for the node's. Returns true if
+// either start time is unknown.
+func startedAt(pid int, startTime uint64) bool {
+	if startTime == 0 {
+		return true
+	}
+	pidStartTime := processStartTime(pid)
+	return pidStartTime == 0 || pidStartTime == startTime
+}
+
+// Returns when the process with ID [pid] started, in clock
+// ticks since boot, or 0 if it can't be read
+func processStartTime(pid int) uint64 {
+	// The start time is the 22nd field, and the fields start at the 3rd
+	fields := procStatFields(pid)
+	if len(fields) < 20 {
+		return 0
+	}
+	startTime, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return startTime
+}
+
+// Returns the fields of /proc/[pid]/stat that follow the process'
+// command name, starting with its state, or nil if it can't be read
+func procStatFields(pid int) []string {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil
+	}
+	// The command name is in parentheses and may itself have parentheses
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return nil
+	}
+	return strings.Fields(string(stat[i+1:]))
+}
+
+func (p *attachedProcess) Start() error {
+	return errors.New("attached process is already started")
+}
+
+// Returns errExitStatusUnknown once the process no longer exists,
+// or exited but wasn't reaped by the process that started it
+func (p *attachedProcess) Wait() error {
+	for processExists(p.process.Pid) {
+		time.Sleep(attachedProcessCheckFreq)
+	}
+	if p.cgroup != nil {
+		if err := p.cgroup.remove(); err != nil {
+			return fmt.Errorf("%w; %s", errExitStatusUnknown, err)
+		}
+	}
+	return errExitStatusUnknown
+}
+
+func (p *attachedProcess) Stop() error {
+	return p.process.Signal(syscall.SIGTERM)
+}
+
+// The original process started the node as the leader of its own
+// process group
+func (p *attachedProcess) Kill() error {
+	return syscall.Kill(-p.process.Pid, syscall.SIGKILL)
+}
+
+func (p *attachedProcess) Pause() error {
+	return p.process.Signal(syscall.SIGSTOP)
+}
+
+func (p *attachedProcess) Resume() error {
+	return p.process.Signal(syscall.SIGCONT)
+}
+
+func (p *attachedProcess) PID() int {
+	return p.process.Pid
+}
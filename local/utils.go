@@ -1,11 +1,12 @@
 package local
 
 import (
-	"context"
-	"fmt"
-	"math"
+	"bytes"
+	"io"
 	"math/rand"
-	"net"
+	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,35 +14,95 @@ func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
-const (
-	maxPort          = math.MaxUint16
-	minPort          = 10000
-	netListenTimeout = 3 * time.Second
-)
+// lineTail is an io.Writer that keeps the
+// last [maxLines] lines written to it
+type lineTail struct {
+	lock     sync.Mutex
+	maxLines int
+	lines    []string
+	// Written after the last newline
+	partial []byte
+}
+
+func newLineTail(maxLines int) *lineTail {
+	return &lineTail{maxLines: maxLines}
+}
 
-// getFreePort generates a random port number and then
-// verifies it is free. If it is, returns that port, otherwise retries.
-// Returns an error if no free port is found within [netListenTimeout].
-// Note that it is possible for [getFreePort] to return the same port twice.
-func getFreePort() (uint16, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), netListenTimeout)
-	defer cancel()
+func (t *lineTail) Write(p []byte) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.partial = append(t.partial, p...)
 	for {
-		select {
-		case <-ctx.Done():
-			return 0, ctx.Err()
-		default:
-			// Generate random port in [minPort, maxPort]
-			port := uint16(rand.Intn(maxPort-minPort+1) + minPort)
-			// Verify it's free by binding to it
-			l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
-			if err != nil {
-				// Couldn't bind to this port. Try another.
-				continue
-			}
-			// We could bind to [port] so must be free.
-			_ = l.Close()
-			return port, nil
+		i := bytes.IndexByte(t.partial, '\n')
+		if i < 0 {
+			break
 		}
+		t.lines = append(t.lines, string(t.partial[:i]))
+		t.partial = t.partial[i+1:]
+	}
+	if len(t.lines) > t.maxLines {
+		t.lines = append([]string(nil), t.lines[len(t.lines)-t.maxLines:]...)
+	}
+	return len(p), nil
+}
+
+// Returns the last lines written, including an
+// unterminated last line, oldest first
+func (t *lineTail) Lines() []string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	lines := append([]string(nil), t.lines...)
+	if len(t.partial) > 0 {
+		lines = append(lines, string(t.partial))
+	}
+	if len(lines) > t.maxLines {
+		lines = lines[len(lines)-t.maxLines:]
+	}
+	return lines
+}
+
+// Returns the flags in [args], which are of the form --name=value,
+// as a map from flag name to value. If a flag is given more than
+// once, the last value wins, as it does for AvalancheGo.
+func flagsMap(args []string) map[string]interface{} {
+	flags := make(map[string]interface{}, len(args))
+	for _, arg := range args {
+		nameVal := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)
+		if len(nameVal) == 2 {
+			flags[nameVal[0]] = nameVal[1]
+		} else {
+			flags[nameVal[0]] = true
+		}
+	}
+	return flags
+}
+
+// Returns the size of [file], or 0 if it's nil or its size is unknown
+func fileSize(file *os.File) int64 {
+	if file == nil {
+		return 0
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// Returns the size of the file at [path], or 0 if it's unknown
+func fileSizeAt(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// Closes each of [closers], ignoring errors
+func closeAll(closers []io.Closer) {
+	for _, closer := range closers {
+		_ = closer.Close()
 	}
 }
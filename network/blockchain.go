@@ -0,0 +1,59 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanche-network-runner/api"
+	"github.com/ava-labs/avalanchego/ids"
+)
+
+// Time between checks of whether a node finished bootstrapping a chain
+const chainHealthCheckFreq = time.Second
+
+// Blockchain is a blockchain created by Network.CreateBlockchain
+type Blockchain struct {
+	ID       ids.ID `json:"id"`
+	SubnetID ids.ID `json:"subnetID"`
+	VMID     ids.ID `json:"vmID"`
+	// Node name --> API client of the node.
+	// Has each node that validates the blockchain.
+	Clients map[string]api.Client `json:"-"`
+	// Node name --> URI of the blockchain's API on the node,
+	// e.g. http://localhost:9650/ext/bc/<ID>
+	Endpoints map[string]string `json:"endpoints"`
+}
+
+// ChainEndpoint returns the URI of chain [chainID]'s API
+// on the node whose API is at [host]:[apiPort]
+func ChainEndpoint(host string, apiPort uint16, chainID ids.ID) string {
+	return fmt.Sprintf("http://%s:%d/ext/bc/%s", host, apiPort, chainID)
+}
+
+// WaitForChain blocks until the node that [client] calls reports that
+// chain [chainID] is healthy, which it does once it has bootstrapped
+// the chain. Returns an error if [ctx] is done first.
+func WaitForChain(ctx context.Context, client api.Client, chainID ids.ID) error {
+	// Each chain's health check is named after its primary alias,
+	// which for chains not in the genesis is the chain's ID
+	checkName := chainID.String()
+	var lastErr string
+	for {
+		reply, err := client.HealthAPI().Health(ctx)
+		if err != nil {
+			lastErr = err.Error()
+		} else if result, ok := reply.Checks[checkName]; !ok {
+			lastErr = "node doesn't run the chain yet"
+		} else if result.Error != nil {
+			lastErr = *result.Error
+		} else {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("chain %s isn't healthy (%s): %w", chainID, lastErr, ctx.Err())
+		case <-time.After(chainHealthCheckFreq):
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package network_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apimocks "github.com/ava-labs/avalanche-network-runner/api/mocks"
+	"github.com/ava-labs/avalanche-network-runner/network"
+	"github.com/ava-labs/avalanchego/api/health"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestChainEndpoint(t *testing.T) {
+	chainID := ids.GenerateTestID()
+	assert.EqualValues(t, "http://localhost:9650/ext/bc/"+chainID.String(), network.ChainEndpoint("localhost", 9650, chainID))
+}
+
+func TestWaitForChain(t *testing.T) {
+	assert := assert.New(t)
+	chainID := ids.GenerateTestID()
+	newClient := func(reply *health.APIHealthReply, err error) *apimocks.Client {
+		healthClient := &apimocks.HealthClient{}
+		healthClient.On("Health", mock.Anything).Return(reply, err)
+		client := &apimocks.Client{}
+		client.On("HealthAPI").Return(healthClient)
+		return client
+	}
+
+	// The chain's health check passes
+	client := newClient(&health.APIHealthReply{
+		Checks: map[string]health.Result{
+			chainID.String(): {},
+		},
+	}, nil)
+	assert.NoError(network.WaitForChain(context.Background(), client, chainID))
+
+	// The chain's health check fails, the node doesn't run
+	// the chain, or the node can't be queried
+	checkErr := "not bootstrapped"
+	for _, client := range []*apimocks.Client{
+		newClient(&health.APIHealthReply{
+			Checks: map[string]health.Result{
+				chainID.String(): {Error: &checkErr},
+			},
+		}, nil),
+		newClient(&health.APIHealthReply{Checks: map[string]health.Result{}}, nil),
+		newClient(nil, errors.New("connection refused")),
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := network.WaitForChain(ctx, client, chainID)
+		cancel()
+		assert.ErrorIs(err, context.DeadlineExceeded)
+	}
+}
@@ -70,6 +70,22 @@ func (b *Backend) UnmarshalJSON(bytes []byte) error {
 	}
 }
 
+// CleanupPolicy says when a network's directories are removed.
+// Only the local backend has directories to remove.
+type CleanupPolicy string
+
+const (
+	// Never remove the network's directories.
+	// The zero value means this too.
+	CleanupNever CleanupPolicy = "never"
+	// Remove the network's directories when it's stopped
+	CleanupAlways CleanupPolicy = "always"
+	// Remove the network's directories when it's stopped, unless
+	// creating or stopping it failed, or a node exited unexpectedly
+	// or was reported unhealthy, so they can be inspected
+	CleanupOnSuccess CleanupPolicy = "on-success"
+)
+
 // Config that defines a network when it is created.
 type Config struct {
 	// Must not be nil
@@ -96,6 +112,39 @@ type Config struct {
 	// and the node's config file has flag W set to Z,
 	// then the node will be started with flag W set to Y.
 	Flags map[string]interface{} `json:"flags"`
+	// Private key of an address that has funds on the P-Chain
+	// in [Genesis], e.g. "PrivateKey-...". Pays for the transactions
+	// issued by network operations such as CreateSubnet.
+	// May be empty if those operations aren't used.
+	FundedPrivateKey string `json:"fundedPrivateKey"`
+	// When to remove the network's directories.
+	// If empty, they're never removed.
+	CleanupPolicy CleanupPolicy `json:"cleanupPolicy,omitempty"`
+	// If true, nodes connect to each other through proxies that
+	// can partition the network and shape the traffic between
+	// nodes. Only the local backend supports this. Its nodes are
+	// then started one at a time.
+	FaultInjection bool `json:"faultInjection,omitempty"`
+	// The ports given to nodes whose API or P2P port isn't given in
+	// their flags or config file. Only the local backend uses this.
+	// If zero, ports are taken from [10000, 65535].
+	Ports PortRange `json:"ports,omitempty"`
+}
+
+// PortRange is a range of ports, from Min to Max inclusive
+type PortRange struct {
+	// If zero, 10000
+	Min uint16 `json:"min,omitempty"`
+	// If zero, 65535
+	Max uint16 `json:"max,omitempty"`
+}
+
+// Validate returns an error if this range is invalid
+func (r PortRange) Validate() error {
+	if r.Min != 0 && r.Max != 0 && r.Min > r.Max {
+		return fmt.Errorf("min port %d is greater than max port %d", r.Min, r.Max)
+	}
+	return nil
 }
 
 // Validate returns an error if this config is invalid
@@ -105,6 +154,14 @@ func (c *Config) Validate() error {
 	case len(c.Genesis) == 0:
 		return errors.New("no genesis given")
 	}
+	switch c.CleanupPolicy {
+	case "", CleanupNever, CleanupAlways, CleanupOnSuccess:
+	default:
+		return fmt.Errorf("unknown cleanup policy %q", c.CleanupPolicy)
+	}
+	if err := c.Ports.Validate(); err != nil {
+		return fmt.Errorf("invalid port range: %w", err)
+	}
 	networkID, err := utils.NetworkIDFromGenesis([]byte(c.Genesis))
 	if err != nil {
 		return fmt.Errorf("couldn't get network ID from genesis: %w", err)
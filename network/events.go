@@ -0,0 +1,175 @@
+package network
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EventType is the kind of thing that happened in a network
+type EventType byte
+
+const (
+	// A node was added to the network
+	NodeAdded EventType = iota + 1
+	// A node was removed from the network
+	NodeRemoved
+	// A node's process exited.
+	// Event.ExitCode holds its exit code.
+	NodeExited
+	// A node reported healthy
+	NodeHealthy
+	// A node failed to report healthy.
+	// Event.Err says why.
+	NodeUnhealthy
+	// The network was stopped.
+	// No more events are sent after this one,
+	// unless the network is started again.
+	NetworkStopped
+	// A stopped network was started again
+	NetworkStarted
+)
+
+func (t EventType) String() string {
+	switch t {
+	case NodeAdded:
+		return "NodeAdded"
+	case NodeRemoved:
+		return "NodeRemoved"
+	case NodeExited:
+		return "NodeExited"
+	case NodeHealthy:
+		return "NodeHealthy"
+	case NodeUnhealthy:
+		return "NodeUnhealthy"
+	case NetworkStopped:
+		return "NetworkStopped"
+	case NetworkStarted:
+		return "NetworkStarted"
+	default:
+		return fmt.Sprintf("EventType(%d)", byte(t))
+	}
+}
+
+// Event is something that happened in a network
+type Event struct {
+	Type EventType
+	// The node this event is about.
+	// Empty for NetworkStopped and NetworkStarted.
+	NodeName string
+	// For NodeExited, the exit code of the node's process,
+	// or -1 if it's unknown.
+	ExitCode int
+	// For NodeExited and NodeUnhealthy, the error
+	// that caused the event, if any.
+	Err error
+	// When this event happened
+	Time time.Time
+}
+
+// EventBroker sends the events published to it to all of its subscribers.
+// Each subscriber has an unbounded queue, so a slow subscriber never blocks
+// Publish or other subscribers.
+// The zero value is not usable; use NewEventBroker.
+type EventBroker struct {
+	lock        sync.Mutex
+	subscribers map[*subscriber]struct{}
+}
+
+func NewEventBroker() *EventBroker {
+	return &EventBroker{
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Subscribe returns a channel on which every event published after
+// this call is sent, in order, and a function that unsubscribes and
+// closes the channel. The function must be called when the subscriber
+// is done, and may be called more than once.
+func (b *EventBroker) Subscribe() (<-chan Event, func()) {
+	s := &subscriber{
+		ch:       make(chan Event),
+		notify:   make(chan struct{}, 1),
+		closedCh: make(chan struct{}),
+	}
+	b.lock.Lock()
+	b.subscribers[s] = struct{}{}
+	b.lock.Unlock()
+
+	go s.run()
+
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			b.lock.Lock()
+			delete(b.subscribers, s)
+			b.lock.Unlock()
+			close(s.closedCh)
+		})
+	}
+}
+
+// Publish sends [event] to all subscribers. Never blocks.
+// If [event.Time] is zero, it's set to the current time.
+func (b *EventBroker) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for s := range b.subscribers {
+		s.push(event)
+	}
+}
+
+type subscriber struct {
+	// Events are delivered on [ch]
+	ch   chan Event
+	lock sync.Mutex
+	// Events not yet delivered on [ch]
+	queue []Event
+	// Signalled when an event is added to [queue]
+	notify chan struct{}
+	// Closed on unsubscribe
+	closedCh chan struct{}
+}
+
+func (s *subscriber) push(event Event) {
+	s.lock.Lock()
+	s.queue = append(s.queue, event)
+	s.lock.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Delivers queued events on [s.ch] until unsubscribed,
+// then closes [s.ch].
+func (s *subscriber) run() {
+	defer close(s.ch)
+	for {
+		s.lock.Lock()
+		if len(s.queue) == 0 {
+			s.lock.Unlock()
+			select {
+			case <-s.notify:
+				continue
+			case <-s.closedCh:
+				return
+			}
+		}
+		event := s.queue[0]
+		s.queue[0] = Event{}
+		s.queue = s.queue[1:]
+		s.lock.Unlock()
+
+		select {
+		case s.ch <- event:
+		case <-s.closedCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package network_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanche-network-runner/network"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEventBroker checks that every subscriber gets every event, in order,
+// and that a subscriber that isn't reading doesn't block the others
+func TestEventBroker(t *testing.T) {
+	assert := assert.New(t)
+	broker := network.NewEventBroker()
+
+	ch1, unsubscribe1 := broker.Subscribe()
+	defer unsubscribe1()
+	// Never read from
+	_, unsubscribe2 := broker.Subscribe()
+	defer unsubscribe2()
+
+	exitErr := errors.New("exited")
+	events := []network.Event{
+		{Type: network.NodeAdded, NodeName: "node0"},
+		{Type: network.NodeExited, NodeName: "node0", ExitCode: 1, Err: exitErr},
+		{Type: network.NodeRemoved, NodeName: "node0"},
+		{Type: network.NetworkStopped},
+	}
+	for _, event := range events {
+		broker.Publish(event)
+	}
+	for _, expected := range events {
+		select {
+		case event := <-ch1:
+			assert.EqualValues(expected.Type, event.Type)
+			assert.EqualValues(expected.NodeName, event.NodeName)
+			assert.EqualValues(expected.ExitCode, event.ExitCode)
+			assert.EqualValues(expected.Err, event.Err)
+			assert.False(event.Time.IsZero())
+		case <-time.After(time.Second):
+			assert.FailNow("didn't get event", expected.Type)
+		}
+	}
+
+	// Unsubscribing closes the channel and can be done twice
+	unsubscribe1()
+	unsubscribe1()
+	broker.Publish(network.Event{Type: network.NodeAdded})
+	select {
+	case _, ok := <-ch1:
+		assert.False(ok)
+	case <-time.After(time.Second):
+		assert.Fail("channel not closed")
+	}
+
+	// Only events published after subscribing are sent
+	ch3, unsubscribe3 := broker.Subscribe()
+	defer unsubscribe3()
+	broker.Publish(network.Event{Type: network.NodeHealthy, NodeName: "node1"})
+	select {
+	case event := <-ch3:
+		assert.EqualValues(network.NodeHealthy, event.Type)
+		assert.EqualValues("node1", event.NodeName)
+	case <-time.After(time.Second):
+		assert.Fail("didn't get event")
+	}
+}
+
+func TestEventTypeString(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("NodeExited", network.NodeExited.String())
+	assert.Equal("NetworkStopped", network.NetworkStopped.String())
+	assert.Equal("NetworkStarted", network.NetworkStarted.String())
+	assert.Equal("EventType(0)", network.EventType(0).String())
+}
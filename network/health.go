@@ -0,0 +1,200 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ava-labs/avalanche-network-runner/api"
+	"github.com/ava-labs/avalanchego/api/health"
+)
+
+const (
+	bootstrappedCheck = "bootstrapped"
+	// Time between checks of whether a node is healthy
+	nodeHealthCheckFreq = time.Second
+)
+
+// Names of the health checks avalanchego registers that aren't
+// about a particular chain. Each other health check is named
+// after the chain it checks.
+var nodeWideHealthChecks = map[string]struct{}{
+	bootstrappedCheck: {},
+	"network":         {},
+	"router":          {},
+	"shuttingDown":    {},
+}
+
+// NodeHealth is a node's health, as reported by its health API
+type NodeHealth struct {
+	// True if the node reported healthy
+	Healthy bool `json:"healthy"`
+	// The node's reply to its health API.
+	// Nil if the node couldn't be queried.
+	Reply *health.APIHealthReply `json:"reply,omitempty"`
+	// Names of the failing health checks, sorted
+	FailingChecks []string `json:"failingChecks,omitempty"`
+	// Chains whose health checks are failing, sorted
+	FailingChains []string `json:"failingChains,omitempty"`
+	// True if the node finished bootstrapping the primary network
+	Bootstrapped bool `json:"bootstrapped"`
+	// Chains the node is still bootstrapping, if it said so
+	BootstrappingChains []string `json:"bootstrappingChains,omitempty"`
+	// Why the node couldn't be queried, or why it's unhealthy
+	LastError string `json:"lastError,omitempty"`
+}
+
+// NewNodeHealth returns the health of a node whose health API
+// returned [reply] and [err]
+func NewNodeHealth(reply *health.APIHealthReply, err error) NodeHealth {
+	if err != nil {
+		return NodeHealth{LastError: err.Error()}
+	}
+	if reply == nil {
+		return NodeHealth{LastError: "no health reply"}
+	}
+	nodeHealth := NodeHealth{
+		Healthy: reply.Healthy,
+		Reply:   reply,
+	}
+	for name, result := range reply.Checks {
+		if name == bootstrappedCheck {
+			nodeHealth.Bootstrapped = result.Error == nil
+			// Lists the chains that aren't bootstrapped
+			if chains, ok := result.Details.([]interface{}); ok {
+				for _, chain := range chains {
+					nodeHealth.BootstrappingChains = append(nodeHealth.BootstrappingChains, fmt.Sprint(chain))
+				}
+			}
+		}
+		if result.Error == nil {
+			continue
+		}
+		nodeHealth.FailingChecks = append(nodeHealth.FailingChecks, name)
+		if _, ok := nodeWideHealthChecks[name]; !ok {
+			nodeHealth.FailingChains = append(nodeHealth.FailingChains, name)
+		}
+	}
+	sort.Strings(nodeHealth.FailingChecks)
+	sort.Strings(nodeHealth.FailingChains)
+	sort.Strings(nodeHealth.BootstrappingChains)
+	switch {
+	case len(nodeHealth.FailingChecks) > 0:
+		name := nodeHealth.FailingChecks[0]
+		nodeHealth.LastError = fmt.Sprintf("health check %q failed: %s", name, *reply.Checks[name].Error)
+	case !reply.Healthy:
+		nodeHealth.LastError = "node reported unhealthy"
+	}
+	return nodeHealth
+}
+
+// HealthTransition is a change in a node's health
+type HealthTransition struct {
+	// The node whose health changed
+	NodeName string `json:"nodeName"`
+	// True if the node became healthy.
+	// False if it became unhealthy.
+	Healthy bool `json:"healthy"`
+	// The node's health when the change was seen
+	Health NodeHealth `json:"health"`
+	// When the change was seen
+	Time time.Time `json:"time"`
+}
+
+// WaitForHealthy blocks until the node that [client] calls reports
+// that it's healthy. Returns an error if [ctx] is done first.
+func WaitForHealthy(ctx context.Context, client api.Client) error {
+	for {
+		nodeHealth := NewNodeHealth(client.HealthAPI().Health(ctx))
+		if nodeHealth.Healthy {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("node isn't healthy (%s): %w", nodeHealth.LastError, ctx.Err())
+		case <-time.After(nodeHealthCheckFreq):
+		}
+	}
+}
+
+// WatchHealth calls [n.HealthReport] every [interval] until [ctx] is
+// done or [n] is stopped, and sends a HealthTransition on the returned
+// channel each time a node's health changes. The first time a node's
+// health is seen, its health is sent too. Each poll times out after
+// [interval]. If the channel isn't read from, polling is delayed until
+// it is. The channel is closed when polling stops.
+// If [events] is non-nil, each transition is also published to it as
+// a NodeHealthy or NodeUnhealthy event.
+// Network implementations can use this to implement Network.WatchHealth.
+func WatchHealth(
+	ctx context.Context,
+	n Network,
+	interval time.Duration,
+	events *EventBroker,
+) (<-chan HealthTransition, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("health check interval must be positive but is %s", interval)
+	}
+	transitions := make(chan HealthTransition)
+	go func() {
+		defer close(transitions)
+		// Node name --> Whether it was healthy when last polled
+		lastHealthy := make(map[string]bool)
+		for {
+			pollCtx, cancel := context.WithTimeout(ctx, interval)
+			report, err := n.HealthReport(pollCtx)
+			cancel()
+			if err != nil || ctx.Err() != nil {
+				// [err] is only returned when the network is stopped.
+				// If [ctx] is done, nodes look unhealthy because their
+				// health checks were cancelled, so don't report them.
+				return
+			}
+			names := make([]string, 0, len(report))
+			for name := range report {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				nodeHealth := report[name]
+				if wasHealthy, ok := lastHealthy[name]; ok && wasHealthy == nodeHealth.Healthy {
+					continue
+				}
+				lastHealthy[name] = nodeHealth.Healthy
+				transition := HealthTransition{
+					NodeName: name,
+					Healthy:  nodeHealth.Healthy,
+					Health:   nodeHealth,
+					Time:     time.Now(),
+				}
+				if events != nil {
+					event := Event{Type: NodeHealthy, NodeName: name, Time: transition.Time}
+					if !nodeHealth.Healthy {
+						event.Type = NodeUnhealthy
+						event.Err = errors.New(nodeHealth.LastError)
+					}
+					events.Publish(event)
+				}
+				select {
+				case transitions <- transition:
+				case <-ctx.Done():
+					return
+				}
+			}
+			// Forget removed nodes
+			for name := range lastHealthy {
+				if _, ok := report[name]; !ok {
+					delete(lastHealthy, name)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+	return transitions, nil
+}
@@ -0,0 +1,83 @@
+package network_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	apimocks "github.com/ava-labs/avalanche-network-runner/api/mocks"
+	"github.com/ava-labs/avalanche-network-runner/network"
+	"github.com/ava-labs/avalanchego/api/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewNodeHealth(t *testing.T) {
+	assert := assert.New(t)
+
+	// Unmarshal the reply the way the health API client does
+	replyJSON := `{
+		"checks": {
+			"bootstrapped": {"message": ["2oYMBNV4eNHyqk2fjjV5nVQLDbtmNJzq5s3qs3Lo6ftnC6FByM"], "error": "not yet bootstrapped"},
+			"C": {"error": "not yet run"},
+			"X": {"message": {"outstandingVertices": 0}},
+			"network": {"message": {"connectedPeers": 4}}
+		},
+		"healthy": false
+	}`
+	reply := &health.APIHealthReply{}
+	assert.NoError(json.Unmarshal([]byte(replyJSON), reply))
+	nodeHealth := network.NewNodeHealth(reply, nil)
+	assert.False(nodeHealth.Healthy)
+	assert.Same(reply, nodeHealth.Reply)
+	assert.EqualValues([]string{"C", "bootstrapped"}, nodeHealth.FailingChecks)
+	assert.EqualValues([]string{"C"}, nodeHealth.FailingChains)
+	assert.False(nodeHealth.Bootstrapped)
+	assert.EqualValues([]string{"2oYMBNV4eNHyqk2fjjV5nVQLDbtmNJzq5s3qs3Lo6ftnC6FByM"}, nodeHealth.BootstrappingChains)
+	assert.Contains(nodeHealth.LastError, "not yet run")
+	_, err := json.Marshal(nodeHealth)
+	assert.NoError(err)
+
+	nodeHealth = network.NewNodeHealth(&health.APIHealthReply{
+		Healthy: true,
+		Checks: map[string]health.Result{
+			"bootstrapped": {Details: []interface{}{}},
+		},
+	}, nil)
+	assert.True(nodeHealth.Healthy)
+	assert.True(nodeHealth.Bootstrapped)
+	assert.Empty(nodeHealth.FailingChecks)
+	assert.Empty(nodeHealth.BootstrappingChains)
+	assert.Empty(nodeHealth.LastError)
+
+	nodeHealth = network.NewNodeHealth(nil, errors.New("connection refused"))
+	assert.False(nodeHealth.Healthy)
+	assert.Nil(nodeHealth.Reply)
+	assert.EqualValues("connection refused", nodeHealth.LastError)
+}
+
+func TestWaitForHealthy(t *testing.T) {
+	assert := assert.New(t)
+	newClient := func(reply *health.APIHealthReply, err error) *apimocks.Client {
+		healthClient := &apimocks.HealthClient{}
+		healthClient.On("Health", mock.Anything).Return(reply, err)
+		client := &apimocks.Client{}
+		client.On("HealthAPI").Return(healthClient)
+		return client
+	}
+
+	assert.NoError(network.WaitForHealthy(context.Background(), newClient(&health.APIHealthReply{Healthy: true}, nil)))
+
+	// The node is unhealthy or can't be queried
+	for _, client := range []*apimocks.Client{
+		newClient(&health.APIHealthReply{Healthy: false}, nil),
+		newClient(nil, errors.New("connection refused")),
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := network.WaitForHealthy(ctx, client)
+		cancel()
+		assert.ErrorIs(err, context.DeadlineExceeded)
+	}
+}
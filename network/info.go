@@ -0,0 +1,75 @@
+package network
+
+import (
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+)
+
+// NodeStatus is the state of a node's process or pod
+type NodeStatus string
+
+const (
+	// The node is running
+	NodeStatusRunning NodeStatus = "running"
+	// The node was started but can't be reached yet
+	NodeStatusStarting NodeStatus = "starting"
+	// The node's process was paused
+	NodeStatusPaused NodeStatus = "paused"
+	// The node's process exited
+	NodeStatusExited NodeStatus = "exited"
+)
+
+// Info describes a network and its nodes, as returned by
+// Network.Describe. It can be marshalled to JSON.
+type Info struct {
+	NetworkID uint32 `json:"networkID"`
+	// SHA-256 hash of the network's genesis, formatted like an ID
+	GenesisHash string `json:"genesisHash"`
+	// The nodes that other nodes bootstrap from
+	Beacons []BeaconInfo `json:"beacons"`
+	// Node name --> Description of the node
+	Nodes map[string]NodeInfo `json:"nodes"`
+}
+
+// BeaconInfo describes a node that other nodes bootstrap from
+type BeaconInfo struct {
+	NodeName string `json:"nodeName"`
+	NodeID   string `json:"nodeID"`
+	// Where other nodes reach the beacon, e.g. 127.0.0.1:9651
+	IP string `json:"ip"`
+}
+
+// NodeInfo describes a node. Fields that don't apply
+// to the network's backend are empty.
+type NodeInfo struct {
+	Name   string `json:"name"`
+	NodeID string `json:"nodeID"`
+	// URI of the node's API, e.g. http://localhost:9650
+	URI      string `json:"uri"`
+	APIPort  uint16 `json:"apiPort"`
+	P2PPort  uint16 `json:"p2pPort"`
+	IsBeacon bool   `json:"isBeacon"`
+	// Where the node's config files, staking key/cert
+	// and genesis are written
+	RootDir string `json:"rootDir,omitempty"`
+	DBDir   string `json:"dbDir,omitempty"`
+	LogsDir string `json:"logsDir,omitempty"`
+	// ID of the node's process
+	PID int `json:"pid,omitempty"`
+	// Name of the node's pod
+	PodName    string `json:"podName,omitempty"`
+	BinaryPath string `json:"binaryPath,omitempty"`
+	// Docker image and tag the node runs
+	Image string `json:"image,omitempty"`
+	// The flags the node was started with, including
+	// the ones given by the network
+	Flags  map[string]interface{} `json:"flags"`
+	Status NodeStatus             `json:"status"`
+	// Why the node exited, if it did
+	Error string `json:"error,omitempty"`
+}
+
+// GenesisHash returns the hash of [genesis] for Info.GenesisHash
+func GenesisHash(genesis []byte) string {
+	return ids.ID(hashing.ComputeHash256Array(genesis)).String()
+}
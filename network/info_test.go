@@ -0,0 +1,18 @@
+package network_test
+
+import (
+	"testing"
+
+	"github.com/ava-labs/avalanche-network-runner/network"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenesisHash(t *testing.T) {
+	assert := assert.New(t)
+	hash := network.GenesisHash([]byte("genesis"))
+	_, err := ids.FromString(hash)
+	assert.NoError(err)
+	assert.EqualValues(hash, network.GenesisHash([]byte("genesis")))
+	assert.NotEqualValues(hash, network.GenesisHash([]byte("other genesis")))
+}
@@ -0,0 +1,186 @@
+package network
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+const (
+	// The stream a LogLine was written to
+	StdoutStream = "stdout"
+	StderrStream = "stderr"
+)
+
+var (
+	// Matches the terminal color codes avalanchego wraps its log lines in
+	colorCodeRegexp = regexp.MustCompile("\x1b\\[[0-9;]*m")
+	// Matches an avalanchego log line, e.g.
+	// "INFO [01-02|15:04:05] <C Chain> vms/vm.go#42: block accepted".
+	// The groups are the level, time, chain, module and message.
+	logLineRegexp = regexp.MustCompile(`^(\S+?) *\[(\d\d-\d\d\|\d\d:\d\d:\d\d(?:\.\d+)?)\](?: <([^>]*)>)? (?:(\S+#\d+): )?(.*)$`)
+)
+
+// LogLine is a line a node wrote to its stdout or stderr
+type LogLine struct {
+	// When the node wrote the line. Local networks use the time an
+	// avalanchego log line was logged at, which is only to the second,
+	// and the time other lines were read from the node.
+	Time time.Time `json:"time"`
+	// StdoutStream or StderrStream. Empty if the
+	// node's backend doesn't tell them apart.
+	Stream string `json:"stream,omitempty"`
+	// Level the line was logged at, e.g. "INFO".
+	// Empty if the line isn't an avalanchego log line.
+	Level string `json:"level,omitempty"`
+	// Chain the line was logged about, e.g. "C Chain".
+	// Empty if it isn't about a chain.
+	Chain string `json:"chain,omitempty"`
+	Text  string `json:"text"`
+}
+
+// NewLogLine returns the line [text] written to [stream] at [t],
+// with the level and chain it was logged at, if it's an avalanchego
+// log line
+func NewLogLine(t time.Time, stream string, text string) LogLine {
+	line := LogLine{
+		Time:   t,
+		Stream: stream,
+		Text:   text,
+	}
+	if record, ok := ParseLogLine(text, t); ok {
+		line.Level = record.Level
+		line.Chain = record.Chain
+	}
+	return line
+}
+
+// LogRecord is an avalanchego log line split into its parts
+type LogRecord struct {
+	// When the line was logged. avalanchego doesn't log the
+	// year, so it's the one in which the line was read.
+	Time time.Time `json:"time"`
+	// e.g. "INFO"
+	Level string `json:"level"`
+	// Chain the line was logged about, e.g. "C Chain".
+	// Empty if it isn't about a chain.
+	Chain string `json:"chain,omitempty"`
+	// Where in avalanchego the line was logged, as <file>#<line>,
+	// e.g. "snow/engine/snowman/transitive.go#120"
+	Module  string `json:"module,omitempty"`
+	Message string `json:"message"`
+}
+
+// ParseLogLine splits the avalanchego log line [text], read at [readAt],
+// into its parts. Terminal color codes in [text] are ignored. Returns
+// false if [text] isn't an avalanchego log line, like the lines of a
+// panic's stack trace.
+func ParseLogLine(text string, readAt time.Time) (LogRecord, bool) {
+	text = strings.TrimRight(colorCodeRegexp.ReplaceAllString(text, ""), "\r\n")
+	match := logLineRegexp.FindStringSubmatch(text)
+	if match == nil {
+		return LogRecord{}, false
+	}
+	level, err := logging.ToLevel(match[1])
+	if err != nil || level == logging.Off {
+		return LogRecord{}, false
+	}
+	parsed, err := time.Parse("01-02|15:04:05", match[2])
+	if err != nil {
+		return LogRecord{}, false
+	}
+	loggedAt := time.Date(
+		readAt.Year(),
+		parsed.Month(),
+		parsed.Day(),
+		parsed.Hour(),
+		parsed.Minute(),
+		parsed.Second(),
+		parsed.Nanosecond(),
+		readAt.Location(),
+	)
+	// A line logged at the end of a year may be read in the next one
+	if loggedAt.After(readAt.Add(24 * time.Hour)) {
+		loggedAt = loggedAt.AddDate(-1, 0, 0)
+	}
+	return LogRecord{
+		Time:    loggedAt,
+		Level:   level.String(),
+		Chain:   match[3],
+		Module:  match[4],
+		Message: match[5],
+	}, true
+}
+
+// LogQuery says which lines Network.GetNodeLogs returns
+type LogQuery struct {
+	// If positive, only the last Tail lines that
+	// match the rest of the query are returned
+	Tail int `json:"tail,omitempty"`
+	// If non-zero, only lines written at or after Since are returned
+	Since time.Time `json:"since,omitempty"`
+	// If non-empty, only lines logged at this level or a more severe
+	// one are returned, e.g. "WARN" returns WARN, ERROR and FATAL lines.
+	// Lines that aren't avalanchego log lines aren't returned.
+	Level string `json:"level,omitempty"`
+	// If non-empty, only lines logged about these chains are returned,
+	// e.g. "C Chain". Lines that aren't about a chain aren't returned.
+	Chains []string `json:"chains,omitempty"`
+}
+
+// Validate returns an error if this query is invalid
+func (q LogQuery) Validate() error {
+	if q.Tail < 0 {
+		return fmt.Errorf("tail must be non-negative but is %d", q.Tail)
+	}
+	if q.Level != "" {
+		if _, err := logging.ToLevel(q.Level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches returns true if [line] matches the Since, Level and
+// Chains of this query. Assumes this query is valid.
+func (q LogQuery) Matches(line LogLine) bool {
+	if !q.Since.IsZero() && line.Time.Before(q.Since) {
+		return false
+	}
+	if q.Level != "" {
+		maxLevel, _ := logging.ToLevel(q.Level)
+		level, err := logging.ToLevel(line.Level)
+		// Lower levels are more severe
+		if err != nil || level == logging.Off || level > maxLevel {
+			return false
+		}
+	}
+	if len(q.Chains) > 0 {
+		for _, chain := range q.Chains {
+			if line.Chain == chain {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// FilterLogLines returns the lines of [lines], which are oldest first,
+// that match [query]. Assumes [query] is valid.
+// Network implementations can use this to implement Network.GetNodeLogs.
+func FilterLogLines(lines []LogLine, query LogQuery) []LogLine {
+	filtered := []LogLine{}
+	for _, line := range lines {
+		if query.Matches(line) {
+			filtered = append(filtered, line)
+		}
+	}
+	if query.Tail > 0 && len(filtered) > query.Tail {
+		filtered = filtered[len(filtered)-query.Tail:]
+	}
+	return filtered
+}
@@ -0,0 +1,126 @@
+package network_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanche-network-runner/network"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewLogLine checks that the level and chain of an
+// avalanchego log line are parsed, even if it's colored
+func TestNewLogLine(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	line := network.NewLogLine(now, network.StdoutStream, "DEBUG[01-02|15:04:05] <X Chain> avm/vm.go#3: hi")
+	assert.Equal("DEBUG", line.Level)
+	assert.Equal("X Chain", line.Chain)
+	tests := map[string]string{
+		"INFO [01-02|15:04:05] bootstrapping finished":                         "INFO",
+		"WARN [01-02|15:04:05] peer benched":                                   "WARN",
+		"DEBUG[01-02|15:04:05] no space after an aligned level":                "DEBUG",
+		"\x1b[1;33mWARN [01-02|15:04:05] colored\n\x1b[0;0m":                   "WARN",
+		"INFO [01-02|15:04:05.123] with milliseconds":                          "INFO",
+		"panic: runtime error":                                                 "",
+		"goroutine 1 [running]:":                                               "",
+		"OFF [01-02|15:04:05] not a level anything is logged at":               "",
+		"         [01-02|15:04:05] continuation of a line with an empty level": "",
+	}
+	for text, level := range tests {
+		line := network.NewLogLine(now, network.StderrStream, text)
+		assert.Equal(level, line.Level, text)
+		assert.Equal("", line.Chain)
+		assert.Equal(text, line.Text)
+		assert.Equal(network.StderrStream, line.Stream)
+		assert.Equal(now, line.Time)
+	}
+}
+
+// TestParseLogLine checks that avalanchego log lines are split into their parts
+func TestParseLogLine(t *testing.T) {
+	assert := assert.New(t)
+	readAt := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	record, ok := network.ParseLogLine("INFO [03-04|05:06:01] <C Chain> vms/vm.go#42: block accepted: height=5", readAt)
+	assert.True(ok)
+	assert.Equal(network.LogRecord{
+		Time:    time.Date(2022, 3, 4, 5, 6, 1, 0, time.UTC),
+		Level:   "INFO",
+		Chain:   "C Chain",
+		Module:  "vms/vm.go#42",
+		Message: "block accepted: height=5",
+	}, record)
+
+	// Without a chain or module
+	record, ok = network.ParseLogLine("\x1b[0;31mFATAL[03-04|05:06:01] node/node.go#1: shutting down\n\x1b[0;0m", readAt)
+	assert.True(ok)
+	assert.Equal("FATAL", record.Level)
+	assert.Equal("", record.Chain)
+	assert.Equal("node/node.go#1", record.Module)
+	assert.Equal("shutting down", record.Message)
+	record, ok = network.ParseLogLine("WARN [03-04|05:06:01] no module here", readAt)
+	assert.True(ok)
+	assert.Equal("", record.Module)
+	assert.Equal("no module here", record.Message)
+
+	// A line logged on New Year's Eve and read on New Year's Day
+	record, ok = network.ParseLogLine("INFO [12-31|23:59:59] old", time.Date(2022, 1, 1, 0, 0, 1, 0, time.UTC))
+	assert.True(ok)
+	assert.Equal(time.Date(2021, 12, 31, 23, 59, 59, 0, time.UTC), record.Time)
+
+	for _, text := range []string{
+		"",
+		"panic: runtime error: invalid memory address",
+		"\t/go/src/runtime/panic.go:1038 +0x215",
+		"NOTALEVEL [03-04|05:06:01] message",
+		"INFO [13-45|05:06:01] not a date",
+		"INFO 03-04|05:06:01 no brackets",
+	} {
+		_, ok := network.ParseLogLine(text, readAt)
+		assert.False(ok, text)
+	}
+}
+
+// TestFilterLogLines checks that lines are filtered
+// by time, level and chain before the tail is taken
+func TestFilterLogLines(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Now()
+	lines := []network.LogLine{
+		{Time: start, Level: "ERROR", Text: "0"},
+		{Time: start.Add(time.Second), Level: "INFO", Chain: "C Chain", Text: "1"},
+		{Time: start.Add(2 * time.Second), Level: "WARN", Chain: "P Chain", Text: "2"},
+		{Time: start.Add(3 * time.Second), Text: "3"},
+		{Time: start.Add(4 * time.Second), Level: "DEBUG", Text: "4"},
+		{Time: start.Add(5 * time.Second), Level: "FATAL", Text: "5"},
+	}
+	texts := func(lines []network.LogLine) []string {
+		texts := []string{}
+		for _, line := range lines {
+			texts = append(texts, line.Text)
+		}
+		return texts
+	}
+	tests := []struct {
+		query network.LogQuery
+		want  []string
+	}{
+		{network.LogQuery{}, []string{"0", "1", "2", "3", "4", "5"}},
+		{network.LogQuery{Tail: 2}, []string{"4", "5"}},
+		{network.LogQuery{Tail: 10}, []string{"0", "1", "2", "3", "4", "5"}},
+		{network.LogQuery{Since: start.Add(3 * time.Second)}, []string{"3", "4", "5"}},
+		{network.LogQuery{Level: "warn"}, []string{"0", "2", "5"}},
+		{network.LogQuery{Level: "WARN", Tail: 2}, []string{"2", "5"}},
+		{network.LogQuery{Level: "INFO", Since: start.Add(time.Second)}, []string{"1", "2", "5"}},
+		{network.LogQuery{Chains: []string{"C Chain"}}, []string{"1"}},
+		{network.LogQuery{Chains: []string{"C Chain", "P Chain"}, Level: "WARN"}, []string{"2"}},
+	}
+	for _, test := range tests {
+		assert.NoError(test.query.Validate())
+		assert.Equal(test.want, texts(network.FilterLogLines(lines, test.query)), "%+v", test.query)
+	}
+
+	assert.Error(network.LogQuery{Tail: -1}.Validate())
+	assert.Error(network.LogQuery{Level: "LOUD"}.Validate())
+}
@@ -3,8 +3,10 @@ package network
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/ava-labs/avalanche-network-runner/network/node"
+	"github.com/ava-labs/avalanchego/ids"
 )
 
 var ErrStopped = errors.New("network stopped")
@@ -20,6 +22,22 @@ type Network interface {
 	// A stopped network is considered unhealthy.
 	// Timeout is given by the context parameter.
 	Healthy(context.Context) chan error
+	// Returns the health of each node, as reported by its health API.
+	// Node name --> Its health.
+	// Unlike Healthy, doesn't wait for nodes to become healthy.
+	// A node that doesn't reply before [ctx] is done is reported
+	// with the error it returned.
+	// Returns ErrStopped if Stop() was previously called.
+	HealthReport(ctx context.Context) (map[string]NodeHealth, error)
+	// Polls the health of every node every [interval] until [ctx] is
+	// done or the network is stopped. Sends on the returned channel each
+	// time a node becomes unhealthy or healthy again, and the health of
+	// each node the first time it's polled. The channel is closed when
+	// polling stops. Each transition is also sent to subscribers as a
+	// NodeHealthy or NodeUnhealthy event.
+	// Unlike Healthy, keeps polling after all nodes are healthy.
+	// Returns ErrStopped if Stop() was previously called.
+	WatchHealth(ctx context.Context, interval time.Duration) (<-chan HealthTransition, error)
 	// Stop all the nodes.
 	// Returns ErrStopped if Stop() was previously called.
 	Stop(context.Context) error
@@ -29,6 +47,34 @@ type Network interface {
 	// Stop the node with this name.
 	// Returns ErrStopped if Stop() was previously called.
 	RemoveNode(name string) error
+	// Stop the node with this name and start it again with
+	// the same name, staking key/cert, database and ports.
+	// If the given config is non-nil, its implementation-specific
+	// config, config file, C-Chain config file and flags, if given,
+	// replace the ones the node was started with. Its name and
+	// staking key/cert, if given, must match the node's, and its
+	// flags and config file can't give the node other ports.
+	// If the node can't be started again, it's kept as a node
+	// that exited, so that it can be restarted later.
+	// Returns ErrStopped if Stop() was previously called.
+	RestartNode(ctx context.Context, name string, newConfig *node.Config) error
+	// Restart the node with this name with another version of AvalancheGo,
+	// keeping its database and identity, as RestartNode does. [newVersion]
+	// is the path of the new binary for local networks, and the tag of the
+	// new image for Kubernetes networks. Waits until the node is healthy,
+	// and returns the versions the node reported before and after.
+	// If the node was restarted but didn't become healthy, the upgrade
+	// is returned along with the error, so the node can be downgraded.
+	// Returns ErrStopped if Stop() was previously called.
+	UpgradeNode(ctx context.Context, name string, newVersion string) (*NodeUpgrade, error)
+	// Upgrade the nodes of this network with UpgradeNode, a batch at a
+	// time, waiting for the upgraded nodes and the network to be healthy
+	// before upgrading the next batch. If they aren't healthy within
+	// the timeout in [opts], stops upgrading, downgrades the upgraded
+	// nodes if [opts] says to, and returns an error. Returns the upgrades
+	// of the nodes upgraded, in order.
+	// Returns ErrStopped if Stop() was previously called.
+	RollingUpgrade(ctx context.Context, newVersion string, opts RollingUpgradeOptions) ([]*NodeUpgrade, error)
 	// Return the node with this name.
 	// Returns ErrStopped if Stop() was previously called.
 	GetNode(name string) (node.Node, error)
@@ -39,5 +85,79 @@ type Network interface {
 	// Returns the names of all nodes in this network.
 	// Returns ErrStopped if Stop() was previously called.
 	GetNodeNames() ([]string, error)
+	// Returns the lines the node named [nodeName] wrote to its stdout and
+	// stderr that match [query], oldest first. Local networks keep the
+	// last lines each node wrote in memory, and all of them in the files
+	// stdout.log and stderr.log in the node's root directory. Kubernetes
+	// networks get the lines from the logs of the node's pod.
+	// Returns ErrStopped if Stop() was previously called.
+	GetNodeLogs(ctx context.Context, nodeName string, query LogQuery) ([]LogLine, error)
+	// Returns a channel on which this network's events are sent, in
+	// the order they happen, and a function that must be called when
+	// the caller no longer wants events. The function closes the channel.
+	// Only events that happen after this call are sent.
+	// The channel isn't closed when the network stops; a NetworkStopped
+	// event is sent instead.
+	Subscribe() (<-chan Event, func())
+	// Create a subnet controlled by [threshold] of [controlKeys], which
+	// are P-Chain addresses, and make the nodes named in [validatorNodes]
+	// its validators. If no control keys are given, the subnet is controlled
+	// by the funded key in the network's config, which pays for the
+	// transactions. Waits until the transactions are accepted, then restarts
+	// the validators with the subnet whitelisted and waits until they're
+	// back up. The validators must be primary network validators, and start
+	// validating the subnet shortly after this returns. Returns the subnet's ID.
+	// Returns ErrStopped if Stop() was previously called.
+	CreateSubnet(ctx context.Context, controlKeys []string, threshold uint32, validatorNodes []string) (ids.ID, error)
+	// Create a blockchain named [name] on subnet [subnetID], running the
+	// VM [vmID] with genesis [genesis]. If [pluginBinaryPath] is non-empty,
+	// it's the VM's plugin binary, which is first installed on the subnet's
+	// validators in this network; validators that didn't have it are
+	// restarted so they load it. The funded key in the network's config
+	// pays for the transaction and must control the subnet. Waits until
+	// each of the subnet's validators in this network reports the chain
+	// as healthy, which it does once it has bootstrapped the chain.
+	// Returns ErrStopped if Stop() was previously called.
+	CreateBlockchain(
+		ctx context.Context,
+		subnetID ids.ID,
+		vmID ids.ID,
+		name string,
+		genesis []byte,
+		pluginBinaryPath string,
+	) (*Blockchain, error)
+	// Make the node named [nodeName] a primary network validator from
+	// [start] to [end], staking [stakeAmount] nAVAX of the funded key in the
+	// network's config. If [start] is zero, the node starts validating as
+	// soon as AvalancheGo allows. Rewards go to [rewardAddr], or to the
+	// funded key if it's empty. Waits until the node is a current validator.
+	// Returns the ID of the transaction that added the validator.
+	// Returns ErrStopped if Stop() was previously called.
+	AddValidator(
+		ctx context.Context,
+		nodeName string,
+		stakeAmount uint64,
+		start time.Time,
+		end time.Time,
+		rewardAddr string,
+	) (ids.ID, error)
+	// Delegate [stakeAmount] nAVAX of the funded key in the network's config
+	// to the node named [nodeName], which must be a primary network
+	// validator, from [start] to [end]. [start] and [rewardAddr] default
+	// as in AddValidator. Waits until the delegation is current.
+	// Returns the ID of the transaction that added the delegator.
+	// Returns ErrStopped if Stop() was previously called.
+	AddDelegator(
+		ctx context.Context,
+		nodeName string,
+		stakeAmount uint64,
+		start time.Time,
+		end time.Time,
+		rewardAddr string,
+	) (ids.ID, error)
+	// Returns a description of this network and its nodes,
+	// which can be marshalled to JSON.
+	// Returns ErrStopped if Stop() was previously called.
+	Describe() (Info, error)
 	// TODO add methods
 }
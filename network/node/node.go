@@ -71,6 +71,39 @@ func (c *Config) Validate(expectedNetworkID uint32) error {
 	}
 }
 
+// WithUpdates returns a copy of [c] where the implementation-specific
+// config, config file, C-Chain config file and flags given in [newConfig],
+// if any, replace the ones in [c]. Used to change the config of a node
+// that is restarted. Returns an error if [newConfig] gives a name or
+// staking key/cert different from [c]'s, since those identify the node.
+// If [newConfig] is nil, returns [c].
+func (c Config) WithUpdates(newConfig *Config) (Config, error) {
+	if newConfig == nil {
+		return c, nil
+	}
+	switch {
+	case newConfig.Name != "" && newConfig.Name != c.Name:
+		return Config{}, fmt.Errorf("can't rename node %q to %q", c.Name, newConfig.Name)
+	case newConfig.StakingKey != "" && newConfig.StakingKey != c.StakingKey:
+		return Config{}, fmt.Errorf("can't change staking key of node %q", c.Name)
+	case newConfig.StakingCert != "" && newConfig.StakingCert != c.StakingCert:
+		return Config{}, fmt.Errorf("can't change staking cert of node %q", c.Name)
+	}
+	if newConfig.ImplSpecificConfig != nil {
+		c.ImplSpecificConfig = newConfig.ImplSpecificConfig
+	}
+	if newConfig.ConfigFile != "" {
+		c.ConfigFile = newConfig.ConfigFile
+	}
+	if newConfig.CChainConfigFile != "" {
+		c.CChainConfigFile = newConfig.CChainConfigFile
+	}
+	if newConfig.Flags != nil {
+		c.Flags = newConfig.Flags
+	}
+	return c, nil
+}
+
 // Returns an error if config file [configFile] is invalid.
 // If len([configFile]) == 0, returns nil.
 func validateConfigFile(configFile []byte, expectedNetworkID uint32) error {
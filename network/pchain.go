@@ -0,0 +1,454 @@
+package network
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanche-network-runner/api"
+	"github.com/ava-labs/avalanche-network-runner/network/node"
+	avalancheapi "github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/config"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/platformvm/status"
+)
+
+const (
+	// Time between checks of whether a transaction was accepted
+	txStatusCheckFreq = time.Second
+	// How far in the future new stakers start.
+	// AvalancheGo requires at least 20 seconds.
+	stakerStartDelay = 30 * time.Second
+	// Weight of each validator added to a subnet by CreateSubnet
+	SubnetValidatorWeight = 1000
+	// Percent of delegators' rewards that validators added by
+	// AddValidator take. The minimum that AvalancheGo allows.
+	delegationFeeRate = 2
+	// How long Close waits for the keystore user to be deleted
+	deleteUserTimeout = 10 * time.Second
+)
+
+var ErrNoFundedKey = errors.New("network config doesn't give a funded private key")
+
+// PChainWallet issues P-Chain transactions through a node's keystore API.
+// The transactions are paid for by a funded key, which is imported
+// into a new keystore user on the node. Close deletes that user.
+type PChainWallet struct {
+	client api.Client
+	user   avalancheapi.UserPass
+	// P-Chain address of the funded key
+	address string
+}
+
+// NewPChainWallet creates a keystore user on the node that [client]
+// calls and imports [privateKey] into it. The wallet must be closed
+// once it's no longer used, so that the user doesn't pile up in the
+// node's keystore.
+func NewPChainWallet(ctx context.Context, client api.Client, privateKey string) (*PChainWallet, error) {
+	if privateKey == "" {
+		return nil, ErrNoFundedKey
+	}
+	username, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	password, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	user := avalancheapi.UserPass{
+		Username: "network-runner-" + username,
+		Password: password,
+	}
+	if _, err := client.KeystoreAPI().CreateUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("couldn't create keystore user: %w", err)
+	}
+	w := &PChainWallet{
+		client: client,
+		user:   user,
+	}
+	address, err := client.PChainAPI().ImportKey(ctx, user, privateKey)
+	if err != nil {
+		_ = w.Close()
+		return nil, fmt.Errorf("couldn't import funded key: %w", err)
+	}
+	w.address = address
+	return w, nil
+}
+
+// Close deletes the wallet's keystore user, along with the funded key
+// imported into it. It doesn't take the context the wallet was used
+// with, since the user should be deleted even if that context is done.
+func (w *PChainWallet) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), deleteUserTimeout)
+	defer cancel()
+	if _, err := w.client.KeystoreAPI().DeleteUser(ctx, w.user); err != nil {
+		return fmt.Errorf("couldn't delete keystore user %q: %w", w.user.Username, err)
+	}
+	return nil
+}
+
+// Address returns the P-Chain address of the funded key
+func (w *PChainWallet) Address() string {
+	return w.address
+}
+
+// CreateSubnet issues a transaction that creates a subnet controlled by
+// [threshold] of [controlKeys], which are P-Chain addresses, and waits
+// until it's accepted. If no control keys are given, the subnet is
+// controlled by the funded key. Returns the new subnet's ID.
+// To add validators to the subnet, the funded key must be enough to
+// reach [threshold].
+func (w *PChainWallet) CreateSubnet(ctx context.Context, controlKeys []string, threshold uint32) (ids.ID, error) {
+	if len(controlKeys) == 0 {
+		controlKeys = []string{w.address}
+		if threshold == 0 {
+			threshold = 1
+		}
+	}
+	txID, err := w.client.PChainAPI().CreateSubnet(ctx, w.user, []string{w.address}, w.address, controlKeys, threshold)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("couldn't issue create subnet tx: %w", err)
+	}
+	if err := w.WaitForTx(ctx, txID); err != nil {
+		return ids.Empty, err
+	}
+	// A subnet's ID is the ID of the tx that created it
+	return txID, nil
+}
+
+// AddSubnetValidator issues a transaction that makes [nodeID] a validator
+// of subnet [subnetID] with weight [weight], and waits until it's accepted.
+// The node must be a primary network validator. It starts validating the
+// subnet shortly after this returns, and stops when it stops validating
+// the primary network. Returns the ID of the transaction.
+func (w *PChainWallet) AddSubnetValidator(ctx context.Context, subnetID ids.ID, nodeID ids.ShortID, weight uint64) (ids.ID, error) {
+	validator, err := w.CurrentValidator(ctx, constants.PrimaryNetworkID, nodeID)
+	if err != nil {
+		return ids.Empty, err
+	}
+	startTime := time.Now().Add(stakerStartDelay)
+	txID, err := w.client.PChainAPI().AddSubnetValidator(
+		ctx,
+		w.user,
+		[]string{w.address},
+		w.address,
+		subnetID.String(),
+		nodeID.PrefixedString(constants.NodeIDPrefix),
+		weight,
+		uint64(startTime.Unix()),
+		uint64(validator.EndTime),
+	)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("couldn't issue add subnet validator tx for %s: %w", nodeID.PrefixedString(constants.NodeIDPrefix), err)
+	}
+	if err := w.WaitForTx(ctx, txID); err != nil {
+		return ids.Empty, err
+	}
+	return txID, nil
+}
+
+// AddValidator issues a transaction that makes [nodeID] a primary network
+// validator from [start] to [end], staking [stakeAmount] nAVAX of the
+// funded key. If [start] is zero, the node starts validating as soon as
+// AvalancheGo allows. Rewards go to [rewardAddr], or to the funded key if
+// it's empty. Waits until the transaction is accepted and the node is a
+// current validator. Returns the ID of the transaction.
+func (w *PChainWallet) AddValidator(
+	ctx context.Context,
+	nodeID ids.ShortID,
+	stakeAmount uint64,
+	start time.Time,
+	end time.Time,
+	rewardAddr string,
+) (ids.ID, error) {
+	start, rewardAddr = w.stakerDefaults(start, rewardAddr)
+	txID, err := w.client.PChainAPI().AddValidator(
+		ctx,
+		w.user,
+		[]string{w.address},
+		w.address,
+		rewardAddr,
+		nodeID.PrefixedString(constants.NodeIDPrefix),
+		stakeAmount,
+		uint64(start.Unix()),
+		uint64(end.Unix()),
+		delegationFeeRate,
+	)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("couldn't issue add validator tx for %s: %w", nodeID.PrefixedString(constants.NodeIDPrefix), err)
+	}
+	if err := w.WaitForTx(ctx, txID); err != nil {
+		return ids.Empty, err
+	}
+	if err := w.WaitForStaker(ctx, nodeID, txID); err != nil {
+		return ids.Empty, err
+	}
+	return txID, nil
+}
+
+// AddDelegator issues a transaction that delegates [stakeAmount] nAVAX of
+// the funded key to primary network validator [nodeID] from [start] to
+// [end]. If [start] is zero, the delegation starts as soon as AvalancheGo
+// allows. Rewards go to [rewardAddr], or to the funded key if it's empty.
+// Waits until the transaction is accepted and the delegation is current.
+// Returns the ID of the transaction.
+func (w *PChainWallet) AddDelegator(
+	ctx context.Context,
+	nodeID ids.ShortID,
+	stakeAmount uint64,
+	start time.Time,
+	end time.Time,
+	rewardAddr string,
+) (ids.ID, error) {
+	start, rewardAddr = w.stakerDefaults(start, rewardAddr)
+	txID, err := w.client.PChainAPI().AddDelegator(
+		ctx,
+		w.user,
+		[]string{w.address},
+		w.address,
+		rewardAddr,
+		nodeID.PrefixedString(constants.NodeIDPrefix),
+		stakeAmount,
+		uint64(start.Unix()),
+		uint64(end.Unix()),
+	)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("couldn't issue add delegator tx for %s: %w", nodeID.PrefixedString(constants.NodeIDPrefix), err)
+	}
+	if err := w.WaitForTx(ctx, txID); err != nil {
+		return ids.Empty, err
+	}
+	if err := w.WaitForStaker(ctx, nodeID, txID); err != nil {
+		return ids.Empty, err
+	}
+	return txID, nil
+}
+
+// Returns [start], or the earliest start time AvalancheGo allows if it's
+// zero, and [rewardAddr], or the funded key's address if it's empty
+func (w *PChainWallet) stakerDefaults(start time.Time, rewardAddr string) (time.Time, string) {
+	if start.IsZero() {
+		start = time.Now().Add(stakerStartDelay)
+	}
+	if rewardAddr == "" {
+		rewardAddr = w.address
+	}
+	return start, rewardAddr
+}
+
+// WaitForStaker blocks until the validation or delegation added by
+// transaction [txID] to primary network validator [nodeID] is current.
+// Returns an error if [ctx] is done first.
+func (w *PChainWallet) WaitForStaker(ctx context.Context, nodeID ids.ShortID, txID ids.ID) error {
+	for {
+		validatorsIntf, err := w.client.PChainAPI().GetCurrentValidators(ctx, constants.PrimaryNetworkID, []ids.ShortID{nodeID})
+		if err != nil {
+			return fmt.Errorf("couldn't get current validators: %w", err)
+		}
+		var validators []platformvm.APIPrimaryValidator
+		if err := parseStakers(validatorsIntf, &validators); err != nil {
+			return err
+		}
+		for _, validator := range validators {
+			if validator.TxID == txID {
+				return nil
+			}
+			for _, delegator := range validator.Delegators {
+				if delegator.TxID == txID {
+					return nil
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("staker added by tx %s isn't current: %w", txID, ctx.Err())
+		case <-time.After(txStatusCheckFreq):
+		}
+	}
+}
+
+// CreateBlockchain issues a transaction that creates a blockchain named
+// [name] on subnet [subnetID], running the VM [vmID] with genesis
+// [genesis], and waits until it's accepted. The funded key must be
+// enough to reach the subnet's threshold. Returns the new blockchain's ID.
+func (w *PChainWallet) CreateBlockchain(
+	ctx context.Context,
+	subnetID ids.ID,
+	vmID ids.ID,
+	name string,
+	genesis []byte,
+) (ids.ID, error) {
+	txID, err := w.client.PChainAPI().CreateBlockchain(
+		ctx,
+		w.user,
+		[]string{w.address},
+		w.address,
+		subnetID,
+		vmID.String(),
+		nil,
+		name,
+		genesis,
+	)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("couldn't issue create blockchain tx: %w", err)
+	}
+	if err := w.WaitForTx(ctx, txID); err != nil {
+		return ids.Empty, err
+	}
+	// A blockchain's ID is the ID of the tx that created it
+	return txID, nil
+}
+
+// CurrentValidator returns [nodeID]'s current validation of subnet
+// [subnetID]. Returns an error if [nodeID] isn't a current validator.
+func (w *PChainWallet) CurrentValidator(ctx context.Context, subnetID ids.ID, nodeID ids.ShortID) (*platformvm.APIStaker, error) {
+	validatorsIntf, err := w.client.PChainAPI().GetCurrentValidators(ctx, subnetID, []ids.ShortID{nodeID})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get current validators: %w", err)
+	}
+	var validators []platformvm.APIStaker
+	if err := parseStakers(validatorsIntf, &validators); err != nil {
+		return nil, err
+	}
+	nodeIDStr := nodeID.PrefixedString(constants.NodeIDPrefix)
+	for _, validator := range validators {
+		if validator.NodeID == nodeIDStr {
+			return &validator, nil
+		}
+	}
+	return nil, fmt.Errorf("%s isn't a current validator of subnet %s", nodeIDStr, subnetID)
+}
+
+// SubnetValidators returns the IDs of the nodes that are current or
+// pending validators of subnet [subnetID]
+func (w *PChainWallet) SubnetValidators(ctx context.Context, subnetID ids.ID) ([]ids.ShortID, error) {
+	currentIntf, err := w.client.PChainAPI().GetCurrentValidators(ctx, subnetID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get current validators: %w", err)
+	}
+	var current []platformvm.APIStaker
+	if err := parseStakers(currentIntf, &current); err != nil {
+		return nil, err
+	}
+	pendingIntf, _, err := w.client.PChainAPI().GetPendingValidators(ctx, subnetID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get pending validators: %w", err)
+	}
+	var pending []platformvm.APIStaker
+	if err := parseStakers(pendingIntf, &pending); err != nil {
+		return nil, err
+	}
+	seen := ids.ShortSet{}
+	nodeIDs := []ids.ShortID{}
+	for _, validator := range append(current, pending...) {
+		nodeID, err := ids.ShortFromPrefixedString(validator.NodeID, constants.NodeIDPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse node ID %q: %w", validator.NodeID, err)
+		}
+		if !seen.Contains(nodeID) {
+			seen.Add(nodeID)
+			nodeIDs = append(nodeIDs, nodeID)
+		}
+	}
+	return nodeIDs, nil
+}
+
+// WaitForTx blocks until transaction [txID] is committed.
+// Returns an error if it's aborted or dropped, or if [ctx] is done first.
+func (w *PChainWallet) WaitForTx(ctx context.Context, txID ids.ID) error {
+	for {
+		reply, err := w.client.PChainAPI().GetTxStatus(ctx, txID, true)
+		if err != nil {
+			return fmt.Errorf("couldn't get status of tx %s: %w", txID, err)
+		}
+		switch reply.Status {
+		case status.Committed:
+			return nil
+		case status.Aborted, status.Dropped:
+			if reply.Reason != "" {
+				return fmt.Errorf("tx %s was %s: %s", txID, reply.Status, reply.Reason)
+			}
+			return fmt.Errorf("tx %s was %s", txID, reply.Status)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("tx %s wasn't accepted: %w", txID, ctx.Err())
+		case <-time.After(txStatusCheckFreq):
+		}
+	}
+}
+
+// WhitelistSubnet returns a copy of [nodeConfig]'s flags where the
+// whitelisted subnets flag also has [subnetID]. The subnets already
+// whitelisted in [nodeConfig]'s flags or, if not there, in its config
+// file, stay whitelisted.
+func WhitelistSubnet(nodeConfig node.Config, subnetID ids.ID) (map[string]interface{}, error) {
+	whitelisted, ok := nodeConfig.Flags[config.WhitelistedSubnetsKey]
+	if !ok && len(nodeConfig.ConfigFile) != 0 {
+		var configFile map[string]interface{}
+		if err := json.Unmarshal([]byte(nodeConfig.ConfigFile), &configFile); err != nil {
+			return nil, fmt.Errorf("couldn't unmarshal config file: %w", err)
+		}
+		whitelisted = configFile[config.WhitelistedSubnetsKey]
+	}
+	var subnets []string
+	if whitelisted != nil {
+		whitelistedStr, ok := whitelisted.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected flag %q to be string but got %T", config.WhitelistedSubnetsKey, whitelisted)
+		}
+		for _, subnet := range strings.Split(whitelistedStr, ",") {
+			if subnet = strings.TrimSpace(subnet); subnet != "" {
+				subnets = append(subnets, subnet)
+			}
+		}
+	}
+	isWhitelisted := false
+	for _, subnet := range subnets {
+		if subnet == subnetID.String() {
+			isWhitelisted = true
+			break
+		}
+	}
+	if !isWhitelisted {
+		subnets = append(subnets, subnetID.String())
+	}
+
+	flags := make(map[string]interface{}, len(nodeConfig.Flags)+1)
+	for flagName, flagVal := range nodeConfig.Flags {
+		flags[flagName] = flagVal
+	}
+	flags[config.WhitelistedSubnetsKey] = strings.Join(subnets, ",")
+	return flags, nil
+}
+
+// Parses the stakers returned by the P-Chain API client, which returns
+// each staker as a JSON object, into [stakers], which must be a pointer
+// to a slice of a staker type such as platformvm.APIStaker
+func parseStakers(stakersIntf []interface{}, stakers interface{}) error {
+	stakersBytes, err := json.Marshal(stakersIntf)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal stakers: %w", err)
+	}
+	if err := json.Unmarshal(stakersBytes, stakers); err != nil {
+		return fmt.Errorf("couldn't unmarshal stakers: %w", err)
+	}
+	return nil
+}
+
+// Returns a random hex string of [numBytes] bytes
+func randomHex(numBytes int) (string, error) {
+	b := make([]byte, numBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("couldn't generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
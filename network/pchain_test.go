@@ -0,0 +1,264 @@
+package network_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apimocks "github.com/ava-labs/avalanche-network-runner/api/mocks"
+	"github.com/ava-labs/avalanche-network-runner/network"
+	"github.com/ava-labs/avalanche-network-runner/network/node"
+	"github.com/ava-labs/avalanchego/config"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/platformvm/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testPrivateKey = "PrivateKey-ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN"
+	testAddress    = "P-custom18jma8ppw3nhx5r4ap8clazz0dps7rv5u9xde7p"
+)
+
+// Returns an API client whose keystore API accepts new users and
+// whose P-Chain API imports the test key and reports each tx
+// as having [txStatus]
+func newMockPChainAPIClient(txStatus status.Status) (*apimocks.Client, *apimocks.PChainClient) {
+	keystoreClient := &apimocks.KeystoreClient{}
+	keystoreClient.On("CreateUser", mock.Anything, mock.Anything).Return(true, nil)
+	keystoreClient.On("DeleteUser", mock.Anything, mock.Anything).Return(true, nil)
+	pChainClient := &apimocks.PChainClient{}
+	pChainClient.On("ImportKey", mock.Anything, mock.Anything, testPrivateKey).Return(testAddress, nil)
+	pChainClient.On("GetTxStatus", mock.Anything, mock.Anything, true).Return(&platformvm.GetTxStatusResponse{Status: txStatus}, nil)
+	client := &apimocks.Client{}
+	client.On("KeystoreAPI").Return(keystoreClient)
+	client.On("PChainAPI").Return(pChainClient)
+	return client, pChainClient
+}
+
+func TestPChainWallet(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	// A funded key is required
+	client, _ := newMockPChainAPIClient(status.Committed)
+	_, err := network.NewPChainWallet(ctx, client, "")
+	assert.ErrorIs(err, network.ErrNoFundedKey)
+
+	wallet, err := network.NewPChainWallet(ctx, client, testPrivateKey)
+	assert.NoError(err)
+	assert.EqualValues(testAddress, wallet.Address())
+
+	// With no control keys, the funded key controls the subnet
+	subnetID := ids.GenerateTestID()
+	client, pChainClient := newMockPChainAPIClient(status.Committed)
+	pChainClient.On("CreateSubnet", mock.Anything, mock.Anything, []string{testAddress}, testAddress, []string{testAddress}, uint32(1)).Return(subnetID, nil)
+	wallet, err = network.NewPChainWallet(ctx, client, testPrivateKey)
+	assert.NoError(err)
+	gotSubnetID, err := wallet.CreateSubnet(ctx, nil, 0)
+	assert.NoError(err)
+	assert.EqualValues(subnetID, gotSubnetID)
+
+	// Subnet validators stop when they stop validating the primary network
+	nodeID := ids.GenerateTestShortID()
+	nodeIDStr := nodeID.PrefixedString(constants.NodeIDPrefix)
+	pChainClient.On("GetCurrentValidators", mock.Anything, constants.PrimaryNetworkID, []ids.ShortID{nodeID}).Return(
+		[]interface{}{
+			map[string]interface{}{"nodeID": ids.GenerateTestShortID().PrefixedString(constants.NodeIDPrefix), "endTime": "100"},
+			map[string]interface{}{"nodeID": nodeIDStr, "endTime": "12345"},
+		},
+		nil,
+	)
+	txID := ids.GenerateTestID()
+	pChainClient.On("AddSubnetValidator", mock.Anything, mock.Anything, []string{testAddress}, testAddress, subnetID.String(), nodeIDStr, uint64(network.SubnetValidatorWeight), mock.Anything, uint64(12345)).Return(txID, nil)
+	gotTxID, err := wallet.AddSubnetValidator(ctx, subnetID, nodeID, network.SubnetValidatorWeight)
+	assert.NoError(err)
+	assert.EqualValues(txID, gotTxID)
+
+	// Only primary network validators can validate a subnet
+	otherNodeID := ids.GenerateTestShortID()
+	pChainClient.On("GetCurrentValidators", mock.Anything, constants.PrimaryNetworkID, []ids.ShortID{otherNodeID}).Return([]interface{}{}, nil)
+	_, err = wallet.AddSubnetValidator(ctx, subnetID, otherNodeID, network.SubnetValidatorWeight)
+	assert.Error(err)
+
+	// Aborted txs are errors
+	client, pChainClient = newMockPChainAPIClient(status.Aborted)
+	pChainClient.On("CreateSubnet", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(subnetID, nil)
+	wallet, err = network.NewPChainWallet(ctx, client, testPrivateKey)
+	assert.NoError(err)
+	_, err = wallet.CreateSubnet(ctx, []string{testAddress}, 1)
+	assert.Error(err)
+
+	// Processing txs are waited for until [ctx] is done
+	client, _ = newMockPChainAPIClient(status.Processing)
+	wallet, err = network.NewPChainWallet(ctx, client, testPrivateKey)
+	assert.NoError(err)
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(wallet.WaitForTx(cancelledCtx, txID), context.Canceled)
+}
+
+func TestWhitelistSubnet(t *testing.T) {
+	assert := assert.New(t)
+	subnetID := ids.GenerateTestID()
+	otherSubnetID := ids.GenerateTestID()
+
+	// No subnets whitelisted yet
+	nodeConfig := node.Config{
+		Flags: map[string]interface{}{
+			"other-flag": "value",
+		},
+	}
+	flags, err := network.WhitelistSubnet(nodeConfig, subnetID)
+	assert.NoError(err)
+	assert.EqualValues(map[string]interface{}{
+		"other-flag":                 "value",
+		config.WhitelistedSubnetsKey: subnetID.String(),
+	}, flags)
+	// The node's config isn't modified
+	assert.Len(nodeConfig.Flags, 1)
+
+	// Subnets whitelisted in the config file stay whitelisted
+	nodeConfig = node.Config{
+		ConfigFile: `{"` + config.WhitelistedSubnetsKey + `":"` + otherSubnetID.String() + `"}`,
+	}
+	flags, err = network.WhitelistSubnet(nodeConfig, subnetID)
+	assert.NoError(err)
+	assert.EqualValues(otherSubnetID.String()+","+subnetID.String(), flags[config.WhitelistedSubnetsKey])
+
+	// The flag takes precedence over the config file,
+	// and a subnet isn't whitelisted twice
+	nodeConfig.Flags = map[string]interface{}{
+		config.WhitelistedSubnetsKey: subnetID.String(),
+	}
+	flags, err = network.WhitelistSubnet(nodeConfig, subnetID)
+	assert.NoError(err)
+	assert.EqualValues(subnetID.String(), flags[config.WhitelistedSubnetsKey])
+
+	// The flag must be a string
+	nodeConfig.Flags[config.WhitelistedSubnetsKey] = 1
+	_, err = network.WhitelistSubnet(nodeConfig, subnetID)
+	assert.Error(err)
+}
+
+func TestPChainWalletCreateBlockchain(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	client, pChainClient := newMockPChainAPIClient(status.Committed)
+	wallet, err := network.NewPChainWallet(ctx, client, testPrivateKey)
+	assert.NoError(err)
+
+	// Current and pending validators validate the subnet
+	subnetID := ids.GenerateTestID()
+	currentID := ids.GenerateTestShortID()
+	pendingID := ids.GenerateTestShortID()
+	pChainClient.On("GetCurrentValidators", mock.Anything, subnetID, mock.Anything).Return(
+		[]interface{}{map[string]interface{}{"nodeID": currentID.PrefixedString(constants.NodeIDPrefix)}},
+		nil,
+	)
+	pChainClient.On("GetPendingValidators", mock.Anything, subnetID, mock.Anything).Return(
+		[]interface{}{
+			map[string]interface{}{"nodeID": pendingID.PrefixedString(constants.NodeIDPrefix)},
+			// Duplicates are ignored
+			map[string]interface{}{"nodeID": currentID.PrefixedString(constants.NodeIDPrefix)},
+		},
+		[]interface{}{},
+		nil,
+	)
+	validators, err := wallet.SubnetValidators(ctx, subnetID)
+	assert.NoError(err)
+	assert.EqualValues([]ids.ShortID{currentID, pendingID}, validators)
+
+	vmID := ids.GenerateTestID()
+	genesis := []byte("genesis")
+	chainID := ids.GenerateTestID()
+	pChainClient.On("CreateBlockchain", mock.Anything, mock.Anything, []string{testAddress}, testAddress, subnetID, vmID.String(), mock.Anything, "chain", genesis).Return(chainID, nil)
+	gotChainID, err := wallet.CreateBlockchain(ctx, subnetID, vmID, "chain", genesis)
+	assert.NoError(err)
+	assert.EqualValues(chainID, gotChainID)
+}
+
+func TestPChainWalletStaking(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	client, pChainClient := newMockPChainAPIClient(status.Committed)
+	wallet, err := network.NewPChainWallet(ctx, client, testPrivateKey)
+	assert.NoError(err)
+
+	nodeID := ids.GenerateTestShortID()
+	nodeIDStr := nodeID.PrefixedString(constants.NodeIDPrefix)
+	validatorTxID := ids.GenerateTestID()
+	delegatorTxID := ids.GenerateTestID()
+	pChainClient.On("GetCurrentValidators", mock.Anything, constants.PrimaryNetworkID, []ids.ShortID{nodeID}).Return(
+		[]interface{}{
+			map[string]interface{}{
+				"txID":   validatorTxID.String(),
+				"nodeID": nodeIDStr,
+				"delegators": []interface{}{
+					map[string]interface{}{"txID": delegatorTxID.String(), "nodeID": nodeIDStr},
+				},
+			},
+		},
+		nil,
+	)
+	start := time.Now().Add(time.Minute)
+	end := start.Add(24 * time.Hour)
+	rewardAddr := "P-custom1reward"
+
+	// Rewards go to the funded key by default
+	pChainClient.On("AddValidator", mock.Anything, mock.Anything, []string{testAddress}, testAddress, testAddress, nodeIDStr, uint64(2000), uint64(start.Unix()), uint64(end.Unix()), mock.Anything).Return(validatorTxID, nil)
+	txID, err := wallet.AddValidator(ctx, nodeID, 2000, start, end, "")
+	assert.NoError(err)
+	assert.EqualValues(validatorTxID, txID)
+
+	pChainClient.On("AddDelegator", mock.Anything, mock.Anything, []string{testAddress}, testAddress, rewardAddr, nodeIDStr, uint64(1000), uint64(start.Unix()), uint64(end.Unix())).Return(delegatorTxID, nil)
+	txID, err = wallet.AddDelegator(ctx, nodeID, 1000, start, end, rewardAddr)
+	assert.NoError(err)
+	assert.EqualValues(delegatorTxID, txID)
+
+	// Stakers that never become current are waited for until [ctx] is done
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(wallet.WaitForStaker(ctx, nodeID, ids.GenerateTestID()), context.DeadlineExceeded)
+}
+
+func TestPChainWalletClose(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	keystoreClient := &apimocks.KeystoreClient{}
+	keystoreClient.On("CreateUser", mock.Anything, mock.Anything).Return(true, nil)
+	keystoreClient.On("DeleteUser", mock.Anything, mock.Anything).Return(true, nil)
+	pChainClient := &apimocks.PChainClient{}
+	pChainClient.On("ImportKey", mock.Anything, mock.Anything, testPrivateKey).Return(testAddress, nil)
+	pChainClient.On("ImportKey", mock.Anything, mock.Anything, "bad key").Return("", errors.New("invalid key"))
+	client := &apimocks.Client{}
+	client.On("KeystoreAPI").Return(keystoreClient)
+	client.On("PChainAPI").Return(pChainClient)
+
+	// Closing the wallet deletes the user it created
+	wallet, err := network.NewPChainWallet(ctx, client, testPrivateKey)
+	assert.NoError(err)
+	keystoreClient.AssertNumberOfCalls(t, "DeleteUser", 0)
+	assert.NoError(wallet.Close())
+	keystoreClient.AssertNumberOfCalls(t, "DeleteUser", 1)
+	keystoreClient.AssertCalled(t, "DeleteUser", mock.Anything, keystoreClient.Calls[0].Arguments.Get(1))
+
+	// The user is deleted if the key can't be imported
+	_, err = network.NewPChainWallet(ctx, client, "bad key")
+	assert.Error(err)
+	keystoreClient.AssertNumberOfCalls(t, "DeleteUser", 2)
+
+	// Errors deleting the user are returned
+	keystoreClient = &apimocks.KeystoreClient{}
+	keystoreClient.On("CreateUser", mock.Anything, mock.Anything).Return(true, nil)
+	keystoreClient.On("DeleteUser", mock.Anything, mock.Anything).Return(false, errors.New("keystore is down"))
+	client = &apimocks.Client{}
+	client.On("KeystoreAPI").Return(keystoreClient)
+	client.On("PChainAPI").Return(pChainClient)
+	wallet, err = network.NewPChainWallet(ctx, client, testPrivateKey)
+	assert.NoError(err)
+	assert.Error(wallet.Close())
+}
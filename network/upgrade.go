@@ -0,0 +1,158 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/api/info"
+	"github.com/ava-labs/avalanchego/utils/wrappers"
+)
+
+// Time a batch of nodes has to be healthy after being upgraded
+// by RollingUpgrade, unless RollingUpgradeOptions says otherwise
+const defaultUpgradeHealthTimeout = 5 * time.Minute
+
+// NodeUpgrade is the result of upgrading a node with Network.UpgradeNode
+type NodeUpgrade struct {
+	NodeName string `json:"nodeName"`
+	// The version the node reported before it was upgraded.
+	// Nil if it couldn't be queried, e.g. because the node had exited.
+	OldVersion *info.GetNodeVersionReply `json:"oldVersion"`
+	// The version the node reported once it was healthy again.
+	// Nil if it didn't become healthy.
+	NewVersion *info.GetNodeVersionReply `json:"newVersion"`
+	// The binary path or image tag the node ran before, which
+	// UpgradeNode can be given to downgrade the node again
+	PreviousBinaryOrTag string `json:"previousBinaryOrTag"`
+}
+
+// RollingUpgradeOptions says how Network.RollingUpgrade upgrades nodes
+type RollingUpgradeOptions struct {
+	// Names of the nodes to upgrade, in the order they're upgraded.
+	// If empty, all the nodes are upgraded, sorted by name.
+	NodeNames []string `json:"nodeNames,omitempty"`
+	// Number of nodes upgraded at the same time.
+	// If 0, nodes are upgraded one at a time.
+	BatchSize int `json:"batchSize,omitempty"`
+	// Time each batch of nodes, and then the network, has to be
+	// healthy after the batch is upgraded. If 0, it's 5 minutes.
+	HealthTimeout time.Duration `json:"healthTimeout,omitempty"`
+	// If true and a batch or the network isn't healthy in time,
+	// the nodes upgraded so far, including the ones in that batch,
+	// are downgraded, in the reverse order.
+	Rollback bool `json:"rollback,omitempty"`
+}
+
+// RollingUpgrade upgrades the nodes of [n] with n.UpgradeNode as
+// described by Network.RollingUpgrade.
+// Network implementations can use this to implement Network.RollingUpgrade.
+func RollingUpgrade(ctx context.Context, n Network, newVersion string, opts RollingUpgradeOptions) ([]*NodeUpgrade, error) {
+	switch {
+	case opts.BatchSize < 0:
+		return nil, fmt.Errorf("batch size must be non-negative but is %d", opts.BatchSize)
+	case opts.HealthTimeout < 0:
+		return nil, fmt.Errorf("health timeout must be non-negative but is %s", opts.HealthTimeout)
+	}
+	batchSize := opts.BatchSize
+	if batchSize == 0 {
+		batchSize = 1
+	}
+	healthTimeout := opts.HealthTimeout
+	if healthTimeout == 0 {
+		healthTimeout = defaultUpgradeHealthTimeout
+	}
+	nodeNames := opts.NodeNames
+	if len(nodeNames) == 0 {
+		var err error
+		nodeNames, err = n.GetNodeNames()
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(nodeNames)
+	}
+
+	upgrades := []*NodeUpgrade{}
+	for start := 0; start < len(nodeNames); start += batchSize {
+		end := start + batchSize
+		if end > len(nodeNames) {
+			end = len(nodeNames)
+		}
+		batch := nodeNames[start:end]
+		batchUpgrades, err := upgradeBatch(ctx, n, newVersion, batch, healthTimeout)
+		upgrades = append(upgrades, batchUpgrades...)
+		if err != nil {
+			err = fmt.Errorf("couldn't upgrade nodes %v: %w", batch, err)
+			if opts.Rollback {
+				if rollbackErr := rollback(ctx, n, upgrades, healthTimeout); rollbackErr != nil {
+					return upgrades, fmt.Errorf("%w; rolling back failed: %s", err, rollbackErr)
+				}
+				return upgrades, fmt.Errorf("%w; rolled back upgraded nodes", err)
+			}
+			return upgrades, err
+		}
+	}
+	return upgrades, nil
+}
+
+// Upgrades the nodes named in [batch] at the same time, then waits for
+// [n] to be healthy. Each node, and then [n], has [healthTimeout] to
+// be healthy. Returns the upgrades of the nodes that were restarted.
+func upgradeBatch(
+	ctx context.Context,
+	n Network,
+	newVersion string,
+	batch []string,
+	healthTimeout time.Duration,
+) ([]*NodeUpgrade, error) {
+	upgrades := make([]*NodeUpgrade, len(batch))
+	errs := make([]error, len(batch))
+	wg := sync.WaitGroup{}
+	for i, nodeName := range batch {
+		i, nodeName := i, nodeName
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			upgradeCtx, cancel := context.WithTimeout(ctx, healthTimeout)
+			defer cancel()
+			upgrades[i], errs[i] = n.UpgradeNode(upgradeCtx, nodeName, newVersion)
+		}()
+	}
+	wg.Wait()
+
+	upgraded := make([]*NodeUpgrade, 0, len(batch))
+	for _, upgrade := range upgrades {
+		if upgrade != nil {
+			upgraded = append(upgraded, upgrade)
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return upgraded, err
+		}
+	}
+	healthyCtx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	if err := <-n.Healthy(healthyCtx); err != nil {
+		return upgraded, fmt.Errorf("network isn't healthy: %w", err)
+	}
+	return upgraded, nil
+}
+
+// Downgrades the nodes of [upgrades] in the reverse order.
+// Each node has [healthTimeout] to be healthy again.
+func rollback(ctx context.Context, n Network, upgrades []*NodeUpgrade, healthTimeout time.Duration) error {
+	errs := wrappers.Errs{}
+	for i := len(upgrades) - 1; i >= 0; i-- {
+		upgrade := upgrades[i]
+		downgradeCtx, cancel := context.WithTimeout(ctx, healthTimeout)
+		_, err := n.UpgradeNode(downgradeCtx, upgrade.NodeName, upgrade.PreviousBinaryOrTag)
+		cancel()
+		if err != nil {
+			errs.Add(fmt.Errorf("couldn't downgrade node %q: %w", upgrade.NodeName, err))
+		}
+	}
+	return errs.Err
+}
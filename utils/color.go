@@ -51,6 +51,9 @@ func (c *colorPicker) NextColor() logging.Color {
 // ColorAndPrepend reads each line from [reader], prepends it
 // with [prependText] and colors it with [color], and then prints the
 // prepended/colored line to [writer].
+//
+// Deprecated: a local network now colors and prefixes its nodes' output
+// itself, which a node's config can redirect or turn off.
 func ColorAndPrepend(reader io.Reader, writer io.Writer, prependText string, color logging.Color) {
 	scanner := bufio.NewScanner(reader)
 	go func(scanner *bufio.Scanner) {